@@ -0,0 +1,171 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbcompress
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// CodecID identifies the algorithm a sequencer batch payload was compressed with.
+// It's written into batches, so existing IDs must never be renumbered.
+type CodecID uint8
+
+const (
+	CodecBrotli CodecID = 0
+	CodecNone   CodecID = 1
+	CodecZstd   CodecID = 2
+)
+
+// Writer is a streaming compressor. Flush must make everything written so far
+// decodable so that callers can measure the compressed size as they go.
+type Writer interface {
+	io.Writer
+	Flush() error
+	Close() error
+}
+
+type Codec interface {
+	ID() CodecID
+	Name() string
+	NewWriter(w io.Writer, level int) (Writer, error)
+	Decompress(input []byte, maxSize int) ([]byte, error)
+}
+
+var ErrUnknownCodec = errors.New("unknown compression codec")
+
+var (
+	codecsMutex  sync.RWMutex
+	codecsByID   = make(map[CodecID]Codec)
+	codecsByName = make(map[string]Codec)
+)
+
+func init() {
+	registerCodec(brotliCodec{})
+	registerCodec(noneCodec{})
+	registerCodec(zstdCodec{})
+}
+
+// registerCodec makes a codec available for compression and decompression.
+// Codecs are part of the chain's state transition, as every node must decode
+// batches the same way, so the set of codecs is fixed.
+func registerCodec(codec Codec) {
+	codecsMutex.Lock()
+	defer codecsMutex.Unlock()
+	codecsByID[codec.ID()] = codec
+	codecsByName[strings.ToLower(codec.Name())] = codec
+}
+
+func CodecByID(id CodecID) (Codec, error) {
+	codecsMutex.RLock()
+	defer codecsMutex.RUnlock()
+	codec, ok := codecsByID[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: id %v", ErrUnknownCodec, id)
+	}
+	return codec, nil
+}
+
+func CodecByName(name string) (Codec, error) {
+	codecsMutex.RLock()
+	defer codecsMutex.RUnlock()
+	codec, ok := codecsByName[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("%w: \"%v\"", ErrUnknownCodec, name)
+	}
+	return codec, nil
+}
+
+func CodecNames() []string {
+	codecsMutex.RLock()
+	defer codecsMutex.RUnlock()
+	names := make([]string, 0, len(codecsByName))
+	for name := range codecsByName {
+		names = append(names, name)
+	}
+	return names
+}
+
+func DecompressWithCodec(id CodecID, input []byte, maxSize int) ([]byte, error) {
+	codec, err := CodecByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return codec.Decompress(input, maxSize)
+}
+
+type brotliCodec struct{}
+
+func (brotliCodec) ID() CodecID  { return CodecBrotli }
+func (brotliCodec) Name() string { return "brotli" }
+
+func (brotliCodec) NewWriter(w io.Writer, level int) (Writer, error) {
+	return brotli.NewWriterLevel(w, level), nil
+}
+
+func (brotliCodec) Decompress(input []byte, maxSize int) ([]byte, error) {
+	return Decompress(input, maxSize)
+}
+
+type noneCodec struct{}
+
+func (noneCodec) ID() CodecID  { return CodecNone }
+func (noneCodec) Name() string { return "none" }
+
+func (noneCodec) NewWriter(w io.Writer, _ int) (Writer, error) {
+	return nopWriter{w}, nil
+}
+
+func (noneCodec) Decompress(input []byte, maxSize int) ([]byte, error) {
+	if len(input) > maxSize {
+		return nil, fmt.Errorf("result too large: %d", len(input))
+	}
+	return append([]byte{}, input...), nil
+}
+
+type nopWriter struct {
+	io.Writer
+}
+
+func (nopWriter) Flush() error { return nil }
+func (nopWriter) Close() error { return nil }
+
+type zstdCodec struct{}
+
+func (zstdCodec) ID() CodecID  { return CodecZstd }
+func (zstdCodec) Name() string { return "zstd" }
+
+// NewWriter interprets the level on the zstd scale (1 to 22).
+func (zstdCodec) NewWriter(w io.Writer, level int) (Writer, error) {
+	return zstd.NewWriter(w,
+		zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)),
+		zstd.WithEncoderConcurrency(1),
+	)
+}
+
+func (zstdCodec) Decompress(input []byte, maxSize int) ([]byte, error) {
+	decoder, err := zstd.NewReader(bytes.NewReader(input),
+		zstd.WithDecoderConcurrency(1),
+		zstd.WithDecoderMaxMemory(uint64(maxSize)+1),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer decoder.Close()
+	output, err := io.ReadAll(io.LimitReader(decoder, int64(maxSize)+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed decompression: %w", err)
+	}
+	if len(output) > maxSize {
+		return nil, fmt.Errorf("result too large: %d", len(output))
+	}
+	return output, nil
+}
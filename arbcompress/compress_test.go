@@ -5,6 +5,8 @@ package arbcompress
 
 import (
 	"bytes"
+	"fmt"
+	"os"
 	"testing"
 
 	"github.com/offchainlabs/nitro/util/testhelpers"
@@ -48,3 +50,94 @@ func TestArbCompress(t *testing.T) {
 	// test empty data:
 	testCompressDecompress(t, []byte{})
 }
+
+func testCodecRoundTrip(t *testing.T, codec Codec, data []byte) {
+	var buf bytes.Buffer
+	writer, err := codec.NewWriter(&buf, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	res, err := DecompressWithCodec(codec.ID(), buf.Bytes(), len(data)*2+64)
+	if err != nil {
+		t.Fatal(codec.Name(), err)
+	}
+	if !bytes.Equal(res, data) {
+		t.Fatal(codec.Name(), " results differ ", res, " vs. ", data)
+	}
+}
+
+func TestCodecs(t *testing.T) {
+	asciiData := []byte("This is a long and repetitive string. Yadda yadda yadda yadda yadda. The quick brown fox jumped over the lazy dog.")
+	for i := 0; i < 8; i++ {
+		asciiData = append(asciiData, asciiData...)
+	}
+	source := testhelpers.NewPseudoRandomDataSource(t, 0)
+	randData := source.GetData(2500)
+
+	for _, name := range []string{"brotli", "zstd", "none"} {
+		codec, err := CodecByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testCodecRoundTrip(t, codec, asciiData)
+		testCodecRoundTrip(t, codec, randData)
+		testCodecRoundTrip(t, codec, []byte{})
+	}
+
+	if _, err := CodecByID(0xff); err == nil {
+		t.Fatal("expected unknown codec to be rejected")
+	}
+	codec, err := CodecByName("none")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := codec.Decompress(randData, len(randData)-1); err == nil {
+		t.Fatal("expected oversized output to be rejected")
+	}
+}
+
+// BenchmarkCodecs compresses the file in ARBCOMPRESS_BENCH_DATA (e.g. a dump of real batch segments)
+// with every registered codec, and reports the compression ratio alongside the speed.
+func BenchmarkCodecs(b *testing.B) {
+	path := os.Getenv("ARBCOMPRESS_BENCH_DATA")
+	if path == "" {
+		b.Skip("ARBCOMPRESS_BENCH_DATA not set")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, name := range CodecNames() {
+		codec, err := CodecByName(name)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, level := range []int{4, 6, 11} {
+			b.Run(fmt.Sprintf("%v-%v", name, level), func(b *testing.B) {
+				b.SetBytes(int64(len(data)))
+				var compressedLen int
+				for i := 0; i < b.N; i++ {
+					var buf bytes.Buffer
+					writer, err := codec.NewWriter(&buf, level)
+					if err != nil {
+						b.Fatal(err)
+					}
+					if _, err := writer.Write(data); err != nil {
+						b.Fatal(err)
+					}
+					if err := writer.Close(); err != nil {
+						b.Fatal(err)
+					}
+					compressedLen = buf.Len()
+				}
+				b.ReportMetric(float64(compressedLen)/float64(len(data)), "ratio")
+			})
+		}
+	}
+}
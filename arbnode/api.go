@@ -11,6 +11,8 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/offchainlabs/nitro/arbutil"
 	"github.com/offchainlabs/nitro/staker"
+	"github.com/offchainlabs/nitro/util/arbmath"
+	"github.com/offchainlabs/nitro/util/redisutil"
 	"github.com/offchainlabs/nitro/validator"
 )
 
@@ -57,3 +59,45 @@ func (a *BlockValidatorDebugAPI) ValidateMessageNumber(
 	result.Valid = valid
 	return result, err
 }
+
+type SeqCoordinatorAPI struct {
+	coordinator *SeqCoordinator
+}
+
+// History returns up to count of the latest chosen sequencer changes, newest first, or all of them if count is 0
+func (a *SeqCoordinatorAPI) History(ctx context.Context, count hexutil.Uint64) ([]redisutil.CoordinatorEvent, error) {
+	return a.coordinator.GetHistory(ctx, int(arbmath.SaturatingCast(uint64(count))))
+}
+
+type MessagePrunerAPI struct {
+	pruner *MessagePruner
+}
+
+// Prune starts pruning up to the latest confirmed state in the background, and returns right away.
+// dryRun defaults to the configured mode. Progress is reported by Status.
+func (a *MessagePrunerAPI) Prune(ctx context.Context, dryRun *bool) error {
+	if dryRun == nil {
+		dryRun = &a.pruner.config().DryRun
+	}
+	return a.pruner.PruneNow(*dryRun)
+}
+
+// Status returns the progress or result of the latest scheduled prune, and of the latest on-demand one
+func (a *MessagePrunerAPI) Status(ctx context.Context) MessagePrunerReport {
+	return a.pruner.Status()
+}
+
+type MaintenanceAPI struct {
+	runner *MaintenanceRunner
+}
+
+// Run starts the named maintenance tasks, or all of them if none are named, in the background.
+// A coordinated sequencer hands off the lockout first, as it does for scheduled maintenance.
+func (a *MaintenanceAPI) Run(ctx context.Context, tasks []string) error {
+	return a.runner.Trigger(tasks)
+}
+
+// Status returns the latest run of each maintenance task
+func (a *MaintenanceAPI) Status(ctx context.Context) MaintenanceStatus {
+	return a.runner.Status()
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/offchainlabs/nitro/arbcompress"
 	"github.com/offchainlabs/nitro/arbnode/dataposter"
 	"github.com/offchainlabs/nitro/arbnode/dataposter/storage"
 	"github.com/offchainlabs/nitro/arbnode/redislock"
@@ -55,6 +56,16 @@ type batchPosterPosition struct {
 	NextSeqNum          uint64
 }
 
+// decodeBatchPosterPosition decodes the data poster metadata of a batch for
+// the data poster admin API.
+func decodeBatchPosterPosition(meta []byte) (interface{}, error) {
+	var position batchPosterPosition
+	if err := rlp.DecodeBytes(meta, &position); err != nil {
+		return nil, err
+	}
+	return &position, nil
+}
+
 type BatchPoster struct {
 	stopwaiter.StopWaiter
 	l1Reader            *headerreader.HeaderReader
@@ -105,6 +116,7 @@ type BatchPosterConfig struct {
 	PollInterval time.Duration `koanf:"poll-interval" reload:"hot"`
 	// Batch posting error delay.
 	ErrorDelay         time.Duration               `koanf:"error-delay" reload:"hot"`
+	Compression        string                      `koanf:"compression" reload:"hot"`
 	CompressionLevel   int                         `koanf:"compression-level" reload:"hot"`
 	DASRetentionPeriod time.Duration               `koanf:"das-retention-period" reload:"hot"`
 	GasRefunderAddress string                      `koanf:"gas-refunder-address" reload:"hot"`
@@ -128,6 +140,12 @@ func (c *BatchPosterConfig) Validate() error {
 	if c.MaxSize <= 40 {
 		return errors.New("MaxBatchSize too small")
 	}
+	if err := c.ParentChainWallet.Validate(); err != nil {
+		return fmt.Errorf("invalid batch poster parent chain wallet: %w", err)
+	}
+	if _, err := arbcompress.CodecByName(c.Compression); err != nil {
+		return fmt.Errorf("invalid batch compression \"%v\" (available: %v)", c.Compression, strings.Join(arbcompress.CodecNames(), ", "))
+	}
 	if c.L1BlockBound == "" {
 		c.l1BlockBound = l1BlockBoundDefault
 	} else if c.L1BlockBound == "safe" {
@@ -154,6 +172,7 @@ func BatchPosterConfigAddOptions(prefix string, f *pflag.FlagSet) {
 	f.Bool(prefix+".wait-for-max-delay", DefaultBatchPosterConfig.WaitForMaxDelay, "wait for the max batch delay, even if the batch is full")
 	f.Duration(prefix+".poll-interval", DefaultBatchPosterConfig.PollInterval, "how long to wait after no batches are ready to be posted before checking again")
 	f.Duration(prefix+".error-delay", DefaultBatchPosterConfig.ErrorDelay, "how long to delay after error posting batch")
+	f.String(prefix+".compression", DefaultBatchPosterConfig.Compression, "batch compression codec (\"brotli\", \"zstd\" or \"none\")")
 	f.Int(prefix+".compression-level", DefaultBatchPosterConfig.CompressionLevel, "batch compression level")
 	f.Duration(prefix+".das-retention-period", DefaultBatchPosterConfig.DASRetentionPeriod, "In AnyTrust mode, the period which DASes are requested to retain the stored batches.")
 	f.String(prefix+".gas-refunder-address", DefaultBatchPosterConfig.GasRefunderAddress, "The gas refunder contract address (optional)")
@@ -175,6 +194,7 @@ var DefaultBatchPosterConfig = BatchPosterConfig{
 	ErrorDelay:         time.Second * 10,
 	MaxDelay:           time.Hour,
 	WaitForMaxDelay:    false,
+	Compression:        "brotli",
 	CompressionLevel:   brotli.BestCompression,
 	DASRetentionPeriod: time.Hour * 24 * 15,
 	GasRefunderAddress: "",
@@ -186,11 +206,12 @@ var DefaultBatchPosterConfig = BatchPosterConfig{
 }
 
 var DefaultBatchPosterL1WalletConfig = genericconf.WalletConfig{
-	Pathname:      "batch-poster-wallet",
-	Password:      genericconf.WalletConfigDefault.Password,
-	PrivateKey:    genericconf.WalletConfigDefault.PrivateKey,
-	Account:       genericconf.WalletConfigDefault.Account,
-	OnlyCreateKey: genericconf.WalletConfigDefault.OnlyCreateKey,
+	Pathname:       "batch-poster-wallet",
+	Password:       genericconf.WalletConfigDefault.Password,
+	PrivateKey:     genericconf.WalletConfigDefault.PrivateKey,
+	Account:        genericconf.WalletConfigDefault.Account,
+	OnlyCreateKey:  genericconf.WalletConfigDefault.OnlyCreateKey,
+	ExternalSigner: genericconf.WalletConfigDefault.ExternalSigner,
 }
 
 var TestBatchPosterConfig = BatchPosterConfig{
@@ -200,6 +221,7 @@ var TestBatchPosterConfig = BatchPosterConfig{
 	ErrorDelay:         time.Millisecond * 10,
 	MaxDelay:           0,
 	WaitForMaxDelay:    false,
+	Compression:        "brotli",
 	CompressionLevel:   2,
 	DASRetentionPeriod: time.Hour * 24 * 15,
 	GasRefunderAddress: "",
@@ -386,8 +408,9 @@ func (b *BatchPoster) getBatchPosterPosition(ctx context.Context, blockNum *big.
 var errBatchAlreadyClosed = errors.New("batch segments already closed")
 
 type batchSegments struct {
+	codec                 arbcompress.Codec
 	compressedBuffer      *bytes.Buffer
-	compressedWriter      *brotli.Writer
+	compressedWriter      arbcompress.Writer
 	rawSegments           [][]byte
 	timestamp             uint64
 	blockNum              uint64
@@ -408,7 +431,7 @@ type buildingBatch struct {
 	haveUsefulMessage bool
 }
 
-func newBatchSegments(firstDelayed uint64, config *BatchPosterConfig, backlog uint64) *batchSegments {
+func newBatchSegments(firstDelayed uint64, config *BatchPosterConfig, backlog uint64) (*batchSegments, error) {
 	compressedBuffer := bytes.NewBuffer(make([]byte, 0, config.MaxSize*2))
 	if config.MaxSize <= 40 {
 		panic("MaxBatchSize too small")
@@ -433,19 +456,32 @@ func newBatchSegments(firstDelayed uint64, config *BatchPosterConfig, backlog ui
 		)
 		recompressionLevel = compressionLevel
 	}
+	codec, err := arbcompress.CodecByName(config.Compression)
+	if err != nil {
+		return nil, err
+	}
+	compressedWriter, err := codec.NewWriter(compressedBuffer, compressionLevel)
+	if err != nil {
+		return nil, err
+	}
 	return &batchSegments{
+		codec:              codec,
 		compressedBuffer:   compressedBuffer,
-		compressedWriter:   brotli.NewWriterLevel(compressedBuffer, compressionLevel),
+		compressedWriter:   compressedWriter,
 		sizeLimit:          config.MaxSize - 40, // TODO
 		recompressionLevel: recompressionLevel,
 		rawSegments:        make([][]byte, 0, 128),
 		delayedMsg:         firstDelayed,
-	}
+	}, nil
 }
 
 func (s *batchSegments) recompressAll() error {
 	s.compressedBuffer = bytes.NewBuffer(make([]byte, 0, s.sizeLimit*2))
-	s.compressedWriter = brotli.NewWriterLevel(s.compressedBuffer, s.recompressionLevel)
+	compressedWriter, err := s.codec.NewWriter(s.compressedBuffer, s.recompressionLevel)
+	if err != nil {
+		return err
+	}
+	s.compressedWriter = compressedWriter
 	s.newUncompressedSize = 0
 	s.totalUncompressedSize = 0
 	for _, segment := range s.rawSegments {
@@ -623,8 +659,16 @@ func (s *batchSegments) CloseAndGetBytes() ([]byte, error) {
 		return nil, err
 	}
 	compressedBytes := s.compressedBuffer.Bytes()
-	fullMsg := make([]byte, 1, len(compressedBytes)+1)
-	fullMsg[0] = arbstate.BrotliMessageHeaderByte
+	if s.codec.ID() == arbcompress.CodecBrotli {
+		// Brotli batches keep the original header so older nodes can still read them
+		fullMsg := make([]byte, 1, len(compressedBytes)+1)
+		fullMsg[0] = arbstate.BrotliMessageHeaderByte
+		fullMsg = append(fullMsg, compressedBytes...)
+		return fullMsg, nil
+	}
+	fullMsg := make([]byte, 2, len(compressedBytes)+2)
+	fullMsg[0] = arbstate.CodecMessageHeaderFlag
+	fullMsg[1] = byte(s.codec.ID())
 	fullMsg = append(fullMsg, compressedBytes...)
 	return fullMsg, nil
 }
@@ -731,8 +775,15 @@ func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context) (bool, error)
 	}
 
 	if b.building == nil || b.building.startMsgCount != batchPosition.MessageCount {
+		segments, err := newBatchSegments(batchPosition.DelayedMessageCount, b.config(), b.backlog)
+		if err != nil {
+			return false, err
+		}
+		if segments.codec.ID() != arbcompress.CodecBrotli && !arbstate.CodecsEnabled(b.streamer.chainConfig) {
+			return false, fmt.Errorf("batch compression %v requires a chain initialized at ArbOS version %v or later", segments.codec.Name(), arbstate.CodecMessageArbOSVersion)
+		}
 		b.building = &buildingBatch{
-			segments:      newBatchSegments(batchPosition.DelayedMessageCount, b.config(), b.backlog),
+			segments:      segments,
 			msgCount:      batchPosition.MessageCount,
 			startMsgCount: batchPosition.MessageCount,
 		}
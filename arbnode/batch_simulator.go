@@ -0,0 +1,104 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/offchainlabs/nitro/arbutil"
+)
+
+type BatchSimulationResult struct {
+	Batches           uint64
+	Messages          uint64
+	UncompressedBytes uint64
+	CompressedBytes   uint64
+	EstimatedL1Gas    uint64
+}
+
+// SimulateBatches replays the stored messages in [start, end) through the same segment building
+// logic the BatchPoster uses, and reports what posting them with the given config would have cost.
+// Message timestamps stand in for the wall clock when applying MaxDelay, and batches are assumed
+// to be posted on-chain rather than to a DAS.
+// The L1 gas estimate is the calldata cost of each batch plus overheadGas per batch.
+func SimulateBatches(ctx context.Context, streamer *TransactionStreamer, config *BatchPosterConfig, start arbutil.MessageIndex, end arbutil.MessageIndex, overheadGas uint64) (*BatchSimulationResult, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	var delayedMsg uint64
+	if start > 0 {
+		prevMsg, err := streamer.GetMessage(start - 1)
+		if err != nil {
+			return nil, fmt.Errorf("error getting message %v: %w", start-1, err)
+		}
+		delayedMsg = prevMsg.DelayedMessagesRead
+	}
+	result := &BatchSimulationResult{}
+	pos := start
+	for pos < end {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		segments, err := newBatchSegments(delayedMsg, config, 0)
+		if err != nil {
+			return nil, err
+		}
+		batchStart := pos
+		var firstMsgTimestamp uint64
+		for pos < end {
+			msg, err := streamer.GetMessage(pos)
+			if err != nil {
+				return nil, fmt.Errorf("error getting message %v: %w", pos, err)
+			}
+			if pos == batchStart {
+				firstMsgTimestamp = msg.Message.Header.Timestamp
+			} else if config.MaxDelay > 0 && msg.Message.Header.Timestamp >= firstMsgTimestamp+uint64(config.MaxDelay.Seconds()) {
+				// the batch poster would've posted the batch by the time this message arrived
+				break
+			}
+			success, err := segments.AddMessage(msg)
+			if err != nil {
+				return nil, fmt.Errorf("error adding message %v to batch: %w", pos, err)
+			}
+			if !success {
+				// this batch is full
+				break
+			}
+			pos++
+		}
+		if pos == batchStart {
+			return nil, fmt.Errorf("message %v doesn't fit in an empty batch", pos)
+		}
+		sequencerMsg, err := segments.CloseAndGetBytes()
+		if err != nil {
+			return nil, err
+		}
+		if sequencerMsg == nil {
+			return nil, errors.New("batch simulation produced an empty batch")
+		}
+		delayedMsg = segments.delayedMsg
+		result.Batches++
+		result.Messages += uint64(pos - batchStart)
+		result.UncompressedBytes += uint64(segments.totalUncompressedSize)
+		result.CompressedBytes += uint64(len(sequencerMsg))
+		result.EstimatedL1Gas += overheadGas + calldataGas(sequencerMsg)
+	}
+	return result, nil
+}
+
+func calldataGas(data []byte) uint64 {
+	var gas uint64
+	for _, b := range data {
+		if b == 0 {
+			gas += params.TxDataZeroGas
+		} else {
+			gas += params.TxDataNonZeroGasEIP2028
+		}
+	}
+	return gas
+}
@@ -0,0 +1,76 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func setupSimulationStreamer(t *testing.T, messageCount uint64) *TransactionStreamer {
+	db := rawdb.NewMemoryDatabase()
+	source := testhelpers.NewPseudoRandomDataSource(t, 1)
+	for i := uint64(0); i < messageCount; i++ {
+		msg := arbostypes.MessageWithMetadata{
+			Message: &arbostypes.L1IncomingMessage{
+				Header: &arbostypes.L1IncomingMessageHeader{
+					Kind:        arbostypes.L1MessageType_L2Message,
+					BlockNumber: 1000 + i/10,
+					Timestamp:   1_000_000 + i*60,
+					L1BaseFee:   big.NewInt(0),
+				},
+				L2msg: source.GetData(200),
+			},
+			DelayedMessagesRead: 1,
+		}
+		data, err := rlp.EncodeToBytes(msg)
+		Require(t, err)
+		Require(t, db.Put(dbKey(messagePrefix, i), data))
+	}
+	return &TransactionStreamer{db: db}
+}
+
+func TestSimulateBatches(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	messageCount := uint64(500)
+	streamer := setupSimulationStreamer(t, messageCount)
+
+	config := TestBatchPosterConfig
+	config.MaxSize = 10_000
+	config.MaxDelay = time.Hour
+	bySize, err := SimulateBatches(ctx, streamer, &config, 1, arbutil.MessageIndex(messageCount), 0)
+	Require(t, err)
+	if bySize.Messages != messageCount-1 {
+		Fail(t, "simulated", bySize.Messages, "messages, expected", messageCount-1)
+	}
+	if bySize.Batches < 2 {
+		Fail(t, "expected random data to need multiple batches, got", bySize.Batches)
+	}
+	if bySize.CompressedBytes > bySize.Batches*uint64(config.MaxSize) {
+		Fail(t, "batches exceeded max size", bySize.CompressedBytes, bySize.Batches)
+	}
+	if bySize.EstimatedL1Gas < bySize.CompressedBytes*4 {
+		Fail(t, "L1 gas estimate", bySize.EstimatedL1Gas, "too low for", bySize.CompressedBytes, "bytes")
+	}
+
+	config.MaxSize = 1_000_000
+	config.MaxDelay = time.Minute * 10
+	byDelay, err := SimulateBatches(ctx, streamer, &config, 1, arbutil.MessageIndex(messageCount), 0)
+	Require(t, err)
+	expectedBatches := (messageCount - 1 + 9) / 10
+	if byDelay.Batches != expectedBatches {
+		Fail(t, "simulated", byDelay.Batches, "batches, expected", expectedBatches)
+	}
+}
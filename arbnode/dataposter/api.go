@@ -0,0 +1,117 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package dataposter
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/offchainlabs/nitro/arbnode/dataposter/storage"
+)
+
+const defaultAPIQueueLimit = 128
+
+// MetaDecoder decodes the opaque metadata the owner of a data poster stores
+// alongside each queued transaction, for display purposes.
+type MetaDecoder func(meta []byte) (interface{}, error)
+
+// DataPosterAPI is an admin API for inspecting the data poster queue and
+// manually intervening when a transaction is stuck.
+type DataPosterAPI struct {
+	dataPoster  *DataPoster
+	metaDecoder MetaDecoder
+}
+
+func NewDataPosterAPI(dataPoster *DataPoster, metaDecoder MetaDecoder) *DataPosterAPI {
+	return &DataPosterAPI{
+		dataPoster:  dataPoster,
+		metaDecoder: metaDecoder,
+	}
+}
+
+type QueuedTransactionResult struct {
+	Nonce           hexutil.Uint64  `json:"nonce"`
+	Hash            common.Hash     `json:"hash"`
+	To              *common.Address `json:"to"`
+	Gas             hexutil.Uint64  `json:"gas"`
+	GasFeeCap       *hexutil.Big    `json:"maxFeePerGas"`
+	GasTipCap       *hexutil.Big    `json:"maxPriorityFeePerGas"`
+	Sent            bool            `json:"sent"`
+	Created         time.Time       `json:"created"`
+	NextReplacement time.Time       `json:"nextReplacement"`
+	Meta            hexutil.Bytes   `json:"meta"`
+	DecodedMeta     interface{}     `json:"decodedMeta,omitempty"`
+	DecodeMetaError string          `json:"decodeMetaError,omitempty"`
+}
+
+func (a *DataPosterAPI) queuedTransactionResult(tx *storage.QueuedTransaction) *QueuedTransactionResult {
+	res := &QueuedTransactionResult{
+		Nonce:           hexutil.Uint64(tx.Data.Nonce),
+		To:              tx.Data.To,
+		Gas:             hexutil.Uint64(tx.Data.Gas),
+		GasFeeCap:       (*hexutil.Big)(tx.Data.GasFeeCap),
+		GasTipCap:       (*hexutil.Big)(tx.Data.GasTipCap),
+		Sent:            tx.Sent,
+		Created:         tx.Created,
+		NextReplacement: tx.NextReplacement,
+		Meta:            tx.Meta,
+	}
+	if tx.FullTx != nil {
+		res.Hash = tx.FullTx.Hash()
+	}
+	if a.metaDecoder != nil && len(tx.Meta) > 0 {
+		decoded, err := a.metaDecoder(tx.Meta)
+		if err != nil {
+			res.DecodeMetaError = err.Error()
+		} else {
+			res.DecodedMeta = decoded
+		}
+	}
+	return res
+}
+
+// Queue lists the queued transactions starting with startNonce (by default,
+// the oldest queued transaction), returning at most limit entries.
+func (a *DataPosterAPI) Queue(ctx context.Context, startNonce *hexutil.Uint64, limit *hexutil.Uint64) ([]*QueuedTransactionResult, error) {
+	var start uint64
+	if startNonce != nil {
+		start = uint64(*startNonce)
+	}
+	maxResults := uint64(defaultAPIQueueLimit)
+	if limit != nil {
+		maxResults = uint64(*limit)
+	}
+	queued, err := a.dataPoster.QueueContents(ctx, start, maxResults)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*QueuedTransactionResult, 0, len(queued))
+	for _, tx := range queued {
+		res = append(res, a.queuedTransactionResult(tx))
+	}
+	return res, nil
+}
+
+// ReplaceByFee replaces the queued transaction with the given nonce using the
+// given fee cap and (optionally) tip cap, returning the new transaction hash.
+func (a *DataPosterAPI) ReplaceByFee(ctx context.Context, nonce hexutil.Uint64, feeCap *hexutil.Big, tipCap *hexutil.Big) (common.Hash, error) {
+	tx, err := a.dataPoster.ReplaceByFee(ctx, uint64(nonce), feeCap.ToInt(), tipCap.ToInt())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
+// CancelNonce replaces the last queued transaction, which must have the given
+// nonce, by a zero value self-transfer, returning the new transaction hash.
+func (a *DataPosterAPI) CancelNonce(ctx context.Context, nonce hexutil.Uint64, feeCap *hexutil.Big, tipCap *hexutil.Big) (common.Hash, error) {
+	tx, err := a.dataPoster.CancelNonce(ctx, uint64(nonce), feeCap.ToInt(), tipCap.ToInt())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
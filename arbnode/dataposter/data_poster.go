@@ -108,6 +108,9 @@ func NewDataPoster(ctx context.Context, opts *DataPosterOpts) (*DataPoster, erro
 	if err != nil {
 		return nil, err
 	}
+	if _, err := newFeeStrategy(initConfig, opts.HeaderReader.Client()); err != nil {
+		return nil, err
+	}
 	if opts.HeaderReader.IsParentChainArbitrum() && !initConfig.UseNoOpStorage {
 		initConfig.UseNoOpStorage = true
 		log.Info("Disabling data poster storage, as parent chain appears to be an Arbitrum chain without a mempool")
@@ -259,14 +262,17 @@ func (p *DataPoster) feeAndTipCaps(ctx context.Context, nonce uint64, gasLimit u
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get latest nonce %v blocks ago (block %v): %w", config.NonceRbfSoftConfs, softConfBlock, err)
 	}
-	newFeeCap := new(big.Int).Mul(latestHeader.BaseFee, big.NewInt(2))
-	newFeeCap = arbmath.BigMax(newFeeCap, arbmath.FloatToBig(config.MinFeeCapGwei*params.GWei))
-
-	newTipCap, err := p.client.SuggestGasTipCap(ctx)
+	feeStrategy, err := newFeeStrategy(config, p.client)
 	if err != nil {
 		return nil, nil, err
 	}
-	newTipCap = arbmath.BigMax(newTipCap, arbmath.FloatToBig(config.MinTipCapGwei*params.GWei))
+	suggestion, err := feeStrategy.SuggestFees(ctx, latestHeader, backlogOfBatches)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%v fee strategy failed to suggest fees: %w", config.FeeStrategy, err)
+	}
+	newFeeCap := arbmath.BigMax(suggestion.BaseFeeCap, arbmath.FloatToBig(config.MinFeeCapGwei*params.GWei))
+
+	newTipCap := arbmath.BigMax(suggestion.TipCap, arbmath.FloatToBig(config.MinTipCapGwei*params.GWei))
 	newTipCap = arbmath.BigMin(newTipCap, arbmath.FloatToBig(config.MaxTipCapGwei*params.GWei))
 
 	hugeTipIncrease := false
@@ -284,13 +290,7 @@ func (p *DataPoster) feeAndTipCaps(ctx context.Context, nonce uint64, gasLimit u
 	}
 
 	elapsed := time.Since(dataCreatedAt)
-	// MaxFeeCap = (BacklogOfBatches^2 * UrgencyGWei^2 + TargetPriceGWei) * GWei
-	maxFeeCap :=
-		arbmath.FloatToBig(
-			(float64(arbmath.SquareUint(backlogOfBatches))*
-				arbmath.SquareFloat(config.UrgencyGwei) +
-				config.TargetPriceGwei) *
-				params.GWei)
+	maxFeeCap := suggestion.MaxFeeCap
 	if arbmath.BigGreaterThan(newFeeCap, maxFeeCap) {
 		log.Warn(
 			"reducing proposed fee cap to current maximum",
@@ -457,6 +457,128 @@ func (p *DataPoster) replaceTx(ctx context.Context, prevTx *storage.QueuedTransa
 	return p.sendTx(ctx, prevTx, &newTx)
 }
 
+// QueueContents returns at most maxResults queued transactions, starting with
+// the given nonce. The queue may include the most recently confirmed
+// transaction, which is kept as a reference point for the next nonce.
+func (p *DataPoster) QueueContents(ctx context.Context, startingNonce uint64, maxResults uint64) ([]*storage.QueuedTransaction, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.queue.FetchContents(ctx, startingNonce, maxResults)
+}
+
+// ReplaceByFee immediately replaces the queued transaction with the given
+// nonce by one with the given fee cap and tip cap. If tipCap is nil, the
+// previous tip cap is bumped by the minimum replacement increase.
+func (p *DataPoster) ReplaceByFee(ctx context.Context, nonce uint64, feeCap *big.Int, tipCap *big.Int) (*types.Transaction, error) {
+	return p.manualReplace(ctx, nonce, feeCap, tipCap, func(*storage.QueuedTransaction) error { return nil })
+}
+
+// CancelNonce replaces the last queued transaction, which must have the given
+// nonce, by a zero value transfer from the sender to itself, with the given fee
+// cap and tip cap. Its metadata is reset to that of the previous nonce, so the
+// next transaction (such as the batch poster's next batch) takes its place.
+// Several transactions are cancelled starting from the last one.
+func (p *DataPoster) CancelNonce(ctx context.Context, nonce uint64, feeCap *big.Int, tipCap *big.Int) (*types.Transaction, error) {
+	return p.manualReplace(ctx, nonce, feeCap, tipCap, func(tx *storage.QueuedTransaction) error {
+		last, err := p.queue.FetchLast(ctx)
+		if err != nil {
+			return fmt.Errorf("fetching last queued transaction: %w", err)
+		}
+		if last.Data.Nonce != nonce {
+			return fmt.Errorf("transactions up to nonce %v are queued after nonce %v, and must be cancelled first", last.Data.Nonce, nonce)
+		}
+		meta, err := p.metaBefore(ctx, nonce)
+		if err != nil {
+			return err
+		}
+		to := p.sender
+		tx.Meta = meta
+		tx.Data.To = &to
+		tx.Data.Value = new(big.Int)
+		tx.Data.Data = nil
+		tx.Data.AccessList = nil
+		tx.Data.Gas = params.TxGas
+		return nil
+	})
+}
+
+// metaBefore returns the metadata of the transaction before the given nonce,
+// from the queue, or else from the block the data poster's nonce was read at.
+// The mutex must be held by the caller.
+func (p *DataPoster) metaBefore(ctx context.Context, nonce uint64) ([]byte, error) {
+	if nonce > 0 {
+		queued, err := p.queue.FetchContents(ctx, nonce-1, 1)
+		if err != nil {
+			return nil, fmt.Errorf("fetching queued transaction: %w", err)
+		}
+		if len(queued) > 0 && queued[0].Data.Nonce == nonce-1 {
+			return queued[0].Meta, nil
+		}
+	}
+	if p.lastBlock == nil || p.nonce != nonce {
+		return nil, fmt.Errorf("metadata before nonce %v isn't known", nonce)
+	}
+	return p.metadataRetriever(ctx, p.lastBlock)
+}
+
+func (p *DataPoster) manualReplace(ctx context.Context, nonce uint64, feeCap *big.Int, tipCap *big.Int, modify func(*storage.QueuedTransaction) error) (*types.Transaction, error) {
+	if feeCap == nil || feeCap.Sign() <= 0 {
+		return nil, errors.New("a positive fee cap must be specified")
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	queued, err := p.queue.FetchContents(ctx, nonce, 1)
+	if err != nil {
+		return nil, fmt.Errorf("fetching queued transaction: %w", err)
+	}
+	if len(queued) == 0 || queued[0].Data.Nonce != nonce {
+		return nil, fmt.Errorf("no transaction with nonce %v in the queue", nonce)
+	}
+	prevTx := queued[0]
+	unconfirmedNonce, err := p.client.NonceAt(ctx, p.sender, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting latest nonce: %w", err)
+	}
+	if nonce < unconfirmedNonce {
+		return nil, fmt.Errorf("transaction with nonce %v was already included in a block (latest nonce %v)", nonce, unconfirmedNonce)
+	}
+
+	if tipCap == nil {
+		tipCap = arbmath.BigMin(arbmath.BigMulByBips(prevTx.Data.GasTipCap, minRbfIncrease), feeCap)
+	}
+	if arbmath.BigGreaterThan(tipCap, feeCap) {
+		return nil, fmt.Errorf("tip cap %v exceeds fee cap %v", tipCap, feeCap)
+	}
+	minFeeCap := arbmath.BigMulByBips(prevTx.Data.GasFeeCap, minRbfIncrease)
+	minTipCap := arbmath.BigMulByBips(prevTx.Data.GasTipCap, minRbfIncrease)
+	if feeCap.Cmp(minFeeCap) < 0 || tipCap.Cmp(minTipCap) < 0 {
+		return nil, fmt.Errorf("fee cap %v and tip cap %v must be at least %v and %v to replace transaction", feeCap, tipCap, minFeeCap, minTipCap)
+	}
+
+	newTx := *prevTx
+	newTx.Sent = false
+	newTx.NextReplacement = time.Now().Add(p.replacementTimes[0])
+	newTx.Data.GasFeeCap = feeCap
+	newTx.Data.GasTipCap = tipCap
+	if err := modify(&newTx); err != nil {
+		return nil, err
+	}
+	newTx.FullTx, err = p.signer(p.sender, types.NewTx(&newTx.Data))
+	if err != nil {
+		return nil, fmt.Errorf("signing transaction: %w", err)
+	}
+	log.Info(
+		"DataPoster manually replacing transaction",
+		"nonce", nonce,
+		"prevHash", prevTx.FullTx.Hash(),
+		"newHash", newTx.FullTx.Hash(),
+		"feeCap", feeCap,
+		"tipCap", tipCap,
+	)
+	return newTx.FullTx, p.sendTx(ctx, prevTx, &newTx)
+}
+
 // Gets latest known or finalized block header (depending on config flag),
 // gets the nonce of the dataposter sender and stores it if it has increased.
 // The mutex must be held by the caller.
@@ -635,20 +757,24 @@ type DataPosterConfig struct {
 	ReplacementTimes string                     `koanf:"replacement-times"`
 	// This is forcibly disabled if the parent chain is an Arbitrum chain,
 	// so you should probably use DataPoster's waitForL1Finality method instead of reading this field directly.
-	WaitForL1Finality      bool            `koanf:"wait-for-l1-finality" reload:"hot"`
-	MaxMempoolTransactions uint64          `koanf:"max-mempool-transactions" reload:"hot"`
-	MaxQueuedTransactions  int             `koanf:"max-queued-transactions" reload:"hot"`
-	TargetPriceGwei        float64         `koanf:"target-price-gwei" reload:"hot"`
-	UrgencyGwei            float64         `koanf:"urgency-gwei" reload:"hot"`
-	MinFeeCapGwei          float64         `koanf:"min-fee-cap-gwei" reload:"hot"`
-	MinTipCapGwei          float64         `koanf:"min-tip-cap-gwei" reload:"hot"`
-	MaxTipCapGwei          float64         `koanf:"max-tip-cap-gwei" reload:"hot"`
-	NonceRbfSoftConfs      uint64          `koanf:"nonce-rbf-soft-confs" reload:"hot"`
-	AllocateMempoolBalance bool            `koanf:"allocate-mempool-balance" reload:"hot"`
-	UseDBStorage           bool            `koanf:"use-db-storage"`
-	UseNoOpStorage         bool            `koanf:"use-noop-storage"`
-	LegacyStorageEncoding  bool            `koanf:"legacy-storage-encoding" reload:"hot"`
-	Dangerous              DangerousConfig `koanf:"dangerous"`
+	WaitForL1Finality      bool    `koanf:"wait-for-l1-finality" reload:"hot"`
+	MaxMempoolTransactions uint64  `koanf:"max-mempool-transactions" reload:"hot"`
+	MaxQueuedTransactions  int     `koanf:"max-queued-transactions" reload:"hot"`
+	TargetPriceGwei        float64 `koanf:"target-price-gwei" reload:"hot"`
+	UrgencyGwei            float64 `koanf:"urgency-gwei" reload:"hot"`
+	MinFeeCapGwei          float64 `koanf:"min-fee-cap-gwei" reload:"hot"`
+	MinTipCapGwei          float64 `koanf:"min-tip-cap-gwei" reload:"hot"`
+	MaxTipCapGwei          float64 `koanf:"max-tip-cap-gwei" reload:"hot"`
+	// FeeStrategy selects how fees are estimated: "default", "percentile" or "target-inclusion".
+	FeeStrategy            string                           `koanf:"fee-strategy" reload:"hot"`
+	PercentileFees         PercentileFeeStrategyConfig      `koanf:"percentile-fees" reload:"hot"`
+	TargetInclusion        TargetInclusionFeeStrategyConfig `koanf:"target-inclusion" reload:"hot"`
+	NonceRbfSoftConfs      uint64                           `koanf:"nonce-rbf-soft-confs" reload:"hot"`
+	AllocateMempoolBalance bool                             `koanf:"allocate-mempool-balance" reload:"hot"`
+	UseDBStorage           bool                             `koanf:"use-db-storage"`
+	UseNoOpStorage         bool                             `koanf:"use-noop-storage"`
+	LegacyStorageEncoding  bool                             `koanf:"legacy-storage-encoding" reload:"hot"`
+	Dangerous              DangerousConfig                  `koanf:"dangerous"`
 }
 
 type DangerousConfig struct {
@@ -671,12 +797,15 @@ func DataPosterConfigAddOptions(prefix string, f *pflag.FlagSet) {
 	f.Float64(prefix+".min-fee-cap-gwei", DefaultDataPosterConfig.MinFeeCapGwei, "the minimum fee cap to post transactions at")
 	f.Float64(prefix+".min-tip-cap-gwei", DefaultDataPosterConfig.MinTipCapGwei, "the minimum tip cap to post transactions at")
 	f.Float64(prefix+".max-tip-cap-gwei", DefaultDataPosterConfig.MaxTipCapGwei, "the maximum tip cap to post transactions at")
+	f.String(prefix+".fee-strategy", DefaultDataPosterConfig.FeeStrategy, "how to estimate fees: \"default\" (twice the base fee plus the suggested tip, capped by target-price-gwei and urgency-gwei), \"percentile\" (based on recent fee history) or \"target-inclusion\" (stay includable for a number of blocks, capped by a budget)")
 	f.Uint64(prefix+".nonce-rbf-soft-confs", DefaultDataPosterConfig.NonceRbfSoftConfs, "the maximum probable reorg depth, used to determine when a transaction will no longer likely need replaced-by-fee")
 	f.Bool(prefix+".allocate-mempool-balance", DefaultDataPosterConfig.AllocateMempoolBalance, "if true, don't put transactions in the mempool that spend a total greater than the batch poster's balance")
 	f.Bool(prefix+".use-db-storage", DefaultDataPosterConfig.UseDBStorage, "uses database storage when enabled")
 	f.Bool(prefix+".use-noop-storage", DefaultDataPosterConfig.UseNoOpStorage, "uses noop storage, it doesn't store anything")
 	f.Bool(prefix+".legacy-storage-encoding", DefaultDataPosterConfig.LegacyStorageEncoding, "encodes items in a legacy way (as it was before dropping generics)")
 
+	PercentileFeeStrategyConfigAddOptions(prefix+".percentile-fees", f)
+	TargetInclusionFeeStrategyConfigAddOptions(prefix+".target-inclusion", f)
 	signature.SimpleHmacConfigAddOptions(prefix+".redis-signer", f)
 	addDangerousOptions(prefix+".dangerous", f)
 }
@@ -693,6 +822,9 @@ var DefaultDataPosterConfig = DataPosterConfig{
 	MaxMempoolTransactions: 10,
 	MinTipCapGwei:          0.05,
 	MaxTipCapGwei:          5,
+	FeeStrategy:            DefaultFeeStrategyName,
+	PercentileFees:         DefaultPercentileFeeStrategyConfig,
+	TargetInclusion:        DefaultTargetInclusionFeeStrategyConfig,
 	NonceRbfSoftConfs:      1,
 	AllocateMempoolBalance: true,
 	UseDBStorage:           true,
@@ -716,6 +848,9 @@ var TestDataPosterConfig = DataPosterConfig{
 	MaxMempoolTransactions: 10,
 	MinTipCapGwei:          0.05,
 	MaxTipCapGwei:          5,
+	FeeStrategy:            DefaultFeeStrategyName,
+	PercentileFees:         DefaultPercentileFeeStrategyConfig,
+	TargetInclusion:        DefaultTargetInclusionFeeStrategyConfig,
 	NonceRbfSoftConfs:      1,
 	AllocateMempoolBalance: true,
 	UseDBStorage:           false,
@@ -1,10 +1,20 @@
 package dataposter
 
 import (
+	"context"
+	"math/big"
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/google/go-cmp/cmp"
+
+	"github.com/offchainlabs/nitro/arbnode/dataposter/slice"
+	"github.com/offchainlabs/nitro/arbnode/dataposter/storage"
+	"github.com/offchainlabs/nitro/arbutil"
 )
 
 func TestParseReplacementTimes(t *testing.T) {
@@ -41,3 +51,120 @@ func TestParseReplacementTimes(t *testing.T) {
 		})
 	}
 }
+
+// stubL1Client implements just enough of arbutil.L1Interface for manual
+// replacements; calling any other method panics.
+type stubL1Client struct {
+	arbutil.L1Interface
+	nonce uint64
+	sent  []*types.Transaction
+}
+
+func (c *stubL1Client) NonceAt(context.Context, common.Address, *big.Int) (uint64, error) {
+	return c.nonce, nil
+}
+
+func (c *stubL1Client) SendTransaction(_ context.Context, tx *types.Transaction) error {
+	c.sent = append(c.sent, tx)
+	return nil
+}
+
+func TestManualReplacement(t *testing.T) {
+	ctx := context.Background()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	txSigner := types.LatestSignerForChainID(big.NewInt(1337))
+	client := &stubL1Client{nonce: 3}
+	p := &DataPoster{
+		client: client,
+		sender: sender,
+		signer: func(_ common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			return types.SignTx(tx, txSigner, key)
+		},
+		replacementTimes: []time.Duration{time.Minute},
+		queue:            slice.NewStorage(func() storage.EncoderDecoderInterface { return &storage.EncoderDecoder{} }),
+		errorCount:       make(map[uint64]int),
+	}
+	to := common.HexToAddress("0x1234")
+	for nonce := uint64(2); nonce < 5; nonce++ {
+		inner := types.DynamicFeeTx{
+			Nonce:     nonce,
+			GasTipCap: big.NewInt(params.GWei),
+			GasFeeCap: big.NewInt(10 * params.GWei),
+			Gas:       100_000,
+			To:        &to,
+			Value:     new(big.Int),
+			Data:      []byte{0x01, 0x02},
+		}
+		fullTx, err := p.signer(sender, types.NewTx(&inner))
+		if err != nil {
+			t.Fatal(err)
+		}
+		queued := &storage.QueuedTransaction{
+			FullTx:          fullTx,
+			Data:            inner,
+			Meta:            []byte{byte(nonce)},
+			Sent:            true,
+			Created:         time.Now(),
+			NextReplacement: time.Now().Add(time.Hour),
+		}
+		if err := p.queue.Put(ctx, nonce, nil, queued); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	contents, err := p.QueueContents(ctx, 3, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contents) != 2 || contents[0].Data.Nonce != 3 {
+		t.Fatalf("QueueContents(3, 10) returned %v items, want nonces 3 and 4", len(contents))
+	}
+
+	if _, err := p.ReplaceByFee(ctx, 2, big.NewInt(20*params.GWei), nil); err == nil {
+		t.Error("ReplaceByFee replaced an already included transaction")
+	}
+	if _, err := p.ReplaceByFee(ctx, 3, big.NewInt(10*params.GWei), nil); err == nil {
+		t.Error("ReplaceByFee accepted a fee cap without the minimum increase")
+	}
+	if _, err := p.ReplaceByFee(ctx, 5, big.NewInt(20*params.GWei), nil); err == nil {
+		t.Error("ReplaceByFee replaced a transaction which isn't queued")
+	}
+
+	replaced, err := p.ReplaceByFee(ctx, 3, big.NewInt(20*params.GWei), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replaced.GasFeeCap().Cmp(big.NewInt(20*params.GWei)) != 0 || replaced.To() == nil || *replaced.To() != to {
+		t.Errorf("unexpected replacement transaction: fee cap %v, to %v", replaced.GasFeeCap(), replaced.To())
+	}
+
+	if _, err := p.CancelNonce(ctx, 3, big.NewInt(40*params.GWei), big.NewInt(4*params.GWei)); err == nil {
+		t.Error("CancelNonce cancelled a transaction with later ones queued")
+	}
+	cancelled, err := p.CancelNonce(ctx, 4, big.NewInt(20*params.GWei), big.NewInt(2*params.GWei))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.To() == nil || *cancelled.To() != sender || cancelled.Value().Sign() != 0 || len(cancelled.Data()) != 0 || cancelled.Gas() != params.TxGas {
+		t.Errorf("cancellation isn't a zero value self-transfer: %+v", cancelled)
+	}
+	if len(client.sent) != 2 || client.sent[1].Hash() != cancelled.Hash() {
+		t.Fatalf("sent %v transactions, want the replacement and the cancellation", len(client.sent))
+	}
+
+	contents, err = p.QueueContents(ctx, 4, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contents) != 1 || contents[0].FullTx.Hash() != cancelled.Hash() || !contents[0].Sent {
+		t.Fatal("queue doesn't contain the sent cancellation")
+	}
+	// The next transaction takes the cancelled one's place
+	if !cmp.Equal(contents[0].Meta, []byte{3}) {
+		t.Errorf("cancellation metadata: %v, want: %v", contents[0].Meta, []byte{3})
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package dataposter
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+)
+
+// SignTxArgs is the eth_signTransaction request sent to the external signer.
+type SignTxArgs struct {
+	From                 common.Address   `json:"from"`
+	To                   *common.Address  `json:"to"`
+	Gas                  hexutil.Uint64   `json:"gas"`
+	MaxFeePerGas         *hexutil.Big     `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big     `json:"maxPriorityFeePerGas"`
+	Value                *hexutil.Big     `json:"value"`
+	Nonce                hexutil.Uint64   `json:"nonce"`
+	Data                 hexutil.Bytes    `json:"data"`
+	AccessList           types.AccessList `json:"accessList"`
+	ChainID              *hexutil.Big     `json:"chainId"`
+}
+
+func txToSignTxArgs(addr common.Address, tx *types.Transaction, chainID *big.Int) *SignTxArgs {
+	return &SignTxArgs{
+		From:                 addr,
+		To:                   tx.To(),
+		Gas:                  hexutil.Uint64(tx.Gas()),
+		MaxFeePerGas:         (*hexutil.Big)(tx.GasFeeCap()),
+		MaxPriorityFeePerGas: (*hexutil.Big)(tx.GasTipCap()),
+		Value:                (*hexutil.Big)(tx.Value()),
+		Nonce:                hexutil.Uint64(tx.Nonce()),
+		Data:                 tx.Data(),
+		AccessList:           tx.AccessList(),
+		ChainID:              (*hexutil.Big)(chainID),
+	}
+}
+
+func externalSignerHTTPClient(opts *genericconf.ExternalSignerConfig) (*http.Client, error) {
+	tlsCfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if opts.ClientCert != "" || opts.ClientPrivateKey != "" {
+		clientCert, err := tls.LoadX509KeyPair(opts.ClientCert, opts.ClientPrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate and private key: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{clientCert}
+	}
+	if opts.RootCA != "" {
+		rootCrt, err := os.ReadFile(opts.RootCA)
+		if err != nil {
+			return nil, fmt.Errorf("error reading external signer root CA: %w", err)
+		}
+		rootCertPool := x509.NewCertPool()
+		if !rootCertPool.AppendCertsFromPEM(rootCrt) {
+			return nil, errors.New("error parsing external signer root CA")
+		}
+		tlsCfg.RootCAs = rootCertPool
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsCfg,
+		},
+	}, nil
+}
+
+// NewExternalSigner returns a signer function that signs transactions by
+// making a JSON-RPC request (eth_signTransaction by default) to the external
+// signer, along with the address the external signer signs for.
+func NewExternalSigner(ctx context.Context, opts *genericconf.ExternalSignerConfig, chainID *big.Int) (bind.SignerFn, common.Address, error) {
+	if !common.IsHexAddress(opts.Address) {
+		return nil, common.Address{}, fmt.Errorf("invalid external signer address \"%v\"", opts.Address)
+	}
+	httpClient, err := externalSignerHTTPClient(opts)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	client, err := rpc.DialHTTPWithClient(opts.URL, httpClient)
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("error connecting to external signer: %w", err)
+	}
+	sender := common.HexToAddress(opts.Address)
+	txSigner := types.LatestSignerForChainID(chainID)
+	return func(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		if addr != sender {
+			return nil, bind.ErrNotAuthorized
+		}
+		callCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+		var data hexutil.Bytes
+		if err := client.CallContext(callCtx, &data, opts.Method, txToSignTxArgs(addr, tx, chainID)); err != nil {
+			return nil, fmt.Errorf("making signing request to external signer: %w", err)
+		}
+		signedTx := &types.Transaction{}
+		if err := signedTx.UnmarshalBinary(data); err != nil {
+			return nil, fmt.Errorf("unmarshaling signed transaction: %w", err)
+		}
+		// Make sure the external signer signed the transaction we asked it to sign, and did so with the right key.
+		if txSigner.Hash(tx) != txSigner.Hash(signedTx) {
+			return nil, fmt.Errorf("transaction: %x from external signer differs from request: %x", txSigner.Hash(signedTx), txSigner.Hash(tx))
+		}
+		signedBy, err := types.Sender(txSigner, signedTx)
+		if err != nil {
+			return nil, fmt.Errorf("recovering external signer transaction sender: %w", err)
+		}
+		if signedBy != sender {
+			return nil, fmt.Errorf("external signer signed transaction as %v instead of %v", signedBy, sender)
+		}
+		return signedTx, nil
+	}, sender, nil
+}
+
+// ExternalSignerTxOpts creates transaction options backed by the external
+// signer, to be used in place of a local parent chain wallet.
+func ExternalSignerTxOpts(ctx context.Context, opts *genericconf.ExternalSignerConfig, chainID *big.Int) (*bind.TransactOpts, error) {
+	signer, sender, err := NewExternalSigner(ctx, opts, chainID)
+	if err != nil {
+		return nil, err
+	}
+	return &bind.TransactOpts{
+		From:    sender,
+		Signer:  signer,
+		Context: ctx,
+	}, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package dataposter_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/offchainlabs/nitro/arbnode/dataposter"
+	"github.com/offchainlabs/nitro/arbnode/dataposter/externalsignertest"
+)
+
+func TestExternalSigner(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cfg, api := externalsignertest.NewServer(t)
+	chainID := big.NewInt(1337)
+	signer, sender, err := dataposter.NewExternalSigner(ctx, cfg, chainID)
+	if err != nil {
+		t.Fatalf("Error creating external signer: %v", err)
+	}
+	if sender != api.Address() {
+		t.Fatalf("External signer address: %v, want: %v", sender, api.Address())
+	}
+	to := common.HexToAddress("0x1234")
+	tx := types.NewTx(&types.DynamicFeeTx{
+		Nonce:     5,
+		GasTipCap: big.NewInt(1_000_000_000),
+		GasFeeCap: big.NewInt(30_000_000_000),
+		Gas:       100_000,
+		To:        &to,
+		Value:     big.NewInt(0),
+		Data:      []byte{0x01, 0x02, 0x03},
+	})
+	signedTx, err := signer(sender, tx)
+	if err != nil {
+		t.Fatalf("Error signing transaction with external signer: %v", err)
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(chainID), signedTx)
+	if err != nil {
+		t.Fatalf("Error recovering sender: %v", err)
+	}
+	if from != sender {
+		t.Errorf("Transaction signed by: %v, want: %v", from, sender)
+	}
+	if signedTx.Nonce() != tx.Nonce() || signedTx.GasFeeCap().Cmp(tx.GasFeeCap()) != 0 {
+		t.Errorf("Signed transaction differs from request")
+	}
+	if _, err := signer(common.HexToAddress("0xdead"), tx); err == nil {
+		t.Errorf("External signer signed for an address other than its own")
+	}
+
+	noClientCert := *cfg
+	noClientCert.ClientCert = ""
+	noClientCert.ClientPrivateKey = ""
+	signer, _, err = dataposter.NewExternalSigner(ctx, &noClientCert, chainID)
+	if err != nil {
+		t.Fatalf("Error creating external signer: %v", err)
+	}
+	if _, err := signer(sender, tx); err == nil {
+		t.Errorf("External signer accepted a client without a certificate")
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package externalsignertest provides a local stand-in for an external
+// transaction signer, served over mutually authenticated TLS.
+package externalsignertest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/arbnode/dataposter"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+)
+
+// SignerAPI implements eth_signTransaction using a local private key.
+type SignerAPI struct {
+	privateKey *ecdsa.PrivateKey
+	address    common.Address
+}
+
+func (a *SignerAPI) Address() common.Address {
+	return a.address
+}
+
+func (a *SignerAPI) SignTransaction(ctx context.Context, args *dataposter.SignTxArgs) (hexutil.Bytes, error) {
+	if args == nil || args.ChainID == nil {
+		return nil, fmt.Errorf("missing chain id")
+	}
+	if args.From != a.address {
+		return nil, fmt.Errorf("can't sign for %v, only for %v", args.From, a.address)
+	}
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:    args.ChainID.ToInt(),
+		Nonce:      uint64(args.Nonce),
+		GasTipCap:  args.MaxPriorityFeePerGas.ToInt(),
+		GasFeeCap:  args.MaxFeePerGas.ToInt(),
+		Gas:        uint64(args.Gas),
+		To:         args.To,
+		Value:      args.Value.ToInt(),
+		Data:       args.Data,
+		AccessList: args.AccessList,
+	})
+	signedTx, err := types.SignTx(tx, types.LatestSignerForChainID(args.ChainID.ToInt()), a.privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return signedTx.MarshalBinary()
+}
+
+// NewServer starts a signer server which only accepts clients presenting the
+// returned client certificate, and returns a config to connect to it.
+func NewServer(t *testing.T) (*genericconf.ExternalSignerConfig, *SignerAPI) {
+	t.Helper()
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := &SignerAPI{
+		privateKey: privateKey,
+		address:    crypto.PubkeyToAddress(privateKey.PublicKey),
+	}
+	rpcServer := rpc.NewServer()
+	if err := rpcServer.RegisterName("eth", api); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	caCert, caKey := generateCert(t, nil, nil, "externalsignertest CA", true)
+	serverCert, serverKey := generateCert(t, caCert, caKey, "localhost", false)
+	clientCert, clientKey := generateCert(t, caCert, caKey, "client", false)
+	caPath := writePEM(t, dir, "ca.crt", "CERTIFICATE", caCert.Raw)
+	clientCertPath := writePEM(t, dir, "client.crt", "CERTIFICATE", clientCert.Raw)
+	clientKeyPath := writePEM(t, dir, "client.key", "EC PRIVATE KEY", marshalKey(t, clientKey))
+
+	caPool := x509.NewCertPool()
+	caPool.AddCert(caCert)
+	server := httptest.NewUnstartedServer(rpcServer)
+	server.TLS = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		Certificates: []tls.Certificate{{
+			Certificate: [][]byte{serverCert.Raw},
+			PrivateKey:  serverKey,
+		}},
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  caPool,
+	}
+	server.StartTLS()
+	t.Cleanup(func() {
+		server.Close()
+		rpcServer.Stop()
+	})
+
+	cfg := genericconf.DefaultExternalSignerConfig
+	cfg.URL = server.URL
+	cfg.Address = api.address.Hex()
+	cfg.RootCA = caPath
+	cfg.ClientCert = clientCertPath
+	cfg.ClientPrivateKey = clientKeyPath
+	return &cfg, api
+}
+
+func generateCert(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, commonName string, isCA bool) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour * 24),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1"), net.IPv6loopback},
+	}
+	if isCA {
+		template.IsCA = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent = template
+		parentKey = key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func marshalKey(t *testing.T, key *ecdsa.PrivateKey) []byte {
+	t.Helper()
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
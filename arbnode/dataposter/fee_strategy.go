@@ -0,0 +1,239 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package dataposter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/arbmath"
+	"github.com/spf13/pflag"
+)
+
+const (
+	DefaultFeeStrategyName         = "default"
+	PercentileFeeStrategyName      = "percentile"
+	TargetInclusionFeeStrategyName = "target-inclusion"
+)
+
+// FeeSuggestion is what a FeeStrategy recommends posting a transaction at.
+// The data poster then applies the configured minimums and maximums, the
+// replace-by-fee bumps, and the limits imposed by its balance.
+type FeeSuggestion struct {
+	// BaseFeeCap is the fee cap excluding the tip.
+	BaseFeeCap *big.Int
+	TipCap     *big.Int
+	// MaxFeeCap is the most the strategy is willing to pay per gas, tip included.
+	MaxFeeCap *big.Int
+}
+
+// FeeStrategy estimates the fees to post a transaction at.
+type FeeStrategy interface {
+	SuggestFees(ctx context.Context, header *types.Header, backlogOfBatches uint64) (*FeeSuggestion, error)
+}
+
+// FeeHistoryReader is implemented by parent chain clients supporting eth_feeHistory.
+type FeeHistoryReader interface {
+	FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error)
+}
+
+func newFeeStrategy(config *DataPosterConfig, client arbutil.L1Interface) (FeeStrategy, error) {
+	switch config.FeeStrategy {
+	case DefaultFeeStrategyName, "":
+		return &defaultFeeStrategy{config: config, client: client}, nil
+	case PercentileFeeStrategyName:
+		history, ok := client.(FeeHistoryReader)
+		if !ok {
+			return nil, errors.New("percentile fee strategy requires a parent chain client supporting eth_feeHistory")
+		}
+		if err := config.PercentileFees.Validate(); err != nil {
+			return nil, err
+		}
+		return &percentileFeeStrategy{config: config, client: history}, nil
+	case TargetInclusionFeeStrategyName:
+		history, ok := client.(FeeHistoryReader)
+		if !ok {
+			return nil, errors.New("target inclusion fee strategy requires a parent chain client supporting eth_feeHistory")
+		}
+		if err := config.TargetInclusion.Validate(); err != nil {
+			return nil, err
+		}
+		return &targetInclusionFeeStrategy{config: config, client: history}, nil
+	default:
+		return nil, fmt.Errorf("unknown fee strategy %q", config.FeeStrategy)
+	}
+}
+
+// backlogMaxFeeCap scales the maximum fee cap with the number of batches
+// waiting to be posted.
+func backlogMaxFeeCap(config *DataPosterConfig, backlogOfBatches uint64) *big.Int {
+	// MaxFeeCap = (BacklogOfBatches^2 * UrgencyGWei^2 + TargetPriceGWei) * GWei
+	return arbmath.FloatToBig(
+		(float64(arbmath.SquareUint(backlogOfBatches))*
+			arbmath.SquareFloat(config.UrgencyGwei) +
+			config.TargetPriceGwei) *
+			params.GWei)
+}
+
+// defaultFeeStrategy offers twice the current base fee and the tip suggested
+// by the parent chain node.
+type defaultFeeStrategy struct {
+	config *DataPosterConfig
+	client arbutil.L1Interface
+}
+
+func (s *defaultFeeStrategy) SuggestFees(ctx context.Context, header *types.Header, backlogOfBatches uint64) (*FeeSuggestion, error) {
+	tipCap, err := s.client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &FeeSuggestion{
+		BaseFeeCap: new(big.Int).Mul(header.BaseFee, big.NewInt(2)),
+		TipCap:     tipCap,
+		MaxFeeCap:  backlogMaxFeeCap(s.config, backlogOfBatches),
+	}, nil
+}
+
+// percentileFeeStrategy offers a multiple of the next block's base fee and
+// a percentile of the tips paid in recent blocks.
+type percentileFeeStrategy struct {
+	config *DataPosterConfig
+	client FeeHistoryReader
+}
+
+func (s *percentileFeeStrategy) SuggestFees(ctx context.Context, header *types.Header, backlogOfBatches uint64) (*FeeSuggestion, error) {
+	config := &s.config.PercentileFees
+	nextBaseFee, tipCap, err := feeHistoryEstimate(ctx, s.client, header, config.HistoryBlocks, config.TipPercentile)
+	if err != nil {
+		return nil, err
+	}
+	multiplier := arbmath.Bips(config.BaseFeeMultiplier * float64(arbmath.OneInBips))
+	return &FeeSuggestion{
+		BaseFeeCap: arbmath.BigMulByBips(nextBaseFee, multiplier),
+		TipCap:     tipCap,
+		MaxFeeCap:  backlogMaxFeeCap(s.config, backlogOfBatches),
+	}, nil
+}
+
+// targetInclusionFeeStrategy offers enough to stay includable if the base fee
+// rises as fast as it can for the target number of blocks, up to a fixed budget.
+type targetInclusionFeeStrategy struct {
+	config *DataPosterConfig
+	client FeeHistoryReader
+}
+
+func (s *targetInclusionFeeStrategy) SuggestFees(ctx context.Context, header *types.Header, backlogOfBatches uint64) (*FeeSuggestion, error) {
+	config := &s.config.TargetInclusion
+	nextBaseFee, tipCap, err := feeHistoryEstimate(ctx, s.client, header, config.HistoryBlocks, config.TipPercentile)
+	if err != nil {
+		return nil, err
+	}
+	// The base fee can rise by at most 1/8 per block.
+	baseFeeCap := new(big.Int).Set(nextBaseFee)
+	for i := uint64(0); i < config.TargetBlocks; i++ {
+		baseFeeCap = arbmath.BigMulByFrac(baseFeeCap, 9, 8)
+	}
+	return &FeeSuggestion{
+		BaseFeeCap: baseFeeCap,
+		TipCap:     tipCap,
+		MaxFeeCap:  arbmath.FloatToBig(config.BudgetGwei * params.GWei),
+	}, nil
+}
+
+// feeHistoryEstimate returns the base fee of the block after header, and the
+// median over the last historyBlocks blocks of the given tip percentile.
+func feeHistoryEstimate(ctx context.Context, client FeeHistoryReader, header *types.Header, historyBlocks uint64, tipPercentile float64) (*big.Int, *big.Int, error) {
+	history, err := client.FeeHistory(ctx, historyBlocks, header.Number, []float64{tipPercentile})
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting parent chain fee history: %w", err)
+	}
+	if len(history.BaseFee) == 0 {
+		return nil, nil, errors.New("parent chain fee history has no base fees")
+	}
+	// The last base fee is the one of the next block.
+	nextBaseFee := history.BaseFee[len(history.BaseFee)-1]
+	var tips []*big.Int
+	for _, rewards := range history.Reward {
+		if len(rewards) > 0 && rewards[0] != nil {
+			tips = append(tips, rewards[0])
+		}
+	}
+	if len(tips) == 0 {
+		return nextBaseFee, new(big.Int), nil
+	}
+	sort.Slice(tips, func(i, j int) bool { return tips[i].Cmp(tips[j]) < 0 })
+	return nextBaseFee, tips[len(tips)/2], nil
+}
+
+type PercentileFeeStrategyConfig struct {
+	HistoryBlocks     uint64  `koanf:"history-blocks" reload:"hot"`
+	TipPercentile     float64 `koanf:"tip-percentile" reload:"hot"`
+	BaseFeeMultiplier float64 `koanf:"base-fee-multiplier" reload:"hot"`
+}
+
+func (c *PercentileFeeStrategyConfig) Validate() error {
+	if c.HistoryBlocks == 0 {
+		return errors.New("percentile fee strategy history-blocks must be positive")
+	}
+	if c.TipPercentile < 0 || c.TipPercentile > 100 {
+		return fmt.Errorf("percentile fee strategy tip-percentile %v must be between 0 and 100", c.TipPercentile)
+	}
+	if c.BaseFeeMultiplier < 1 {
+		return fmt.Errorf("percentile fee strategy base-fee-multiplier %v must be at least 1", c.BaseFeeMultiplier)
+	}
+	return nil
+}
+
+var DefaultPercentileFeeStrategyConfig = PercentileFeeStrategyConfig{
+	HistoryBlocks:     20,
+	TipPercentile:     50,
+	BaseFeeMultiplier: 2,
+}
+
+func PercentileFeeStrategyConfigAddOptions(prefix string, f *pflag.FlagSet) {
+	f.Uint64(prefix+".history-blocks", DefaultPercentileFeeStrategyConfig.HistoryBlocks, "number of recent parent chain blocks to estimate tips from")
+	f.Float64(prefix+".tip-percentile", DefaultPercentileFeeStrategyConfig.TipPercentile, "percentile of the tips paid in each recent block to offer")
+	f.Float64(prefix+".base-fee-multiplier", DefaultPercentileFeeStrategyConfig.BaseFeeMultiplier, "multiple of the next block's base fee to offer")
+}
+
+type TargetInclusionFeeStrategyConfig struct {
+	TargetBlocks  uint64  `koanf:"target-blocks" reload:"hot"`
+	HistoryBlocks uint64  `koanf:"history-blocks" reload:"hot"`
+	TipPercentile float64 `koanf:"tip-percentile" reload:"hot"`
+	BudgetGwei    float64 `koanf:"budget-gwei" reload:"hot"`
+}
+
+func (c *TargetInclusionFeeStrategyConfig) Validate() error {
+	if c.HistoryBlocks == 0 {
+		return errors.New("target inclusion fee strategy history-blocks must be positive")
+	}
+	if c.TipPercentile < 0 || c.TipPercentile > 100 {
+		return fmt.Errorf("target inclusion fee strategy tip-percentile %v must be between 0 and 100", c.TipPercentile)
+	}
+	if c.BudgetGwei <= 0 {
+		return errors.New("target inclusion fee strategy budget-gwei must be positive")
+	}
+	return nil
+}
+
+var DefaultTargetInclusionFeeStrategyConfig = TargetInclusionFeeStrategyConfig{
+	TargetBlocks:  3,
+	HistoryBlocks: 10,
+	TipPercentile: 60,
+	BudgetGwei:    100,
+}
+
+func TargetInclusionFeeStrategyConfigAddOptions(prefix string, f *pflag.FlagSet) {
+	f.Uint64(prefix+".target-blocks", DefaultTargetInclusionFeeStrategyConfig.TargetBlocks, "number of parent chain blocks the transaction should remain includable for if the base fee keeps rising")
+	f.Uint64(prefix+".history-blocks", DefaultTargetInclusionFeeStrategyConfig.HistoryBlocks, "number of recent parent chain blocks to estimate tips from")
+	f.Float64(prefix+".tip-percentile", DefaultTargetInclusionFeeStrategyConfig.TipPercentile, "percentile of the tips paid in each recent block to offer")
+	f.Float64(prefix+".budget-gwei", DefaultTargetInclusionFeeStrategyConfig.BudgetGwei, "the maximum fee cap to post transactions at, regardless of backlog")
+}
@@ -0,0 +1,81 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package dataposter
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+type stubFeeHistoryClient struct {
+	stubL1Client
+	history *ethereum.FeeHistory
+}
+
+func (c *stubFeeHistoryClient) FeeHistory(context.Context, uint64, *big.Int, []float64) (*ethereum.FeeHistory, error) {
+	return c.history, nil
+}
+
+func (c *stubFeeHistoryClient) SuggestGasTipCap(context.Context) (*big.Int, error) {
+	return big.NewInt(params.GWei), nil
+}
+
+func gwei(n int64) *big.Int {
+	return big.NewInt(n * params.GWei)
+}
+
+func TestFeeStrategies(t *testing.T) {
+	ctx := context.Background()
+	client := &stubFeeHistoryClient{
+		history: &ethereum.FeeHistory{
+			// One tip per block, for the single percentile requested.
+			Reward:  [][]*big.Int{{gwei(3)}, {gwei(1)}, {gwei(2)}},
+			BaseFee: []*big.Int{gwei(10), gwei(12), gwei(14), gwei(16)},
+		},
+	}
+	header := &types.Header{Number: big.NewInt(100), BaseFee: gwei(14)}
+	config := TestDataPosterConfig
+
+	for _, tc := range []struct {
+		strategy   string
+		baseFeeCap *big.Int
+		tipCap     *big.Int
+		maxFeeCap  *big.Int
+	}{
+		// Twice the header's base fee and the node's tip suggestion.
+		{DefaultFeeStrategyName, gwei(28), gwei(1), gwei(60)},
+		// Twice the next block's base fee and the median tip.
+		{PercentileFeeStrategyName, gwei(32), gwei(2), gwei(60)},
+		// The next block's base fee after rising by 1/8 three times, capped by the budget.
+		{TargetInclusionFeeStrategyName, big.NewInt(22_781_250_000), gwei(2), gwei(100)},
+	} {
+		config.FeeStrategy = tc.strategy
+		strategy, err := newFeeStrategy(&config, client)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fees, err := strategy.SuggestFees(ctx, header, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fees.BaseFeeCap.Cmp(tc.baseFeeCap) != 0 || fees.TipCap.Cmp(tc.tipCap) != 0 || fees.MaxFeeCap.Cmp(tc.maxFeeCap) != 0 {
+			t.Errorf("%v fee strategy suggested base fee cap %v, tip cap %v and max fee cap %v, expected %v, %v and %v",
+				tc.strategy, fees.BaseFeeCap, fees.TipCap, fees.MaxFeeCap, tc.baseFeeCap, tc.tipCap, tc.maxFeeCap)
+		}
+	}
+
+	config.FeeStrategy = "unknown"
+	if _, err := newFeeStrategy(&config, client); err == nil {
+		t.Error("created an unknown fee strategy")
+	}
+	config.FeeStrategy = PercentileFeeStrategyName
+	if _, err := newFeeStrategy(&config, &stubL1Client{}); err == nil {
+		t.Error("created a percentile fee strategy without fee history support")
+	}
+}
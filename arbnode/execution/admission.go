@@ -0,0 +1,348 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package execution
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/util/containers"
+)
+
+var (
+	admissionRejectedCounter      = metrics.NewRegisteredCounter("arb/sequencer/admission/rejected", nil)
+	admissionReloadedCounter      = metrics.NewRegisteredCounter("arb/sequencer/admission/reloaded", nil)
+	admissionReloadFailureCounter = metrics.NewRegisteredCounter("arb/sequencer/admission/reloadfailure", nil)
+)
+
+// JSON-RPC error codes of the reasons a transaction can be refused admission
+// to the sequencer queue, in the server error range.
+const (
+	AdmissionErrorSenderNotAllowed    = -32010
+	AdmissionErrorRecipientNotAllowed = -32011
+	AdmissionErrorSelectorBlocked     = -32012
+	AdmissionErrorCalldataTooLarge    = -32013
+	AdmissionErrorRateLimited         = -32014
+)
+
+// AdmissionError is returned when a transaction is refused by the admission
+// policy. It implements rpc.Error and rpc.DataError, so clients get a distinct
+// error code for each reason.
+type AdmissionError struct {
+	code    int
+	reason  string
+	message string
+}
+
+func (e *AdmissionError) Error() string {
+	return e.message
+}
+
+func (e *AdmissionError) ErrorCode() int {
+	return e.code
+}
+
+func (e *AdmissionError) ErrorData() interface{} {
+	return map[string]string{"reason": e.reason}
+}
+
+func newAdmissionError(code int, reason string, format string, args ...interface{}) *AdmissionError {
+	return &AdmissionError{
+		code:    code,
+		reason:  reason,
+		message: fmt.Sprintf(format, args...),
+	}
+}
+
+var errSenderNotOnWhitelist = newAdmissionError(AdmissionErrorSenderNotAllowed, "sender-not-allowed", "transaction sender is not on the whitelist")
+
+type AdmissionPolicyConfig struct {
+	File           string        `koanf:"file" reload:"hot"`
+	ReloadInterval time.Duration `koanf:"reload-interval" reload:"hot"`
+	RateLimitCache int           `koanf:"rate-limit-cache"`
+}
+
+var DefaultAdmissionPolicyConfig = AdmissionPolicyConfig{
+	File:           "",
+	ReloadInterval: time.Second * 10,
+	RateLimitCache: 65536,
+}
+
+func AdmissionPolicyConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".file", DefaultAdmissionPolicyConfig.File, "JSON file with the policy transactions must satisfy to be queued, reloaded when it changes (if empty, everything is admitted)")
+	f.Duration(prefix+".reload-interval", DefaultAdmissionPolicyConfig.ReloadInterval, "how often to check the admission policy file for changes")
+	f.Int(prefix+".rate-limit-cache", DefaultAdmissionPolicyConfig.RateLimitCache, "number of senders to track the rate limit of")
+}
+
+func (c *AdmissionPolicyConfig) Validate() error {
+	if c.ReloadInterval <= 0 {
+		return errors.New("sequencer admission policy reload-interval must be positive")
+	}
+	if c.RateLimitCache <= 0 {
+		return errors.New("sequencer admission policy rate-limit-cache must be positive")
+	}
+	return nil
+}
+
+type AdmissionPolicyConfigFetcher func() *AdmissionPolicyConfig
+
+type AdmissionRateLimit struct {
+	TransactionsPerSecond float64 `json:"transactions-per-second"`
+	Burst                 float64 `json:"burst"`
+}
+
+// AdmissionPolicy is the content of the admission policy file, for instance
+//
+//	{
+//	  "denied-senders": ["0x..."],
+//	  "allowed-recipients": ["0x..."],
+//	  "sender-rate-limit": {"transactions-per-second": 2, "burst": 10},
+//	  "max-calldata-size": {"0x...": 1024},
+//	  "default-max-calldata-size": 65536,
+//	  "blocked-selectors": ["0xa9059cbb"]
+//	}
+//
+// Empty allow lists allow everyone, and a non-empty recipient allow list
+// also refuses contract creations. Calldata size limits of zero are unlimited.
+type AdmissionPolicy struct {
+	AllowedSenders         []common.Address       `json:"allowed-senders,omitempty"`
+	DeniedSenders          []common.Address       `json:"denied-senders,omitempty"`
+	AllowedRecipients      []common.Address       `json:"allowed-recipients,omitempty"`
+	DeniedRecipients       []common.Address       `json:"denied-recipients,omitempty"`
+	SenderRateLimit        *AdmissionRateLimit    `json:"sender-rate-limit,omitempty"`
+	MaxCalldataSize        map[common.Address]int `json:"max-calldata-size,omitempty"`
+	DefaultMaxCalldataSize int                    `json:"default-max-calldata-size,omitempty"`
+	BlockedSelectors       []hexutil.Bytes        `json:"blocked-selectors,omitempty"`
+}
+
+type addressSet map[common.Address]struct{}
+
+func newAddressSet(addresses []common.Address) addressSet {
+	if len(addresses) == 0 {
+		return nil
+	}
+	set := make(addressSet, len(addresses))
+	for _, addr := range addresses {
+		set[addr] = struct{}{}
+	}
+	return set
+}
+
+func (s addressSet) contains(addr common.Address) bool {
+	_, ok := s[addr]
+	return ok
+}
+
+// parsedAdmissionPolicy is an AdmissionPolicy in the form it's evaluated in.
+type parsedAdmissionPolicy struct {
+	allowedSenders         addressSet
+	deniedSenders          addressSet
+	allowedRecipients      addressSet
+	deniedRecipients       addressSet
+	rateLimit              *AdmissionRateLimit
+	maxCalldataSize        map[common.Address]int
+	defaultMaxCalldataSize int
+	blockedSelectors       map[[4]byte]struct{}
+}
+
+func parseAdmissionPolicy(data []byte) (*parsedAdmissionPolicy, error) {
+	var policy AdmissionPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, err
+	}
+	parsed := &parsedAdmissionPolicy{
+		allowedSenders:         newAddressSet(policy.AllowedSenders),
+		deniedSenders:          newAddressSet(policy.DeniedSenders),
+		allowedRecipients:      newAddressSet(policy.AllowedRecipients),
+		deniedRecipients:       newAddressSet(policy.DeniedRecipients),
+		maxCalldataSize:        policy.MaxCalldataSize,
+		defaultMaxCalldataSize: policy.DefaultMaxCalldataSize,
+	}
+	if policy.SenderRateLimit != nil {
+		if policy.SenderRateLimit.TransactionsPerSecond <= 0 {
+			return nil, errors.New("sender-rate-limit transactions-per-second must be positive")
+		}
+		if policy.SenderRateLimit.Burst < 1 {
+			return nil, errors.New("sender-rate-limit burst must be at least 1")
+		}
+		parsed.rateLimit = policy.SenderRateLimit
+	}
+	if policy.DefaultMaxCalldataSize < 0 {
+		return nil, errors.New("default-max-calldata-size is negative")
+	}
+	for addr, size := range policy.MaxCalldataSize {
+		if size < 0 {
+			return nil, fmt.Errorf("max-calldata-size of %v is negative", addr)
+		}
+	}
+	if len(policy.BlockedSelectors) > 0 {
+		parsed.blockedSelectors = make(map[[4]byte]struct{}, len(policy.BlockedSelectors))
+		for _, selector := range policy.BlockedSelectors {
+			if len(selector) != 4 {
+				return nil, fmt.Errorf("blocked selector %v isn't 4 bytes", selector)
+			}
+			parsed.blockedSelectors[*(*[4]byte)(selector)] = struct{}{}
+		}
+	}
+	return parsed, nil
+}
+
+// check evaluates everything but the rate limit, which is stateful.
+func (p *parsedAdmissionPolicy) check(sender common.Address, tx *types.Transaction) error {
+	if p.deniedSenders.contains(sender) || (p.allowedSenders != nil && !p.allowedSenders.contains(sender)) {
+		return newAdmissionError(AdmissionErrorSenderNotAllowed, "sender-not-allowed", "transaction sender %v is not allowed", sender)
+	}
+	to := tx.To()
+	if to == nil {
+		if p.allowedRecipients != nil {
+			return newAdmissionError(AdmissionErrorRecipientNotAllowed, "recipient-not-allowed", "contract creation is not allowed")
+		}
+	} else if p.deniedRecipients.contains(*to) || (p.allowedRecipients != nil && !p.allowedRecipients.contains(*to)) {
+		return newAdmissionError(AdmissionErrorRecipientNotAllowed, "recipient-not-allowed", "transaction recipient %v is not allowed", *to)
+	}
+	data := tx.Data()
+	if to != nil && len(data) >= 4 && p.blockedSelectors != nil {
+		if _, blocked := p.blockedSelectors[*(*[4]byte)(data[:4])]; blocked {
+			return newAdmissionError(AdmissionErrorSelectorBlocked, "selector-blocked", "function selector %v is blocked", hexutil.Bytes(data[:4]))
+		}
+	}
+	maxSize := p.defaultMaxCalldataSize
+	if to != nil {
+		if size, ok := p.maxCalldataSize[*to]; ok {
+			maxSize = size
+		}
+	}
+	if maxSize > 0 && len(data) > maxSize {
+		return newAdmissionError(AdmissionErrorCalldataTooLarge, "calldata-too-large", "transaction calldata of %v bytes exceeds the limit of %v bytes", len(data), maxSize)
+	}
+	return nil
+}
+
+type senderRateLimitState struct {
+	tokens     float64
+	lastRefill time.Time
+}
+
+// AdmissionPolicyEngine decides whether transactions are admitted to the
+// sequencer queue, according to the policy in the configured file. The file
+// is reloaded by calling Reload, and if the new policy is invalid, the last
+// valid one stays in effect.
+type AdmissionPolicyEngine struct {
+	config AdmissionPolicyConfigFetcher
+
+	mutex      sync.Mutex
+	policy     *parsedAdmissionPolicy
+	loadedFile string
+	modTime    time.Time
+	size       int64
+	rateLimits *containers.LruCache[common.Address, *senderRateLimitState]
+}
+
+func NewAdmissionPolicyEngine(configFetcher AdmissionPolicyConfigFetcher) (*AdmissionPolicyEngine, error) {
+	e := &AdmissionPolicyEngine{
+		config:     configFetcher,
+		rateLimits: containers.NewLruCache[common.Address, *senderRateLimitState](configFetcher().RateLimitCache),
+	}
+	if err := e.Reload(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Reload loads the policy file if it has changed since it was last loaded.
+func (e *AdmissionPolicyEngine) Reload() error {
+	file := e.config().File
+	if file == "" {
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+		e.policy = nil
+		e.loadedFile = ""
+		return nil
+	}
+	e.mutex.Lock()
+	loadedFile, modTime, size := e.loadedFile, e.modTime, e.size
+	e.mutex.Unlock()
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	if file == loadedFile && info.ModTime().Equal(modTime) && info.Size() == size {
+		return nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	policy, err := parseAdmissionPolicy(data)
+	if err != nil {
+		return fmt.Errorf("invalid admission policy file %v: %w", file, err)
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.policy = policy
+	e.loadedFile = file
+	e.modTime = info.ModTime()
+	e.size = info.Size()
+	admissionReloadedCounter.Inc(1)
+	log.Info("loaded sequencer admission policy", "file", file)
+	return nil
+}
+
+// reloadIteratively is called by the sequencer's CallIteratively.
+func (e *AdmissionPolicyEngine) reloadIteratively() time.Duration {
+	if err := e.Reload(); err != nil {
+		admissionReloadFailureCounter.Inc(1)
+		log.Error("failed to reload sequencer admission policy, keeping the previous policy", "err", err)
+	}
+	return e.config().ReloadInterval
+}
+
+// Admit returns an AdmissionError if the transaction isn't allowed to enter
+// the sequencer queue.
+func (e *AdmissionPolicyEngine) Admit(sender common.Address, tx *types.Transaction, now time.Time) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.policy == nil {
+		return nil
+	}
+	err := e.policy.check(sender, tx)
+	if err == nil && e.policy.rateLimit != nil {
+		err = e.reserveRateLimit(sender, e.policy.rateLimit, now)
+	}
+	if err != nil {
+		admissionRejectedCounter.Inc(1)
+	}
+	return err
+}
+
+// reserveRateLimit must be called with the mutex held.
+func (e *AdmissionPolicyEngine) reserveRateLimit(sender common.Address, limit *AdmissionRateLimit, now time.Time) error {
+	state, ok := e.rateLimits.Get(sender)
+	if !ok {
+		state = &senderRateLimitState{tokens: limit.Burst, lastRefill: now}
+		e.rateLimits.Add(sender, state)
+	} else {
+		state.tokens += now.Sub(state.lastRefill).Seconds() * limit.TransactionsPerSecond
+		if state.tokens > limit.Burst {
+			state.tokens = limit.Burst
+		}
+		state.lastRefill = now
+	}
+	if state.tokens < 1 {
+		return newAdmissionError(AdmissionErrorRateLimited, "rate-limited", "transaction sender %v exceeded the rate limit of %v transactions per second", sender, limit.TransactionsPerSecond)
+	}
+	state.tokens--
+	return nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package execution
+
+import (
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func testAdmissionTx(to *common.Address, data []byte) *types.Transaction {
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(412346),
+		GasFeeCap: big.NewInt(100),
+		Gas:       100000,
+		To:        to,
+		Data:      data,
+	})
+}
+
+func expectAdmissionError(t *testing.T, err error, code int) {
+	t.Helper()
+	var admissionErr *AdmissionError
+	if !errors.As(err, &admissionErr) {
+		Fail(t, "expected admission error with code", code, "got", err)
+	}
+	if admissionErr.ErrorCode() != code {
+		Fail(t, "expected admission error code", code, "got", admissionErr.ErrorCode(), admissionErr)
+	}
+}
+
+func TestAdmissionPolicy(t *testing.T) {
+	denied := common.HexToAddress("0xdead")
+	sender := common.HexToAddress("0x1111")
+	allowedTo := common.HexToAddress("0x2222")
+	smallTo := common.HexToAddress("0x3333")
+	policyFile := filepath.Join(t.TempDir(), "policy.json")
+	Require(t, os.WriteFile(policyFile, []byte(`{
+		"denied-senders": ["0x000000000000000000000000000000000000dead"],
+		"denied-recipients": ["0x000000000000000000000000000000000000dead"],
+		"max-calldata-size": {"0x0000000000000000000000000000000000003333": 4},
+		"default-max-calldata-size": 100,
+		"blocked-selectors": ["0xa9059cbb"],
+		"sender-rate-limit": {"transactions-per-second": 1, "burst": 2}
+	}`), 0600))
+	config := DefaultAdmissionPolicyConfig
+	config.File = policyFile
+	Require(t, config.Validate())
+	engine, err := NewAdmissionPolicyEngine(func() *AdmissionPolicyConfig { return &config })
+	Require(t, err)
+
+	now := time.Now()
+	expectAdmissionError(t, engine.Admit(denied, testAdmissionTx(&allowedTo, nil), now), AdmissionErrorSenderNotAllowed)
+	expectAdmissionError(t, engine.Admit(sender, testAdmissionTx(&denied, nil), now), AdmissionErrorRecipientNotAllowed)
+	expectAdmissionError(t, engine.Admit(sender, testAdmissionTx(&allowedTo, []byte{0xa9, 0x05, 0x9c, 0xbb}), now), AdmissionErrorSelectorBlocked)
+	expectAdmissionError(t, engine.Admit(sender, testAdmissionTx(&allowedTo, make([]byte, 101)), now), AdmissionErrorCalldataTooLarge)
+	expectAdmissionError(t, engine.Admit(sender, testAdmissionTx(&smallTo, make([]byte, 5)), now), AdmissionErrorCalldataTooLarge)
+	// Contract creations can't have blocked selectors
+	Require(t, engine.Admit(sender, testAdmissionTx(nil, []byte{0xa9, 0x05, 0x9c, 0xbb}), now))
+
+	// The first tx used up one of the two burst tokens; rejected txs don't use any
+	Require(t, engine.Admit(sender, testAdmissionTx(&smallTo, make([]byte, 4)), now))
+	expectAdmissionError(t, engine.Admit(sender, testAdmissionTx(&allowedTo, nil), now), AdmissionErrorRateLimited)
+	// Rate limits are per sender
+	Require(t, engine.Admit(common.HexToAddress("0x4444"), testAdmissionTx(&allowedTo, nil), now))
+	now = now.Add(time.Second)
+	Require(t, engine.Admit(sender, testAdmissionTx(&allowedTo, nil), now))
+
+	// Changes to the file are picked up on reload, and allow lists refuse
+	// everything not on them
+	Require(t, os.WriteFile(policyFile, []byte(`{
+		"allowed-senders": ["0x0000000000000000000000000000000000001111"],
+		"allowed-recipients": ["0x0000000000000000000000000000000000002222"]
+	}`), 0600))
+	// Make sure the modification time changes
+	Require(t, os.Chtimes(policyFile, now, now.Add(time.Minute)))
+	Require(t, engine.Reload())
+	for i := 0; i < 5; i++ {
+		Require(t, engine.Admit(sender, testAdmissionTx(&allowedTo, []byte{0xa9, 0x05, 0x9c, 0xbb}), now))
+	}
+	expectAdmissionError(t, engine.Admit(common.HexToAddress("0x4444"), testAdmissionTx(&allowedTo, nil), now), AdmissionErrorSenderNotAllowed)
+	expectAdmissionError(t, engine.Admit(sender, testAdmissionTx(&smallTo, nil), now), AdmissionErrorRecipientNotAllowed)
+	expectAdmissionError(t, engine.Admit(sender, testAdmissionTx(nil, nil), now), AdmissionErrorRecipientNotAllowed)
+
+	// An invalid file keeps the previous policy
+	Require(t, os.WriteFile(policyFile, []byte(`{"blocked-selectors": ["0x1234"]}`), 0600))
+	Require(t, os.Chtimes(policyFile, now, now.Add(2*time.Minute)))
+	if err := engine.Reload(); err == nil {
+		Fail(t, "reloaded invalid admission policy")
+	}
+	expectAdmissionError(t, engine.Admit(common.HexToAddress("0x4444"), testAdmissionTx(&allowedTo, nil), now), AdmissionErrorSenderNotAllowed)
+
+	// Without a file, everything is admitted
+	config.File = ""
+	Require(t, engine.Reload())
+	Require(t, engine.Admit(denied, testAdmissionTx(&denied, nil), now))
+}
+
+func TestInvalidAdmissionPolicy(t *testing.T) {
+	for _, policy := range []string{
+		`not json`,
+		`{"allowed-senders": ["0x1234"]}`,
+		`{"blocked-selectors": ["0xa9059c"]}`,
+		`{"sender-rate-limit": {"transactions-per-second": 0, "burst": 1}}`,
+		`{"sender-rate-limit": {"transactions-per-second": 1, "burst": 0.5}}`,
+		`{"max-calldata-size": {"0x0000000000000000000000000000000000003333": -1}}`,
+		`{"default-max-calldata-size": -1}`,
+	} {
+		if _, err := parseAdmissionPolicy([]byte(policy)); err == nil {
+			Fail(t, "parsed invalid admission policy", policy)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/arbitrum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+)
+
+var (
+	bundleSequencedCounter = metrics.NewRegisteredCounter("arb/sequencer/bundle/sequenced", nil)
+	bundleFailedCounter    = metrics.NewRegisteredCounter("arb/sequencer/bundle/failed", nil)
+)
+
+const BundleAPINamespace = "arbbundle"
+
+var ErrBundleExpired = errors.New("bundle deadline passed")
+
+type BundleConfig struct {
+	Enable          bool          `koanf:"enable"`
+	MaxTransactions int           `koanf:"max-transactions" reload:"hot"`
+	MaxDeadline     time.Duration `koanf:"max-deadline" reload:"hot"`
+	QueueSize       int           `koanf:"queue-size"`
+}
+
+var DefaultBundleConfig = BundleConfig{
+	Enable:          false,
+	MaxTransactions: 16,
+	MaxDeadline:     time.Minute,
+	QueueSize:       64,
+}
+
+func BundleConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultBundleConfig.Enable, "accept transaction bundles on the authenticated "+BundleAPINamespace+" RPC namespace")
+	f.Int(prefix+".max-transactions", DefaultBundleConfig.MaxTransactions, "maximum number of transactions in a bundle")
+	f.Duration(prefix+".max-deadline", DefaultBundleConfig.MaxDeadline, "maximum time a bundle's deadline may be in the future")
+	f.Int(prefix+".queue-size", DefaultBundleConfig.QueueSize, "size of the pending bundle queue")
+}
+
+func (c *BundleConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.MaxTransactions <= 0 {
+		return errors.New("sequencer bundles max-transactions must be positive")
+	}
+	if c.MaxDeadline <= 0 {
+		return errors.New("sequencer bundles max-deadline must be positive")
+	}
+	return nil
+}
+
+// BundleReceipt is returned once a bundle has been sequenced.
+type BundleReceipt struct {
+	BundleHash  common.Hash    `json:"bundleHash"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	TxHashes    []common.Hash  `json:"txHashes"`
+}
+
+// BundleHash is the hash of the concatenated hashes of the bundle's txs.
+func BundleHash(txes types.Transactions) common.Hash {
+	hashes := make([]byte, 0, len(txes)*common.HashLength)
+	for _, tx := range txes {
+		hashes = append(hashes, tx.Hash().Bytes()...)
+	}
+	return crypto.Keccak256Hash(hashes)
+}
+
+type bundleResult struct {
+	receipt *BundleReceipt
+	err     error
+}
+
+type bundleQueueItem struct {
+	txes       types.Transactions
+	deadline   time.Time
+	ctx        context.Context
+	resultChan chan bundleResult
+}
+
+func (i *bundleQueueItem) returnResult(receipt *BundleReceipt, err error) {
+	i.resultChan <- bundleResult{receipt, err}
+}
+
+// PublishBundle sequences the transactions in order in a block of their own
+// before the deadline, or not at all if any of them fails or reverts.
+// Bundles are never forwarded, and unlike transactions published with
+// PublishTransaction, their transactions aren't visible to anyone before
+// they're sequenced.
+func (s *Sequencer) PublishBundle(parentCtx context.Context, txes types.Transactions, deadline time.Time) (*BundleReceipt, error) {
+	config := s.config()
+	if !config.Bundles.Enable {
+		return nil, errors.New("bundles are disabled")
+	}
+	if len(txes) == 0 {
+		return nil, errors.New("bundle has no transactions")
+	}
+	if len(txes) > config.Bundles.MaxTransactions {
+		return nil, fmt.Errorf("bundle has %v transactions, more than the maximum of %v", len(txes), config.Bundles.MaxTransactions)
+	}
+	now := time.Now()
+	if !deadline.After(now) {
+		return nil, ErrBundleExpired
+	}
+	if deadline.After(now.Add(config.Bundles.MaxDeadline)) {
+		return nil, fmt.Errorf("bundle deadline is more than %v in the future", config.Bundles.MaxDeadline)
+	}
+	_, forwarder := s.GetPauseAndForwarder()
+	if forwarder != nil {
+		return nil, ErrNoSequencer
+	}
+
+	signer := types.LatestSigner(s.execEngine.bc.Config())
+	totalSize := 0
+	for i, tx := range txes {
+		if tx.Type() >= types.ArbitrumDepositTxType {
+			return nil, types.ErrTxTypeNotSupported
+		}
+		sender, err := types.Sender(signer, tx)
+		if err != nil {
+			return nil, fmt.Errorf("bundle transaction %v: %w", i, err)
+		}
+		if len(s.senderWhitelist) > 0 {
+			if _, authorized := s.senderWhitelist[sender]; !authorized {
+				return nil, errSenderNotOnWhitelist
+			}
+		}
+		if err := s.admission.Admit(sender, tx, now); err != nil {
+			return nil, err
+		}
+		totalSize += int(tx.Size())
+	}
+	if totalSize > config.MaxTxDataSize {
+		return nil, fmt.Errorf("bundle of %v bytes is larger than the maximum of %v bytes", totalSize, config.MaxTxDataSize)
+	}
+
+	ctx, cancel := context.WithDeadline(parentCtx, deadline)
+	defer cancel()
+	item := &bundleQueueItem{
+		txes:       txes,
+		deadline:   deadline,
+		ctx:        ctx,
+		resultChan: make(chan bundleResult, 1),
+	}
+	select {
+	case s.bundleQueue <- item:
+	case <-ctx.Done():
+		if parentCtx.Err() == nil {
+			return nil, ErrBundleExpired
+		}
+		return nil, parentCtx.Err()
+	}
+
+	// Once queued, the bundle is always given a result, and it may be
+	// sequenced a little after its context is done, so keep waiting a bit
+	abortCtx, cancelAbort := ctxWithTimeout(parentCtx, time.Until(deadline)+config.QueueTimeout)
+	defer cancelAbort()
+	select {
+	case res := <-item.resultChan:
+		return res.receipt, res.err
+	case <-abortCtx.Done():
+		log.Warn("Bundle sequencing hit abort deadline", "bundleHash", BundleHash(txes), "deadline", deadline)
+		return nil, abortCtx.Err()
+	}
+}
+
+// sequenceBundle is only called from createBlock.
+func (s *Sequencer) sequenceBundle(item *bundleQueueItem) bool {
+	if item.ctx.Err() != nil {
+		item.returnResult(nil, ErrBundleExpired)
+		return false
+	}
+	pause, forwarder := s.GetPauseAndForwarder()
+	if pause != nil || forwarder != nil {
+		item.returnResult(nil, ErrNoSequencer)
+		return false
+	}
+	config := s.config()
+	header := s.nextBlockHeader(config)
+	if header == nil {
+		item.returnResult(nil, ErrNoSequencer)
+		return false
+	}
+	if time.Unix(int64(header.Timestamp), 0).After(item.deadline) {
+		item.returnResult(nil, ErrBundleExpired)
+		return false
+	}
+
+	s.nonceCache.Resize(config.NonceCacheSize)
+	s.nonceCache.BeginNewBlock()
+	hooks := s.makeSequencingHooks()
+	hooks.PostTxFilter = func(header *types.Header, state *arbosState.ArbosState, tx *types.Transaction, sender common.Address, dataGas uint64, result *core.ExecutionResult) error {
+		// Reverted transactions would be included, but would break the bundle
+		if result.Err != nil {
+			return arbitrum.NewRevertReason(result)
+		}
+		return s.postTxFilter(header, state, tx, sender, dataGas, result)
+	}
+
+	start := time.Now()
+	block, err := s.execEngine.SequenceBundle(header, item.txes, hooks)
+	blockCreationTimer.Update(time.Since(start))
+	if err == nil && block == nil {
+		err = sequencerInternalError
+	}
+	if err != nil {
+		var txErr *BundleTxError
+		if !errors.As(err, &txErr) {
+			log.Error("error sequencing bundle", "err", err)
+		}
+		bundleFailedCounter.Inc(1)
+		item.returnResult(nil, err)
+		return false
+	}
+	successfulBlocksCounter.Inc(1)
+	bundleSequencedCounter.Inc(1)
+	s.nonceCache.Finalize(block)
+
+	txHashes := make([]common.Hash, len(item.txes))
+	for i, tx := range item.txes {
+		txHashes[i] = tx.Hash()
+	}
+	item.returnResult(&BundleReceipt{
+		BundleHash:  BundleHash(item.txes),
+		BlockHash:   block.Hash(),
+		BlockNumber: hexutil.Uint64(block.NumberU64()),
+		TxHashes:    txHashes,
+	}, nil)
+	return true
+}
+
+type BundleAPI struct {
+	sequencer *Sequencer
+}
+
+func NewBundleAPI(sequencer *Sequencer) *BundleAPI {
+	return &BundleAPI{sequencer}
+}
+
+type SendBundleArgs struct {
+	Txs []hexutil.Bytes `json:"txs"`
+	// Unix timestamp in seconds the bundle must be sequenced by
+	Deadline hexutil.Uint64 `json:"deadline"`
+}
+
+// SendBundle returns once the bundle was sequenced, or failed to be.
+func (a *BundleAPI) SendBundle(ctx context.Context, args SendBundleArgs) (*BundleReceipt, error) {
+	txes := make(types.Transactions, len(args.Txs))
+	for i, encoded := range args.Txs {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(encoded); err != nil {
+			return nil, fmt.Errorf("decoding bundle transaction %v: %w", i, err)
+		}
+		txes[i] = tx
+	}
+	return a.sequencer.PublishBundle(ctx, txes, time.Unix(int64(args.Deadline), 0))
+}
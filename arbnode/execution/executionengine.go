@@ -237,7 +237,7 @@ func (s *ExecutionEngine) resequenceReorgedMessages(messages []*arbostypes.Messa
 		}
 		hooks := arbos.NoopSequencingHooks()
 		hooks.DiscardInvalidTxsEarly = true
-		_, err = s.sequenceTransactionsWithBlockMutex(msg.Message.Header, txes, hooks)
+		_, err = s.sequenceTransactionsWithBlockMutex(msg.Message.Header, txes, hooks, false)
 		if err != nil {
 			log.Error("failed to re-sequence old user message removed by reorg", "err", err)
 			return
@@ -279,11 +279,36 @@ func (s *ExecutionEngine) sequencerWrapper(sequencerFunc func() (*types.Block, e
 func (s *ExecutionEngine) SequenceTransactions(header *arbostypes.L1IncomingMessageHeader, txes types.Transactions, hooks *arbos.SequencingHooks) (*types.Block, error) {
 	return s.sequencerWrapper(func() (*types.Block, error) {
 		hooks.TxErrors = nil
-		return s.sequenceTransactionsWithBlockMutex(header, txes, hooks)
+		return s.sequenceTransactionsWithBlockMutex(header, txes, hooks, false)
 	})
 }
 
-func (s *ExecutionEngine) sequenceTransactionsWithBlockMutex(header *arbostypes.L1IncomingMessageHeader, txes types.Transactions, hooks *arbos.SequencingHooks) (*types.Block, error) {
+// BundleTxError is returned by SequenceBundle when one of the bundle's
+// transactions failed, so the bundle wasn't sequenced.
+type BundleTxError struct {
+	Index int
+	Err   error
+}
+
+func (e *BundleTxError) Error() string {
+	return fmt.Sprintf("bundle transaction %v failed: %v", e.Index, e.Err)
+}
+
+func (e *BundleTxError) Unwrap() error {
+	return e.Err
+}
+
+// SequenceBundle sequences the transactions in order in a block of their own,
+// only if all of them succeed. Otherwise no block is created and a
+// *BundleTxError is returned.
+func (s *ExecutionEngine) SequenceBundle(header *arbostypes.L1IncomingMessageHeader, txes types.Transactions, hooks *arbos.SequencingHooks) (*types.Block, error) {
+	return s.sequencerWrapper(func() (*types.Block, error) {
+		hooks.TxErrors = nil
+		return s.sequenceTransactionsWithBlockMutex(header, txes, hooks, true)
+	})
+}
+
+func (s *ExecutionEngine) sequenceTransactionsWithBlockMutex(header *arbostypes.L1IncomingMessageHeader, txes types.Transactions, hooks *arbos.SequencingHooks, atomic bool) (*types.Block, error) {
 	lastBlockHeader, err := s.getCurrentHeader()
 	if err != nil {
 		return nil, err
@@ -315,6 +340,14 @@ func (s *ExecutionEngine) sequenceTransactionsWithBlockMutex(header *arbostypes.
 		return nil, fmt.Errorf("unexpected number of error results: %v vs number of txes %v", len(hooks.TxErrors), len(txes))
 	}
 
+	if atomic {
+		for i, err := range hooks.TxErrors {
+			if err != nil {
+				return nil, &BundleTxError{Index: i, Err: err}
+			}
+		}
+	}
+
 	if len(receipts) == 0 {
 		return nil, nil
 	}
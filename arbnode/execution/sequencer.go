@@ -65,6 +65,9 @@ type SequencerConfig struct {
 	MaxTxDataSize               int                      `koanf:"max-tx-data-size" reload:"hot"`
 	NonceFailureCacheSize       int                      `koanf:"nonce-failure-cache-size" reload:"hot"`
 	NonceFailureCacheExpiry     time.Duration            `koanf:"nonce-failure-cache-expiry" reload:"hot"`
+	Ordering                    TxOrderingConfig         `koanf:"ordering" reload:"hot"`
+	AdmissionPolicy             AdmissionPolicyConfig    `koanf:"admission-policy" reload:"hot"`
+	Bundles                     BundleConfig             `koanf:"bundles" reload:"hot"`
 	Dangerous                   DangerousSequencerConfig `koanf:"dangerous"`
 }
 
@@ -94,7 +97,13 @@ func (c *SequencerConfig) Validate() error {
 			return fmt.Errorf("sequencer sender whitelist entry \"%v\" is not a valid address", address)
 		}
 	}
-	return nil
+	if err := c.Ordering.Validate(); err != nil {
+		return err
+	}
+	if err := c.AdmissionPolicy.Validate(); err != nil {
+		return err
+	}
+	return c.Bundles.Validate()
 }
 
 type SequencerConfigFetcher func() *SequencerConfig
@@ -114,6 +123,9 @@ var DefaultSequencerConfig = SequencerConfig{
 	MaxTxDataSize:           95000,
 	NonceFailureCacheSize:   1024,
 	NonceFailureCacheExpiry: time.Second,
+	Ordering:                DefaultTxOrderingConfig,
+	AdmissionPolicy:         DefaultAdmissionPolicyConfig,
+	Bundles:                 DefaultBundleConfig,
 }
 
 var TestSequencerConfig = SequencerConfig{
@@ -130,6 +142,9 @@ var TestSequencerConfig = SequencerConfig{
 	MaxTxDataSize:               95000,
 	NonceFailureCacheSize:       1024,
 	NonceFailureCacheExpiry:     time.Second,
+	Ordering:                    DefaultTxOrderingConfig,
+	AdmissionPolicy:             DefaultAdmissionPolicyConfig,
+	Bundles:                     DefaultBundleConfig,
 }
 
 func SequencerConfigAddOptions(prefix string, f *flag.FlagSet) {
@@ -145,6 +160,9 @@ func SequencerConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Int(prefix+".max-tx-data-size", DefaultSequencerConfig.MaxTxDataSize, "maximum transaction size the sequencer will accept")
 	f.Int(prefix+".nonce-failure-cache-size", DefaultSequencerConfig.NonceFailureCacheSize, "number of transactions with too high of a nonce to keep in memory while waiting for their predecessor")
 	f.Duration(prefix+".nonce-failure-cache-expiry", DefaultSequencerConfig.NonceFailureCacheExpiry, "maximum amount of time to wait for a predecessor before rejecting a tx with nonce too high")
+	TxOrderingConfigAddOptions(prefix+".ordering", f)
+	AdmissionPolicyConfigAddOptions(prefix+".admission-policy", f)
+	BundleConfigAddOptions(prefix+".bundles", f)
 	DangerousSequencerConfigAddOptions(prefix+".dangerous", f)
 }
 
@@ -293,9 +311,12 @@ type Sequencer struct {
 	execEngine      *ExecutionEngine
 	txQueue         chan txQueueItem
 	txRetryQueue    containers.Queue[txQueueItem]
+	txHeldQueue     []txQueueItem
+	bundleQueue     chan *bundleQueueItem
 	l1Reader        *headerreader.HeaderReader
 	config          SequencerConfigFetcher
 	senderWhitelist map[common.Address]struct{}
+	admission       *AdmissionPolicyEngine
 	nonceCache      *nonceCache
 	nonceFailures   *nonceFailureCache
 	onForwarderSet  chan struct{}
@@ -325,12 +346,18 @@ func NewSequencer(execEngine *ExecutionEngine, l1Reader *headerreader.HeaderRead
 		}
 		senderWhitelist[common.HexToAddress(address)] = struct{}{}
 	}
+	admission, err := NewAdmissionPolicyEngine(func() *AdmissionPolicyConfig { return &configFetcher().AdmissionPolicy })
+	if err != nil {
+		return nil, err
+	}
 	s := &Sequencer{
 		execEngine:      execEngine,
 		txQueue:         make(chan txQueueItem, config.QueueSize),
+		bundleQueue:     make(chan *bundleQueueItem, config.Bundles.QueueSize),
 		l1Reader:        l1Reader,
 		config:          configFetcher,
 		senderWhitelist: senderWhitelist,
+		admission:       admission,
 		nonceCache:      newNonceCache(config.NonceCacheSize),
 		l1BlockNumber:   0,
 		l1Timestamp:     0,
@@ -395,17 +422,20 @@ func (s *Sequencer) PublishTransaction(parentCtx context.Context, tx *types.Tran
 		}
 	}
 
+	signer := types.LatestSigner(s.execEngine.bc.Config())
+	sender, err := types.Sender(signer, tx)
+	if err != nil {
+		return err
+	}
 	if len(s.senderWhitelist) > 0 {
-		signer := types.LatestSigner(s.execEngine.bc.Config())
-		sender, err := types.Sender(signer, tx)
-		if err != nil {
-			return err
-		}
 		_, authorized := s.senderWhitelist[sender]
 		if !authorized {
-			return errors.New("transaction sender is not on the whitelist")
+			return errSenderNotOnWhitelist
 		}
 	}
+	if err := s.admission.Admit(sender, tx, time.Now()); err != nil {
+		return err
+	}
 	if tx.Type() >= types.ArbitrumDepositTxType {
 		// Should be unreachable due to UnmarshalBinary not accepting Arbitrum internal txs
 		return types.ErrTxTypeNotSupported
@@ -646,6 +676,31 @@ func (s *Sequencer) expireNonceFailures() *time.Timer {
 	}
 }
 
+// releaseHeldTxs moves the held txs the ordering policy is now ready to
+// sequence to the retry queue, and returns a timer for when the next held tx
+// will be ready, or nil if none are held.
+func (s *Sequencer) releaseHeldTxs(policy txOrderingPolicy) *time.Timer {
+	now := time.Now()
+	var nextReady time.Time
+	stillHeld := s.txHeldQueue[:0]
+	for _, item := range s.txHeldQueue {
+		readyAt := policy.readyAt(&item)
+		if !readyAt.After(now) {
+			s.txRetryQueue.Push(item)
+			continue
+		}
+		stillHeld = append(stillHeld, item)
+		if nextReady.IsZero() || readyAt.Before(nextReady) {
+			nextReady = readyAt
+		}
+	}
+	s.txHeldQueue = stillHeld
+	if nextReady.IsZero() {
+		return nil
+	}
+	return time.NewTimer(nextReady.Sub(now))
+}
+
 // There's no guarantee that returned tx nonces will be correct
 func (s *Sequencer) precheckNonces(queueItems []txQueueItem) []txQueueItem {
 	bc := s.execEngine.bc
@@ -731,6 +786,35 @@ func (s *Sequencer) precheckNonces(queueItems []txQueueItem) []txQueueItem {
 	return outputQueueItems
 }
 
+// nextBlockHeader returns the header to sequence the next block with, or nil
+// if the L1 block is unknown or its timestamp is too far from the local clock.
+func (s *Sequencer) nextBlockHeader(config *SequencerConfig) *arbostypes.L1IncomingMessageHeader {
+	timestamp := time.Now().Unix()
+	s.L1BlockAndTimeMutex.Lock()
+	l1Block := s.l1BlockNumber
+	l1Timestamp := s.l1Timestamp
+	s.L1BlockAndTimeMutex.Unlock()
+
+	if s.l1Reader != nil && (l1Block == 0 || math.Abs(float64(l1Timestamp)-float64(timestamp)) > config.MaxAcceptableTimestampDelta.Seconds()) {
+		log.Error(
+			"cannot sequence: unknown L1 block or L1 timestamp too far from local clock time",
+			"l1Block", l1Block,
+			"l1Timestamp", time.Unix(int64(l1Timestamp), 0),
+			"localTimestamp", time.Unix(int64(timestamp), 0),
+		)
+		return nil
+	}
+
+	return &arbostypes.L1IncomingMessageHeader{
+		Kind:        arbostypes.L1MessageType_L2Message,
+		Poster:      l1pricing.BatchPosterAddress,
+		BlockNumber: l1Block,
+		Timestamp:   uint64(timestamp),
+		RequestId:   nil,
+		L1BaseFee:   nil,
+	}
+}
+
 func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 	var queueItems []txQueueItem
 	var totalBatchSize int
@@ -753,6 +837,13 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 
 	config := s.config()
 
+	// Bundles get a block of their own
+	select {
+	case bundle := <-s.bundleQueue:
+		return s.sequenceBundle(bundle)
+	default:
+	}
+
 	// Clear out old nonceFailures
 	s.nonceFailures.Resize(config.NonceFailureCacheSize)
 	nextNonceExpiryTimer := s.expireNonceFailures()
@@ -763,6 +854,19 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 		}
 	}()
 
+	bc := s.execEngine.bc
+	orderingPolicy := newTxOrderingPolicy(&config.Ordering, types.LatestSigner(bc.Config()), bc.CurrentBlock().BaseFee)
+	nextReleaseTimer := s.releaseHeldTxs(orderingPolicy)
+	var collectionTimer *time.Timer
+	defer func() {
+		if nextReleaseTimer != nil {
+			nextReleaseTimer.Stop()
+		}
+		if collectionTimer != nil {
+			collectionTimer.Stop()
+		}
+	}()
+
 	for {
 		var queueItem txQueueItem
 		if s.txRetryQueue.Len() > 0 {
@@ -772,12 +876,21 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 			if nextNonceExpiryTimer != nil {
 				nextNonceExpiryChan = nextNonceExpiryTimer.C
 			}
+			var nextReleaseChan <-chan time.Time
+			if nextReleaseTimer != nil {
+				nextReleaseChan = nextReleaseTimer.C
+			}
 			select {
 			case queueItem = <-s.txQueue:
 			case <-nextNonceExpiryChan:
 				// No need to stop the previous timer since it already elapsed
 				nextNonceExpiryTimer = s.expireNonceFailures()
 				continue
+			case <-nextReleaseChan:
+				nextReleaseTimer = s.releaseHeldTxs(orderingPolicy)
+				continue
+			case bundle := <-s.bundleQueue:
+				return s.sequenceBundle(bundle)
 			case <-s.onForwarderSet:
 				// Make sure this notification isn't outdated
 				_, forwarder := s.GetPauseAndForwarder()
@@ -790,10 +903,21 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 			}
 		} else {
 			done := false
-			select {
-			case queueItem = <-s.txQueue:
-			default:
-				done = true
+			if collectionTimer != nil {
+				// Keep collecting txs until the ordering policy's window closes
+				select {
+				case queueItem = <-s.txQueue:
+				case <-collectionTimer.C:
+					done = true
+				case <-ctx.Done():
+					done = true
+				}
+			} else {
+				select {
+				case queueItem = <-s.txQueue:
+				default:
+					done = true
+				}
 			}
 			if done {
 				break
@@ -804,6 +928,14 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 			queueItem.returnResult(err)
 			continue
 		}
+		if orderingPolicy.readyAt(&queueItem).After(time.Now()) {
+			s.txHeldQueue = append(s.txHeldQueue, queueItem)
+			if nextReleaseTimer != nil {
+				nextReleaseTimer.Stop()
+			}
+			nextReleaseTimer = s.releaseHeldTxs(orderingPolicy)
+			continue
+		}
 		txBytes, err := queueItem.tx.MarshalBinary()
 		if err != nil {
 			queueItem.returnResult(err)
@@ -822,8 +954,12 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 		}
 		totalBatchSize += len(txBytes)
 		queueItems = append(queueItems, queueItem)
+		if len(queueItems) == 1 && orderingPolicy.collectionWindow() > 0 {
+			collectionTimer = time.NewTimer(orderingPolicy.collectionWindow())
+		}
 	}
 
+	orderingPolicy.order(queueItems)
 	s.nonceCache.Resize(config.NonceCacheSize) // Would probably be better in a config hook but this is basically free
 	s.nonceCache.BeginNewBlock()
 	queueItems = s.precheckNonces(queueItems)
@@ -839,31 +975,11 @@ func (s *Sequencer) createBlock(ctx context.Context) (returnValue bool) {
 		return false
 	}
 
-	timestamp := time.Now().Unix()
-	s.L1BlockAndTimeMutex.Lock()
-	l1Block := s.l1BlockNumber
-	l1Timestamp := s.l1Timestamp
-	s.L1BlockAndTimeMutex.Unlock()
-
-	if s.l1Reader != nil && (l1Block == 0 || math.Abs(float64(l1Timestamp)-float64(timestamp)) > config.MaxAcceptableTimestampDelta.Seconds()) {
-		log.Error(
-			"cannot sequence: unknown L1 block or L1 timestamp too far from local clock time",
-			"l1Block", l1Block,
-			"l1Timestamp", time.Unix(int64(l1Timestamp), 0),
-			"localTimestamp", time.Unix(int64(timestamp), 0),
-		)
+	header := s.nextBlockHeader(config)
+	if header == nil {
 		return false
 	}
 
-	header := &arbostypes.L1IncomingMessageHeader{
-		Kind:        arbostypes.L1MessageType_L2Message,
-		Poster:      l1pricing.BatchPosterAddress,
-		BlockNumber: l1Block,
-		Timestamp:   uint64(timestamp),
-		RequestId:   nil,
-		L1BaseFee:   nil,
-	}
-
 	start := time.Now()
 	block, err := s.execEngine.SequenceTransactions(header, txes, hooks)
 	elapsed := time.Since(start)
@@ -990,6 +1106,10 @@ func (s *Sequencer) Start(ctxIn context.Context) error {
 
 	}
 
+	s.CallIteratively(func(ctx context.Context) time.Duration {
+		return s.admission.reloadIteratively()
+	})
+
 	s.CallIteratively(func(ctx context.Context) time.Duration {
 		nextBlock := time.Now().Add(s.config().MaxBlockSpeed)
 		madeBlock := s.createBlock(ctx)
@@ -1006,11 +1126,18 @@ func (s *Sequencer) Start(ctxIn context.Context) error {
 
 func (s *Sequencer) StopAndWait() {
 	s.StopWaiter.StopAndWait()
-	if s.txRetryQueue.Len() == 0 && len(s.txQueue) == 0 && s.nonceFailures.Len() == 0 {
+	for len(s.bundleQueue) > 0 {
+		(<-s.bundleQueue).returnResult(nil, ErrNoSequencer)
+	}
+	if s.txRetryQueue.Len() == 0 && len(s.txQueue) == 0 && s.nonceFailures.Len() == 0 && len(s.txHeldQueue) == 0 {
 		return
 	}
 	// this usually means that coordinator's safe-shutdown-delay is too low
-	log.Warn("Sequencer has queued items while shutting down", "txQueue", len(s.txQueue), "retryQueue", s.txRetryQueue.Len(), "nonceFailures", s.nonceFailures.Len())
+	log.Warn("Sequencer has queued items while shutting down", "txQueue", len(s.txQueue), "retryQueue", s.txRetryQueue.Len(), "nonceFailures", s.nonceFailures.Len(), "heldQueue", len(s.txHeldQueue))
+	for _, item := range s.txHeldQueue {
+		s.txRetryQueue.Push(item)
+	}
+	s.txHeldQueue = nil
 	_, forwarder := s.GetPauseAndForwarder()
 	if forwarder != nil {
 		var wg sync.WaitGroup
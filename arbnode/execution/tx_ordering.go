@@ -0,0 +1,222 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package execution
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	flag "github.com/spf13/pflag"
+)
+
+const (
+	TxOrderingFIFO        = "fifo"
+	TxOrderingPriorityFee = "priority-fee"
+	TxOrderingExpressLane = "express-lane"
+)
+
+type TxOrderingConfig struct {
+	Policy               string        `koanf:"policy" reload:"hot"`
+	PriorityFeeWindow    time.Duration `koanf:"priority-fee-window" reload:"hot"`
+	ExpressLaneAddress   string        `koanf:"express-lane-address" reload:"hot"`
+	ExpressLaneAdvantage time.Duration `koanf:"express-lane-advantage" reload:"hot"`
+}
+
+var DefaultTxOrderingConfig = TxOrderingConfig{
+	Policy:               TxOrderingFIFO,
+	PriorityFeeWindow:    time.Millisecond * 50,
+	ExpressLaneAddress:   "",
+	ExpressLaneAdvantage: time.Millisecond * 200,
+}
+
+func TxOrderingConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".policy", DefaultTxOrderingConfig.Policy, "order to sequence queued transactions in: \"fifo\" for arrival order, \"priority-fee\" to order transactions received within priority-fee-window by priority fee, or \"express-lane\" to give transactions from express-lane-address a head start")
+	f.Duration(prefix+".priority-fee-window", DefaultTxOrderingConfig.PriorityFeeWindow, "with the priority-fee policy, how long to collect transactions for a block before ordering them")
+	f.String(prefix+".express-lane-address", DefaultTxOrderingConfig.ExpressLaneAddress, "with the express-lane policy, the sender whose transactions are sequenced without delay")
+	f.Duration(prefix+".express-lane-advantage", DefaultTxOrderingConfig.ExpressLaneAdvantage, "with the express-lane policy, how long other senders' transactions are delayed")
+}
+
+func (c *TxOrderingConfig) Validate() error {
+	switch c.Policy {
+	case TxOrderingFIFO:
+	case TxOrderingPriorityFee:
+		if c.PriorityFeeWindow < 0 {
+			return fmt.Errorf("sequencer ordering priority-fee-window %v is negative", c.PriorityFeeWindow)
+		}
+	case TxOrderingExpressLane:
+		if !common.IsHexAddress(c.ExpressLaneAddress) {
+			return fmt.Errorf("sequencer ordering express-lane-address \"%v\" is not a valid address", c.ExpressLaneAddress)
+		}
+		if c.ExpressLaneAdvantage < 0 {
+			return fmt.Errorf("sequencer ordering express-lane-advantage %v is negative", c.ExpressLaneAdvantage)
+		}
+	default:
+		return fmt.Errorf("unknown sequencer ordering policy \"%v\"", c.Policy)
+	}
+	return nil
+}
+
+// txOrderingPolicy decides when the sequencer may sequence queued
+// transactions, and the order it sequences them in. Whatever the order, a
+// sender's transactions must stay in nonce order, or the later ones will fail
+// their nonce checks.
+type txOrderingPolicy interface {
+	// readyAt returns the earliest time the transaction may be sequenced.
+	// Transactions are held back until then.
+	readyAt(item *txQueueItem) time.Time
+	// collectionWindow is how long to keep collecting transactions for a
+	// block after the first, so that there's something to reorder.
+	collectionWindow() time.Duration
+	// order reorders the transactions collected for a block in place.
+	order(items []txQueueItem)
+}
+
+func newTxOrderingPolicy(config *TxOrderingConfig, signer types.Signer, baseFee *big.Int) txOrderingPolicy {
+	switch config.Policy {
+	case TxOrderingPriorityFee:
+		return &priorityFeeOrderingPolicy{
+			window:  config.PriorityFeeWindow,
+			signer:  signer,
+			baseFee: baseFee,
+		}
+	case TxOrderingExpressLane:
+		return &expressLaneOrderingPolicy{
+			address:   common.HexToAddress(config.ExpressLaneAddress),
+			advantage: config.ExpressLaneAdvantage,
+			signer:    signer,
+		}
+	default:
+		return fifoOrderingPolicy{}
+	}
+}
+
+// fifoOrderingPolicy sequences transactions in the order they arrived.
+type fifoOrderingPolicy struct{}
+
+func (fifoOrderingPolicy) readyAt(item *txQueueItem) time.Time {
+	return item.firstAppearance
+}
+
+func (fifoOrderingPolicy) collectionWindow() time.Duration {
+	return 0
+}
+
+func (fifoOrderingPolicy) order([]txQueueItem) {}
+
+// priorityFeeOrderingPolicy collects transactions for a window and sequences
+// them by decreasing effective priority fee, breaking ties by arrival order.
+type priorityFeeOrderingPolicy struct {
+	window  time.Duration
+	signer  types.Signer
+	baseFee *big.Int
+}
+
+func (p *priorityFeeOrderingPolicy) readyAt(item *txQueueItem) time.Time {
+	return item.firstAppearance
+}
+
+func (p *priorityFeeOrderingPolicy) collectionWindow() time.Duration {
+	return p.window
+}
+
+func (p *priorityFeeOrderingPolicy) tip(tx *types.Transaction) *big.Int {
+	if p.baseFee == nil {
+		return tx.GasTipCap()
+	}
+	tip := tx.EffectiveGasTipValue(p.baseFee)
+	if tip.Sign() < 0 {
+		// The fee cap is below the base fee, so this tx will fail anyway
+		return common.Big0
+	}
+	return tip
+}
+
+func (p *priorityFeeOrderingPolicy) order(items []txQueueItem) {
+	tips := make([]*big.Int, len(items))
+	for i := range items {
+		tips[i] = p.tip(items[i].tx)
+	}
+	indices := make([]int, len(items))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return tips[indices[i]].Cmp(tips[indices[j]]) > 0
+	})
+	sorted := make([]txQueueItem, len(items))
+	for i, idx := range indices {
+		sorted[i] = items[idx]
+	}
+	restoreNonceOrder(sorted, p.signer)
+	copy(items, sorted)
+}
+
+// restoreNonceOrder puts each sender's transactions back in nonce order,
+// keeping the positions the reordering gave the sender's transactions.
+func restoreNonceOrder(items []txQueueItem, signer types.Signer) {
+	positions := make(map[common.Address][]int)
+	var senders []common.Address
+	for i := range items {
+		sender, err := types.Sender(signer, items[i].tx)
+		if err != nil {
+			// This tx will be rejected by the nonce pre-check
+			continue
+		}
+		if _, ok := positions[sender]; !ok {
+			senders = append(senders, sender)
+		}
+		positions[sender] = append(positions[sender], i)
+	}
+	for _, sender := range senders {
+		senderPositions := positions[sender]
+		if len(senderPositions) < 2 {
+			continue
+		}
+		senderItems := make([]txQueueItem, len(senderPositions))
+		for i, pos := range senderPositions {
+			senderItems[i] = items[pos]
+		}
+		sort.SliceStable(senderItems, func(i, j int) bool {
+			return senderItems[i].tx.Nonce() < senderItems[j].tx.Nonce()
+		})
+		for i, pos := range senderPositions {
+			items[pos] = senderItems[i]
+		}
+	}
+}
+
+// expressLaneOrderingPolicy gives the express lane address a head start:
+// other senders' transactions are held back for the advantage, and the
+// express lane's transactions are sequenced first in each block.
+type expressLaneOrderingPolicy struct {
+	address   common.Address
+	advantage time.Duration
+	signer    types.Signer
+}
+
+func (p *expressLaneOrderingPolicy) isExpress(tx *types.Transaction) bool {
+	sender, err := types.Sender(p.signer, tx)
+	return err == nil && sender == p.address
+}
+
+func (p *expressLaneOrderingPolicy) readyAt(item *txQueueItem) time.Time {
+	if p.isExpress(item.tx) {
+		return item.firstAppearance
+	}
+	return item.firstAppearance.Add(p.advantage)
+}
+
+func (p *expressLaneOrderingPolicy) collectionWindow() time.Duration {
+	return 0
+}
+
+func (p *expressLaneOrderingPolicy) order(items []txQueueItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return p.isExpress(items[i].tx) && !p.isExpress(items[j].tx)
+	})
+}
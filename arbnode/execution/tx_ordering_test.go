@@ -0,0 +1,186 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package execution
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+var testOrderingChainId = big.NewInt(412346)
+
+func testQueueItem(t *testing.T, key *ecdsa.PrivateKey, nonce uint64, tipCap int64, firstAppearance time.Time) txQueueItem {
+	t.Helper()
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(testOrderingChainId), &types.DynamicFeeTx{
+		ChainID:   testOrderingChainId,
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(tipCap),
+		GasFeeCap: big.NewInt(100),
+		Gas:       21000,
+		To:        &common.Address{},
+	})
+	Require(t, err)
+	return txQueueItem{
+		tx:              tx,
+		resultChan:      make(chan error, 1),
+		ctx:             context.Background(),
+		firstAppearance: firstAppearance,
+	}
+}
+
+func generateKeys(t *testing.T, count int) []*ecdsa.PrivateKey {
+	t.Helper()
+	keys := make([]*ecdsa.PrivateKey, count)
+	for i := range keys {
+		var err error
+		keys[i], err = crypto.GenerateKey()
+		Require(t, err)
+	}
+	return keys
+}
+
+func expectOrder(t *testing.T, items []txQueueItem, expected []txQueueItem) {
+	t.Helper()
+	if len(items) != len(expected) {
+		Fail(t, "ordered", len(items), "items, expected", len(expected))
+	}
+	for i := range items {
+		if items[i].tx.Hash() != expected[i].tx.Hash() {
+			Fail(t, "unexpected tx at position", i, "nonce", items[i].tx.Nonce(), "tip", items[i].tx.GasTipCap())
+		}
+	}
+}
+
+func TestFIFOOrdering(t *testing.T) {
+	config := DefaultTxOrderingConfig
+	Require(t, config.Validate())
+	policy := newTxOrderingPolicy(&config, types.LatestSignerForChainID(testOrderingChainId), big.NewInt(10))
+
+	keys := generateKeys(t, 2)
+	now := time.Now()
+	items := []txQueueItem{
+		testQueueItem(t, keys[0], 0, 1, now),
+		testQueueItem(t, keys[1], 0, 50, now),
+	}
+	expected := append([]txQueueItem{}, items...)
+	policy.order(items)
+	expectOrder(t, items, expected)
+	if policy.collectionWindow() != 0 || !policy.readyAt(&items[0]).Equal(now) {
+		Fail(t, "fifo policy delayed transactions")
+	}
+}
+
+func TestPriorityFeeOrdering(t *testing.T) {
+	config := DefaultTxOrderingConfig
+	config.Policy = TxOrderingPriorityFee
+	Require(t, config.Validate())
+	policy := newTxOrderingPolicy(&config, types.LatestSignerForChainID(testOrderingChainId), big.NewInt(10))
+	if policy.collectionWindow() != config.PriorityFeeWindow {
+		Fail(t, "unexpected collection window", policy.collectionWindow())
+	}
+
+	keys := generateKeys(t, 3)
+	now := time.Now()
+	lowTip := testQueueItem(t, keys[0], 0, 1, now)
+	// The tip is capped by the fee cap minus the base fee, so this pays 90
+	cappedTip := testQueueItem(t, keys[1], 0, 1000, now)
+	highTip := testQueueItem(t, keys[2], 0, 50, now)
+	sameTip := testQueueItem(t, keys[0], 1, 50, now)
+	// The sender's later nonce pays more, but it must not go before its predecessor
+	nextNonce := testQueueItem(t, keys[2], 1, 80, now)
+	items := []txQueueItem{lowTip, cappedTip, highTip, sameTip, nextNonce}
+	policy.order(items)
+	// By tip, the order would be cappedTip, nextNonce, highTip, sameTip,
+	// lowTip, but keys[0] and keys[2]'s transactions are kept in nonce order
+	expectOrder(t, items, []txQueueItem{cappedTip, highTip, nextNonce, lowTip, sameTip})
+}
+
+func TestExpressLaneOrdering(t *testing.T) {
+	keys := generateKeys(t, 2)
+	express := crypto.PubkeyToAddress(keys[0].PublicKey)
+	config := DefaultTxOrderingConfig
+	config.Policy = TxOrderingExpressLane
+	config.ExpressLaneAddress = express.Hex()
+	Require(t, config.Validate())
+	policy := newTxOrderingPolicy(&config, types.LatestSignerForChainID(testOrderingChainId), big.NewInt(10))
+
+	now := time.Now()
+	other := testQueueItem(t, keys[1], 0, 1, now)
+	expressItem := testQueueItem(t, keys[0], 0, 1, now)
+	if !policy.readyAt(&expressItem).Equal(now) {
+		Fail(t, "express lane tx was delayed")
+	}
+	if !policy.readyAt(&other).Equal(now.Add(config.ExpressLaneAdvantage)) {
+		Fail(t, "other tx ready at", policy.readyAt(&other), "expected", now.Add(config.ExpressLaneAdvantage))
+	}
+
+	otherLater := testQueueItem(t, keys[1], 1, 1, now)
+	items := []txQueueItem{other, expressItem, otherLater}
+	policy.order(items)
+	expectOrder(t, items, []txQueueItem{expressItem, other, otherLater})
+}
+
+func TestReleaseHeldTxs(t *testing.T) {
+	keys := generateKeys(t, 2)
+	config := DefaultTxOrderingConfig
+	config.Policy = TxOrderingExpressLane
+	config.ExpressLaneAddress = crypto.PubkeyToAddress(keys[0].PublicKey).Hex()
+	config.ExpressLaneAdvantage = time.Hour
+	policy := newTxOrderingPolicy(&config, types.LatestSignerForChainID(testOrderingChainId), nil)
+
+	now := time.Now()
+	s := &Sequencer{}
+	s.txHeldQueue = []txQueueItem{
+		testQueueItem(t, keys[1], 0, 1, now),
+		testQueueItem(t, keys[1], 1, 1, now.Add(-2*time.Hour)),
+	}
+	timer := s.releaseHeldTxs(policy)
+	if timer == nil {
+		Fail(t, "expected a timer for the held tx")
+	}
+	timer.Stop()
+	if len(s.txHeldQueue) != 1 || s.txHeldQueue[0].tx.Nonce() != 0 {
+		Fail(t, "unexpected held txs", len(s.txHeldQueue))
+	}
+	if s.txRetryQueue.Len() != 1 || s.txRetryQueue.Pop().tx.Nonce() != 1 {
+		Fail(t, "expected the ready tx to be released")
+	}
+
+	s.txHeldQueue = nil
+	if s.releaseHeldTxs(policy) != nil {
+		Fail(t, "expected no timer without held txs")
+	}
+}
+
+func TestInvalidTxOrderingConfig(t *testing.T) {
+	for _, config := range []TxOrderingConfig{
+		{Policy: "random"},
+		{Policy: TxOrderingPriorityFee, PriorityFeeWindow: -time.Second},
+		{Policy: TxOrderingExpressLane, ExpressLaneAddress: "0x1234"},
+		{Policy: TxOrderingExpressLane, ExpressLaneAddress: common.Address{}.Hex(), ExpressLaneAdvantage: -time.Second},
+	} {
+		if err := config.Validate(); err == nil {
+			Fail(t, "invalid config passed validation", config)
+		}
+	}
+}
+
+func Require(t *testing.T, err error, printables ...interface{}) {
+	t.Helper()
+	testhelpers.RequireImpl(t, err, printables...)
+}
+
+func Fail(t *testing.T, printables ...interface{}) {
+	t.Helper()
+	testhelpers.FailImpl(t, printables...)
+}
@@ -5,103 +5,212 @@ package arbnode
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/offchainlabs/nitro/arbnode/redislock"
 	"github.com/offchainlabs/nitro/util/stopwaiter"
 	flag "github.com/spf13/pflag"
 )
 
-// Regularly runs db compaction if configured
+// MaintenanceTask is a unit of maintenance work, such as compacting the databases.
+// Tasks run one at a time, after the sequencer handed off the lockout if it's coordinated.
+type MaintenanceTask interface {
+	Name() string
+	Run(ctx context.Context) error
+}
+
+// Regularly runs the maintenance tasks on their schedules
 type MaintenanceRunner struct {
 	stopwaiter.StopWaiter
 
-	config          MaintenanceConfigFetcher
-	seqCoordinator  *SeqCoordinator
-	dbs             []ethdb.Database
-	lastMaintenance time.Time
+	config         MaintenanceConfigFetcher
+	seqCoordinator *SeqCoordinator
+	tasks          []MaintenanceTask
+	// The last time each task was run on its schedule. Only accessed from the scheduling thread.
+	lastMaintenance map[string]time.Time
 
 	// lock is used to ensures that at any given time, only single node is on
 	// maintenance mode.
-	lock *redislock.Simple
+	lock MaintenanceLock
+
+	// Held while a maintenance window runs, either scheduled or triggered
+	runningMutex sync.Mutex
+
+	statusMutex sync.Mutex
+	running     bool
+	status      map[string]*MaintenanceTaskStatus
+	lastError   string
 }
 
 type MaintenanceConfig struct {
-	TimeOfDay string              `koanf:"time-of-day" reload:"hot"`
-	Lock      redislock.SimpleCfg `koanf:"lock" reload:"hot"`
+	TimeOfDay  string                `koanf:"time-of-day" reload:"hot"`
+	Compaction MaintenanceTaskConfig `koanf:"compaction" reload:"hot"`
+	TxIndex    MaintenanceTaskConfig `koanf:"tx-index" reload:"hot"`
+	Lock       redislock.SimpleCfg   `koanf:"lock" reload:"hot"`
 
 	// Generated: the minutes since start of UTC day to compact at
 	minutesAfterMidnight int
 	enabled              bool
 }
 
-// Returns true if successful
-func (c *MaintenanceConfig) parseDbCompactionTime() bool {
-	if c.TimeOfDay == "" {
-		return true
-	}
-	parts := strings.Split(c.TimeOfDay, ":")
+type MaintenanceTaskConfig struct {
+	Enable    bool   `koanf:"enable" reload:"hot"`
+	TimeOfDay string `koanf:"time-of-day" reload:"hot"`
+
+	// Generated: the minutes since start of UTC day to run the task at, if TimeOfDay is set
+	minutesAfterMidnight int
+}
+
+const (
+	compactionMaintenanceTask = "compaction"
+	txIndexMaintenanceTask    = "tx-index"
+)
+
+// parseTimeOfDay returns the minutes since start of UTC day of a 24-hour HH:MM time, and false if it's invalid
+func parseTimeOfDay(timeOfDay string) (int, bool) {
+	parts := strings.Split(timeOfDay, ":")
 	if len(parts) != 2 {
-		return false
+		return 0, false
 	}
 	hours, err := strconv.Atoi(parts[0])
 	if err != nil || hours >= 24 {
-		return false
+		return 0, false
 	}
 	minutes, err := strconv.Atoi(parts[1])
 	if err != nil || minutes >= 60 {
+		return 0, false
+	}
+	return hours*60 + minutes, true
+}
+
+// Returns true if successful
+func (c *MaintenanceConfig) parseDbCompactionTime() bool {
+	if c.TimeOfDay == "" {
+		return true
+	}
+	minutes, ok := parseTimeOfDay(c.TimeOfDay)
+	if !ok {
 		return false
 	}
 	c.enabled = true
-	c.minutesAfterMidnight = hours*60 + minutes
+	c.minutesAfterMidnight = minutes
 	return true
 }
 
+func (c *MaintenanceConfig) task(name string) *MaintenanceTaskConfig {
+	switch name {
+	case compactionMaintenanceTask:
+		return &c.Compaction
+	case txIndexMaintenanceTask:
+		return &c.TxIndex
+	default:
+		return nil
+	}
+}
+
+// schedule returns the minutes since start of UTC day to run the task at, and false if it isn't scheduled
+func (c *MaintenanceConfig) schedule(name string) (int, bool) {
+	task := c.task(name)
+	if task == nil || !task.Enable {
+		return 0, false
+	}
+	if task.TimeOfDay != "" {
+		return task.minutesAfterMidnight, true
+	}
+	return c.minutesAfterMidnight, c.enabled
+}
+
 func (c *MaintenanceConfig) Validate() error {
 	if !c.parseDbCompactionTime() {
 		return fmt.Errorf("expected sequencer coordinator db compaction time to be in 24-hour HH:MM format but got \"%v\"", c.TimeOfDay)
 	}
+	for _, name := range []string{compactionMaintenanceTask, txIndexMaintenanceTask} {
+		task := c.task(name)
+		if task.TimeOfDay == "" {
+			continue
+		}
+		minutes, ok := parseTimeOfDay(task.TimeOfDay)
+		if !ok {
+			return fmt.Errorf("expected %s maintenance time to be in 24-hour HH:MM format but got \"%v\"", name, task.TimeOfDay)
+		}
+		task.minutesAfterMidnight = minutes
+	}
 	return nil
 }
 
 func MaintenanceConfigAddOptions(prefix string, f *flag.FlagSet) {
-	f.String(prefix+".time-of-day", DefaultMaintenanceConfig.TimeOfDay, "UTC 24-hour time of day to run maintenance (currently only db compaction) at (e.g. 15:00)")
+	f.String(prefix+".time-of-day", DefaultMaintenanceConfig.TimeOfDay, "UTC 24-hour time of day to run the enabled maintenance tasks at, unless a task sets its own (e.g. 15:00)")
+	MaintenanceTaskConfigAddOptions(prefix+".compaction", f, DefaultMaintenanceConfig.Compaction, "compact the databases")
+	MaintenanceTaskConfigAddOptions(prefix+".tx-index", f, DefaultMaintenanceConfig.TxIndex, "rebuild the transaction index of the currently indexed blocks")
 	redislock.AddConfigOptions(prefix+".lock", f)
 }
 
+func MaintenanceTaskConfigAddOptions(prefix string, f *flag.FlagSet, defaultConfig MaintenanceTaskConfig, description string) {
+	f.Bool(prefix+".enable", defaultConfig.Enable, "during maintenance, "+description)
+	f.String(prefix+".time-of-day", defaultConfig.TimeOfDay, "UTC 24-hour time of day to run this task at instead of the maintenance time of day (e.g. 15:00)")
+}
+
 var DefaultMaintenanceConfig = MaintenanceConfig{
-	TimeOfDay: "",
+	TimeOfDay:  "",
+	Compaction: MaintenanceTaskConfig{Enable: true},
+	TxIndex:    MaintenanceTaskConfig{Enable: false},
 
 	minutesAfterMidnight: 0,
 }
 
 type MaintenanceConfigFetcher func() *MaintenanceConfig
 
-func NewMaintenanceRunner(config MaintenanceConfigFetcher, seqCoordinator *SeqCoordinator, dbs []ethdb.Database) (*MaintenanceRunner, error) {
+// MaintenanceTaskStatus reports the latest run of a maintenance task
+type MaintenanceTaskStatus struct {
+	Name string `json:"name"`
+	// The configured time of day the task runs at, empty if it isn't scheduled
+	TimeOfDay    string    `json:"timeOfDay,omitempty"`
+	Running      bool      `json:"running"`
+	LastStarted  time.Time `json:"lastStarted"`
+	LastFinished time.Time `json:"lastFinished"`
+	LastError    string    `json:"lastError,omitempty"`
+}
+
+type MaintenanceStatus struct {
+	Running bool `json:"running"`
+	// Why the latest maintenance window didn't run, if it didn't
+	LastError string                  `json:"lastError,omitempty"`
+	Tasks     []MaintenanceTaskStatus `json:"tasks"`
+}
+
+func NewMaintenanceRunner(config MaintenanceConfigFetcher, seqCoordinator *SeqCoordinator, tasks []MaintenanceTask) (*MaintenanceRunner, error) {
 	cfg := config()
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("validating config: %w", err)
 	}
+	now := time.Now().UTC()
 	res := &MaintenanceRunner{
 		config:          config,
 		seqCoordinator:  seqCoordinator,
-		dbs:             dbs,
-		lastMaintenance: time.Now().UTC(),
+		tasks:           tasks,
+		lastMaintenance: make(map[string]time.Time),
+		status:          make(map[string]*MaintenanceTaskStatus),
+	}
+	for _, task := range tasks {
+		if _, exists := res.status[task.Name()]; exists {
+			return nil, fmt.Errorf("duplicate maintenance task %v", task.Name())
+		}
+		res.lastMaintenance[task.Name()] = now
+		res.status[task.Name()] = &MaintenanceTaskStatus{Name: task.Name()}
 	}
 
 	if seqCoordinator != nil {
-		c := func() *redislock.SimpleCfg { return &cfg.Lock }
-		r := func() bool { return true } // always ready to lock
-		rl, err := redislock.NewSimple(seqCoordinator.Client, c, r)
+		lock, err := seqCoordinator.MaintenanceLock(func() *redislock.SimpleCfg { return &cfg.Lock })
 		if err != nil {
-			return nil, fmt.Errorf("creating new simple redis lock: %w", err)
+			return nil, err
 		}
-		res.lock = rl
+		res.lock = lock
 	}
 	return res, nil
 }
@@ -132,51 +241,156 @@ func wentPastTimeOfDay(before time.Time, after time.Time, timeOfDay int) bool {
 
 func (mr *MaintenanceRunner) maybeRunMaintenance(ctx context.Context) time.Duration {
 	config := mr.config()
-	if !config.enabled {
+	now := time.Now().UTC()
+
+	var due []MaintenanceTask
+	for _, task := range mr.tasks {
+		minutesAfterMidnight, scheduled := config.schedule(task.Name())
+		if scheduled && wentPastTimeOfDay(mr.lastMaintenance[task.Name()], now, minutesAfterMidnight) {
+			due = append(due, task)
+		}
+	}
+	if len(due) == 0 {
 		return time.Minute
 	}
 
-	now := time.Now().UTC()
-
-	if !wentPastTimeOfDay(mr.lastMaintenance, now, config.minutesAfterMidnight) {
+	if !mr.runningMutex.TryLock() {
+		// A triggered maintenance window is running, retry once it's done
+		return time.Minute
+	}
+	defer mr.runningMutex.Unlock()
+	if err := mr.runWindow(ctx, due); err != nil {
+		log.Debug("Scheduled maintenance didn't run", "err", err)
 		return time.Minute
 	}
+	for _, task := range due {
+		mr.lastMaintenance[task.Name()] = now
+	}
+	return time.Minute
+}
 
+// Trigger runs the named maintenance tasks, or all of them if none are named, in the background.
+// It fails if maintenance is already running. Progress is reported by Status.
+func (mr *MaintenanceRunner) Trigger(names []string) error {
+	var tasks []MaintenanceTask
+	if len(names) > 0 {
+		for _, name := range names {
+			task := mr.findTask(name)
+			if task == nil {
+				return fmt.Errorf("unknown maintenance task %v", name)
+			}
+			tasks = append(tasks, task)
+		}
+	} else {
+		tasks = mr.tasks
+	}
+	if !mr.runningMutex.TryLock() {
+		return errors.New("maintenance is already running")
+	}
+	err := mr.LaunchThreadSafe(func(ctx context.Context) {
+		defer mr.runningMutex.Unlock()
+		if err := mr.runWindow(ctx, tasks); err != nil {
+			log.Warn("Triggered maintenance didn't run", "err", err)
+		}
+	})
+	if err != nil {
+		mr.runningMutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (mr *MaintenanceRunner) findTask(name string) MaintenanceTask {
+	for _, task := range mr.tasks {
+		if task.Name() == name {
+			return task
+		}
+	}
+	return nil
+}
+
+func (mr *MaintenanceRunner) Status() MaintenanceStatus {
+	config := mr.config()
+	mr.statusMutex.Lock()
+	defer mr.statusMutex.Unlock()
+	status := MaintenanceStatus{
+		Running:   mr.running,
+		LastError: mr.lastError,
+	}
+	for _, task := range mr.tasks {
+		taskStatus := *mr.status[task.Name()]
+		if minutesAfterMidnight, scheduled := config.schedule(task.Name()); scheduled {
+			taskStatus.TimeOfDay = fmt.Sprintf("%02d:%02d", minutesAfterMidnight/60, minutesAfterMidnight%60)
+		}
+		status.Tasks = append(status.Tasks, taskStatus)
+	}
+	return status
+}
+
+// runWindow hands off the chosen sequencer status if coordinated, runs the tasks, and seeks the lockout again.
+// The runningMutex must be held. Returns an error if the tasks didn't run.
+func (mr *MaintenanceRunner) runWindow(ctx context.Context, tasks []MaintenanceTask) error {
+	mr.statusMutex.Lock()
+	mr.running = true
+	mr.statusMutex.Unlock()
+	err := mr.runWindowImpl(ctx, tasks)
+	mr.statusMutex.Lock()
+	mr.running = false
+	mr.lastError = ""
+	if err != nil {
+		mr.lastError = err.Error()
+	}
+	mr.statusMutex.Unlock()
+	return err
+}
+
+func (mr *MaintenanceRunner) runWindowImpl(ctx context.Context, tasks []MaintenanceTask) error {
 	if mr.seqCoordinator == nil {
-		mr.lastMaintenance = now
-		mr.runMaintenance()
-		return time.Minute
+		mr.runTasks(ctx, tasks)
+		return nil
 	}
 
 	if !mr.lock.AttemptLock(ctx) {
-		return time.Minute
+		return errors.New("another node holds the maintenance lock")
 	}
 	defer mr.lock.Release(ctx)
 
-	log.Info("Attempting avoiding lockout and handing off", "targetTime", config.TimeOfDay)
+	log.Info("Attempting avoiding lockout and handing off", "targetTime", mr.config().TimeOfDay)
 	// Avoid lockout for the sequencer and try to handoff.
-	if mr.seqCoordinator.AvoidLockout(ctx) && mr.seqCoordinator.TryToHandoffChosenOne(ctx) {
-		mr.lastMaintenance = now
-		mr.runMaintenance()
-	}
 	defer mr.seqCoordinator.SeekLockout(ctx) // needs called even if c.Zombify returns false
-
-	return time.Minute
+	if !mr.seqCoordinator.AvoidLockout(ctx) || !mr.seqCoordinator.TryToHandoffChosenOne(ctx, "maintenance") {
+		return errors.New("failed to hand off the chosen sequencer status")
+	}
+	mr.runTasks(ctx, tasks)
+	return nil
 }
 
-func (mr *MaintenanceRunner) runMaintenance() {
-	log.Info("Compacting databases (this may take a while...)")
-	results := make(chan error, len(mr.dbs))
-	for _, db := range mr.dbs {
-		db := db
-		go func() {
-			results <- db.Compact(nil, nil)
-		}()
-	}
-	for range mr.dbs {
-		if err := <-results; err != nil {
-			log.Warn("Failed to compact database", "err", err)
+func (mr *MaintenanceRunner) runTasks(ctx context.Context, tasks []MaintenanceTask) {
+	for _, task := range tasks {
+		if ctx.Err() != nil {
+			return
+		}
+		mr.statusMutex.Lock()
+		status := mr.status[task.Name()]
+		status.Running = true
+		status.LastStarted = time.Now()
+		mr.statusMutex.Unlock()
+
+		log.Info("Running maintenance task", "task", task.Name())
+		err := task.Run(ctx)
+		if err != nil {
+			log.Warn("Maintenance task failed", "task", task.Name(), "err", err)
+		} else {
+			log.Info("Done running maintenance task", "task", task.Name())
+		}
+
+		mr.statusMutex.Lock()
+		status.Running = false
+		status.LastFinished = time.Now()
+		status.LastError = ""
+		if err != nil {
+			status.LastError = err.Error()
 		}
+		mr.statusMutex.Unlock()
 	}
-	log.Info("Done compacting databases")
 }
@@ -0,0 +1,87 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// dbCompactionTask compacts the databases in parallel
+type dbCompactionTask struct {
+	dbs []ethdb.Database
+}
+
+func newDbCompactionTask(dbs []ethdb.Database) *dbCompactionTask {
+	return &dbCompactionTask{dbs: dbs}
+}
+
+func (t *dbCompactionTask) Name() string {
+	return compactionMaintenanceTask
+}
+
+func (t *dbCompactionTask) Run(ctx context.Context) error {
+	log.Info("Compacting databases (this may take a while...)")
+	results := make(chan error, len(t.dbs))
+	for _, db := range t.dbs {
+		db := db
+		go func() {
+			results <- db.Compact(nil, nil)
+		}()
+	}
+	var errs []error
+	for range t.dbs {
+		if err := <-results; err != nil {
+			log.Warn("Failed to compact database", "err", err)
+			errs = append(errs, err)
+		}
+	}
+	log.Info("Done compacting databases")
+	return errors.Join(errs...)
+}
+
+// txIndexTask rewrites the transaction lookup entries of the blocks currently indexed,
+// from the transaction index tail up to the head block.
+type txIndexTask struct {
+	chainDb ethdb.Database
+}
+
+func newTxIndexTask(chainDb ethdb.Database) *txIndexTask {
+	return &txIndexTask{chainDb: chainDb}
+}
+
+func (t *txIndexTask) Name() string {
+	return txIndexMaintenanceTask
+}
+
+func (t *txIndexTask) Run(ctx context.Context) error {
+	tail := rawdb.ReadTxIndexTail(t.chainDb)
+	if tail == nil {
+		log.Info("Transaction index isn't initialized yet, skipping rebuilding it")
+		return nil
+	}
+	head := rawdb.ReadHeaderNumber(t.chainDb, rawdb.ReadHeadBlockHash(t.chainDb))
+	if head == nil {
+		return errors.New("head block number not found")
+	}
+	if *head < *tail {
+		return nil
+	}
+	interrupt := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			close(interrupt)
+		case <-done:
+		}
+	}()
+	rawdb.IndexTransactions(t.chainDb, *tail, *head+1, interrupt)
+	return ctx.Err()
+}
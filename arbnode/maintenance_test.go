@@ -4,6 +4,9 @@
 package arbnode
 
 import (
+	"context"
+	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -39,3 +42,97 @@ func TestWentPastTimeOfDay(t *testing.T) {
 		}
 	}
 }
+
+func TestMaintenanceTaskSchedule(t *testing.T) {
+	config := DefaultMaintenanceConfig
+	config.TimeOfDay = "03:00"
+	config.TxIndex = MaintenanceTaskConfig{Enable: true, TimeOfDay: "04:30"}
+	Require(t, config.Validate())
+
+	for _, tc := range []struct {
+		task      string
+		minutes   int
+		scheduled bool
+	}{
+		{task: compactionMaintenanceTask, minutes: 3 * 60, scheduled: true},
+		{task: txIndexMaintenanceTask, minutes: 4*60 + 30, scheduled: true},
+		{task: "unknown"},
+	} {
+		minutes, scheduled := config.schedule(tc.task)
+		if scheduled != tc.scheduled || (scheduled && minutes != tc.minutes) {
+			Fail(t, "task", tc.task, "scheduled", scheduled, "at", minutes, "expected", tc.scheduled, tc.minutes)
+		}
+	}
+
+	config.Compaction.TimeOfDay = "25:00"
+	if err := config.Validate(); err == nil {
+		Fail(t, "invalid task time of day passed validation")
+	}
+}
+
+type testMaintenanceTask struct {
+	name string
+	err  error
+	runs atomic.Int32
+}
+
+func (t *testMaintenanceTask) Name() string {
+	return t.name
+}
+
+func (t *testMaintenanceTask) Run(ctx context.Context) error {
+	t.runs.Add(1)
+	return t.err
+}
+
+func TestMaintenanceTrigger(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	succeeding := &testMaintenanceTask{name: "succeeding"}
+	failing := &testMaintenanceTask{name: "failing", err: errors.New("task failed")}
+	config := DefaultMaintenanceConfig
+	runner, err := NewMaintenanceRunner(func() *MaintenanceConfig { return &config }, nil, []MaintenanceTask{succeeding, failing})
+	Require(t, err)
+	runner.Start(ctx)
+	defer runner.StopAndWait()
+
+	if err := runner.Trigger([]string{"unknown"}); err == nil {
+		Fail(t, "triggered unknown maintenance task")
+	}
+	waitForMaintenance := func() MaintenanceStatus {
+		t.Helper()
+		for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+			if status := runner.Status(); !status.Running && !status.Tasks[0].LastFinished.IsZero() {
+				return status
+			}
+		}
+		Fail(t, "maintenance didn't finish")
+		return MaintenanceStatus{}
+	}
+
+	// Only the named task runs
+	Require(t, runner.Trigger([]string{"succeeding"}))
+	status := waitForMaintenance()
+	if succeeding.runs.Load() != 1 || failing.runs.Load() != 0 {
+		Fail(t, "unexpected task runs", succeeding.runs.Load(), failing.runs.Load())
+	}
+	if status.Tasks[0].LastError != "" || !status.Tasks[1].LastStarted.IsZero() {
+		Fail(t, "unexpected status", status)
+	}
+
+	// All tasks run if none are named, and failures are reported
+	Require(t, runner.Trigger(nil))
+	for start := time.Now(); failing.runs.Load() == 0 || runner.Status().Running; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			Fail(t, "maintenance didn't finish")
+		}
+	}
+	status = runner.Status()
+	if succeeding.runs.Load() != 2 || failing.runs.Load() != 1 {
+		Fail(t, "unexpected task runs", succeeding.runs.Load(), failing.runs.Load())
+	}
+	if status.Tasks[1].LastError != "task failed" || status.Tasks[1].LastFinished.IsZero() {
+		Fail(t, "task failure wasn't reported", status)
+	}
+}
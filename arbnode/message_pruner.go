@@ -7,14 +7,22 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
 
 	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/arbmath"
 	"github.com/offchainlabs/nitro/util/stopwaiter"
 	"github.com/offchainlabs/nitro/validator"
 
@@ -25,11 +33,28 @@ type MessagePruner struct {
 	stopwaiter.StopWaiter
 	transactionStreamer         *TransactionStreamer
 	inboxTracker                *InboxTracker
+	parentChain                 ParentChainHeaderReader
 	config                      MessagePrunerConfigFetcher
 	pruningLock                 sync.Mutex
 	lastPruneDone               time.Time
 	cachedPrunedMessages        uint64
 	cachedPrunedDelayedMessages uint64
+
+	// Progress of the running prune, reported through Status
+	messagesPruned        atomic.Uint64
+	delayedMessagesPruned atomic.Uint64
+
+	statusMutex sync.Mutex
+	// The latest scheduled and on-demand prunes, one of which is running if running is set
+	scheduledStatus *MessagePrunerStatus
+	onDemandStatus  *MessagePrunerStatus
+	running         *MessagePrunerStatus
+	latestConfirmed *validator.GoGlobalState
+}
+
+// ParentChainHeaderReader reads the headers of the parent chain blocks batches were posted in
+type ParentChainHeaderReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
 }
 
 type MessagePrunerConfig struct {
@@ -37,6 +62,56 @@ type MessagePrunerConfig struct {
 	// Message pruning interval.
 	PruneInterval  time.Duration `koanf:"prune-interval" reload:"hot"`
 	MinBatchesLeft uint64        `koanf:"min-batches-left" reload:"hot"`
+	// Batches posted to the parent chain within KeepDuration aren't pruned.
+	KeepDuration time.Duration `koanf:"keep-duration" reload:"hot"`
+	// Batch ranges, e.g. "1000-1200" or "1500", whose messages aren't pruned while they're listed.
+	PinnedBatches []string `koanf:"pinned-batches" reload:"hot"`
+	DryRun        bool     `koanf:"dry-run" reload:"hot"`
+
+	pinnedBatches []batchRange
+}
+
+// batchRange is an inclusive range of batch sequence numbers
+type batchRange struct {
+	first uint64
+	last  uint64
+}
+
+func parseBatchRange(s string) (batchRange, error) {
+	firstStr, lastStr, isRange := strings.Cut(s, "-")
+	first, err := strconv.ParseUint(strings.TrimSpace(firstStr), 10, 64)
+	if err != nil {
+		return batchRange{}, fmt.Errorf("invalid pinned batch range %q: %w", s, err)
+	}
+	last := first
+	if isRange {
+		last, err = strconv.ParseUint(strings.TrimSpace(lastStr), 10, 64)
+		if err != nil {
+			return batchRange{}, fmt.Errorf("invalid pinned batch range %q: %w", s, err)
+		}
+		if last < first {
+			return batchRange{}, fmt.Errorf("invalid pinned batch range %q: last batch is before the first", s)
+		}
+	}
+	return batchRange{first: first, last: last}, nil
+}
+
+func (c *MessagePrunerConfig) Validate() error {
+	if c.KeepDuration < 0 {
+		return errors.New("message pruner keep-duration must not be negative")
+	}
+	c.pinnedBatches = nil
+	for _, s := range c.PinnedBatches {
+		batches, err := parseBatchRange(s)
+		if err != nil {
+			return err
+		}
+		c.pinnedBatches = append(c.pinnedBatches, batches)
+	}
+	sort.Slice(c.pinnedBatches, func(i, j int) bool {
+		return c.pinnedBatches[i].first < c.pinnedBatches[j].first
+	})
+	return nil
 }
 
 type MessagePrunerConfigFetcher func() *MessagePrunerConfig
@@ -45,18 +120,47 @@ var DefaultMessagePrunerConfig = MessagePrunerConfig{
 	Enable:         true,
 	PruneInterval:  time.Minute,
 	MinBatchesLeft: 2,
+	KeepDuration:   0,
+	PinnedBatches:  []string{},
+	DryRun:         false,
 }
 
 func MessagePrunerConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Bool(prefix+".enable", DefaultMessagePrunerConfig.Enable, "enable message pruning")
 	f.Duration(prefix+".prune-interval", DefaultMessagePrunerConfig.PruneInterval, "interval for running message pruner")
 	f.Uint64(prefix+".min-batches-left", DefaultMessagePrunerConfig.MinBatchesLeft, "min number of batches not pruned")
+	f.Duration(prefix+".keep-duration", DefaultMessagePrunerConfig.KeepDuration, "don't prune batches posted in a parent chain block whose timestamp is within this duration of now (0 to prune regardless of age)")
+	f.StringSlice(prefix+".pinned-batches", DefaultMessagePrunerConfig.PinnedBatches, "batch ranges to not prune the messages of, as first-last or a single batch number (messages pruned before a range is pinned can't be recovered, and a range that's no longer pinned is pruned by the next prune)")
+	f.Bool(prefix+".dry-run", DefaultMessagePrunerConfig.DryRun, "only log and report what would be pruned, without deleting anything")
 }
 
-func NewMessagePruner(transactionStreamer *TransactionStreamer, inboxTracker *InboxTracker, config MessagePrunerConfigFetcher) *MessagePruner {
+// MessagePrunerReport holds the latest scheduled prune and the latest prune requested through PruneNow,
+// each of which is nil until it first runs
+type MessagePrunerReport struct {
+	Scheduled *MessagePrunerStatus `json:"scheduled,omitempty"`
+	OnDemand  *MessagePrunerStatus `json:"onDemand,omitempty"`
+}
+
+// MessagePrunerStatus reports the progress of a prune
+type MessagePrunerStatus struct {
+	Running  bool      `json:"running"`
+	DryRun   bool      `json:"dryRun"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	// The message and delayed message counts being pruned up to, zero if there's nothing to prune
+	MessageCount        arbutil.MessageIndex `json:"messageCount"`
+	DelayedMessageCount uint64               `json:"delayedMessageCount"`
+	// The number of messages and delayed messages pruned so far, or that would be in dry-run mode
+	MessagesPruned        uint64 `json:"messagesPruned"`
+	DelayedMessagesPruned uint64 `json:"delayedMessagesPruned"`
+	Error                 string `json:"error,omitempty"`
+}
+
+func NewMessagePruner(transactionStreamer *TransactionStreamer, inboxTracker *InboxTracker, parentChain ParentChainHeaderReader, config MessagePrunerConfigFetcher) *MessagePruner {
 	return &MessagePruner{
 		transactionStreamer: transactionStreamer,
 		inboxTracker:        inboxTracker,
+		parentChain:         parentChain,
 		config:              config,
 	}
 }
@@ -66,21 +170,22 @@ func (m *MessagePruner) Start(ctxIn context.Context) {
 }
 
 func (m *MessagePruner) UpdateLatestConfirmed(count arbutil.MessageIndex, globalState validator.GoGlobalState) {
+	m.statusMutex.Lock()
+	m.latestConfirmed = &globalState
+	m.statusMutex.Unlock()
+
 	locked := m.pruningLock.TryLock()
 	if !locked {
 		return
 	}
 
-	if m.lastPruneDone.Add(m.config().PruneInterval).After(time.Now()) {
+	config := m.config()
+	if m.lastPruneDone.Add(config.PruneInterval).After(time.Now()) {
 		m.pruningLock.Unlock()
 		return
 	}
 	err := m.LaunchThreadSafe(func(ctx context.Context) {
-		defer m.pruningLock.Unlock()
-		err := m.prune(ctx, count, globalState)
-		if err != nil && ctx.Err() == nil {
-			log.Error("error while pruning", "err", err)
-		}
+		m.runPrune(ctx, globalState, config.DryRun, false)
 	})
 	if err != nil {
 		log.Info("failed launching prune thread", "err", err)
@@ -88,9 +193,88 @@ func (m *MessagePruner) UpdateLatestConfirmed(count arbutil.MessageIndex, global
 	}
 }
 
-func (m *MessagePruner) prune(ctx context.Context, count arbutil.MessageIndex, globalState validator.GoGlobalState) error {
+// PruneNow starts pruning up to the latest confirmed state in the background.
+// It fails if a prune is already running. Progress is reported by Status.
+func (m *MessagePruner) PruneNow(dryRun bool) error {
+	m.statusMutex.Lock()
+	latestConfirmed := m.latestConfirmed
+	m.statusMutex.Unlock()
+	if latestConfirmed == nil {
+		return errors.New("no confirmed state to prune up to yet")
+	}
+	if !m.pruningLock.TryLock() {
+		return errors.New("a prune is already running")
+	}
+	err := m.LaunchThreadSafe(func(ctx context.Context) {
+		m.runPrune(ctx, *latestConfirmed, dryRun, true)
+	})
+	if err != nil {
+		m.pruningLock.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (m *MessagePruner) Status() MessagePrunerReport {
+	m.statusMutex.Lock()
+	defer m.statusMutex.Unlock()
+	copyStatus := func(status *MessagePrunerStatus) *MessagePrunerStatus {
+		if status == nil {
+			return nil
+		}
+		statusCopy := *status
+		if status == m.running {
+			statusCopy.MessagesPruned = m.messagesPruned.Load()
+			statusCopy.DelayedMessagesPruned = m.delayedMessagesPruned.Load()
+		}
+		return &statusCopy
+	}
+	return MessagePrunerReport{
+		Scheduled: copyStatus(m.scheduledStatus),
+		OnDemand:  copyStatus(m.onDemandStatus),
+	}
+}
+
+// runPrune must be called with the pruningLock held, and releases it
+func (m *MessagePruner) runPrune(ctx context.Context, globalState validator.GoGlobalState, dryRun bool, onDemand bool) {
+	defer m.pruningLock.Unlock()
+	m.messagesPruned.Store(0)
+	m.delayedMessagesPruned.Store(0)
+	status := &MessagePrunerStatus{
+		Running: true,
+		DryRun:  dryRun,
+		Started: time.Now(),
+	}
+	m.statusMutex.Lock()
+	if onDemand {
+		m.onDemandStatus = status
+	} else {
+		m.scheduledStatus = status
+	}
+	m.running = status
+	m.statusMutex.Unlock()
+
+	err := m.prune(ctx, globalState, dryRun)
+
+	m.statusMutex.Lock()
+	status.Running = false
+	status.Finished = time.Now()
+	status.MessagesPruned = m.messagesPruned.Load()
+	status.DelayedMessagesPruned = m.delayedMessagesPruned.Load()
+	if err != nil {
+		status.Error = err.Error()
+	}
+	m.running = nil
+	m.statusMutex.Unlock()
+	if err != nil && ctx.Err() == nil {
+		log.Error("error while pruning", "err", err)
+	}
+}
+
+func (m *MessagePruner) prune(ctx context.Context, globalState validator.GoGlobalState, dryRun bool) error {
+	config := m.config()
 	trimBatchCount := globalState.Batch
-	minBatchesLeft := m.config().MinBatchesLeft
+	minBatchesLeft := config.MinBatchesLeft
 	batchCount, err := m.inboxTracker.GetBatchCount()
 	if err != nil {
 		return err
@@ -101,6 +285,12 @@ func (m *MessagePruner) prune(ctx context.Context, count arbutil.MessageIndex, g
 		}
 		trimBatchCount = batchCount - minBatchesLeft
 	}
+	if config.KeepDuration > 0 {
+		trimBatchCount, err = m.batchCountPostedBefore(ctx, time.Now().Add(-config.KeepDuration), trimBatchCount)
+		if err != nil {
+			return err
+		}
+	}
 	if trimBatchCount < 1 {
 		return nil
 	}
@@ -110,32 +300,127 @@ func (m *MessagePruner) prune(ctx context.Context, count arbutil.MessageIndex, g
 	}
 	msgCount := endBatchMetadata.MessageCount
 	delayedCount := endBatchMetadata.DelayedMessageCount
+	pinned, err := m.pinnedRanges(config.pinnedBatches, trimBatchCount)
+	if err != nil {
+		return err
+	}
 
-	return m.deleteOldMessagesFromDB(ctx, msgCount, delayedCount)
+	m.statusMutex.Lock()
+	m.running.MessageCount = msgCount
+	m.running.DelayedMessageCount = delayedCount
+	m.statusMutex.Unlock()
+	return m.deleteOldMessagesFromDB(ctx, msgCount, delayedCount, pinned, dryRun)
 }
 
-func (m *MessagePruner) deleteOldMessagesFromDB(ctx context.Context, messageCount arbutil.MessageIndex, delayedMessageCount uint64) error {
-	prunedKeysRange, err := deleteFromLastPrunedUptoEndKey(ctx, m.transactionStreamer.db, messagePrefix, &m.cachedPrunedMessages, uint64(messageCount))
+// batchCountPostedBefore returns the number of leading batches, up to batchCount,
+// posted in a parent chain block with a timestamp before cutoff.
+func (m *MessagePruner) batchCountPostedBefore(ctx context.Context, cutoff time.Time, batchCount uint64) (uint64, error) {
+	var searchErr error
+	count := sort.Search(int(batchCount), func(i int) bool {
+		if searchErr != nil {
+			return true
+		}
+		postedBefore, err := m.batchPostedBefore(ctx, uint64(i), cutoff)
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		return !postedBefore
+	})
+	if searchErr != nil {
+		return 0, searchErr
+	}
+	return uint64(count), nil
+}
+
+func (m *MessagePruner) batchPostedBefore(ctx context.Context, batch uint64, cutoff time.Time) (bool, error) {
+	metadata, err := m.inboxTracker.GetBatchMetadata(batch)
+	if err != nil {
+		return false, err
+	}
+	header, err := m.parentChain.HeaderByNumber(ctx, new(big.Int).SetUint64(metadata.ParentChainBlock))
+	if err != nil {
+		return false, fmt.Errorf("getting parent chain block %v of batch %v: %w", metadata.ParentChainBlock, batch, err)
+	}
+	return header.Time < uint64(cutoff.Unix()), nil
+}
+
+// keyRange is a range of message or delayed message sequence numbers, excluding end
+type keyRange struct {
+	start uint64
+	end   uint64
+}
+
+// pinnedRange holds the messages and delayed messages of a range of pinned batches
+type pinnedRange struct {
+	messages        keyRange
+	delayedMessages keyRange
+}
+
+// pinnedRanges returns the messages and delayed messages of the pinned batches out of the first batchCount batches
+func (m *MessagePruner) pinnedRanges(pinnedBatches []batchRange, batchCount uint64) ([]pinnedRange, error) {
+	var ranges []pinnedRange
+	for _, batches := range pinnedBatches {
+		if batches.first >= batchCount {
+			continue
+		}
+		var startMetadata BatchMetadata
+		if batches.first > 0 {
+			var err error
+			startMetadata, err = m.inboxTracker.GetBatchMetadata(batches.first - 1)
+			if err != nil {
+				return nil, err
+			}
+		}
+		endMetadata, err := m.inboxTracker.GetBatchMetadata(arbmath.MinInt(batches.last, batchCount-1))
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, pinnedRange{
+			messages:        keyRange{start: uint64(startMetadata.MessageCount), end: uint64(endMetadata.MessageCount)},
+			delayedMessages: keyRange{start: startMetadata.DelayedMessageCount, end: endMetadata.DelayedMessageCount},
+		})
+	}
+	return ranges, nil
+}
+
+func (m *MessagePruner) deleteOldMessagesFromDB(ctx context.Context, messageCount arbutil.MessageIndex, delayedMessageCount uint64, pinned []pinnedRange, dryRun bool) error {
+	var pinnedMessages, pinnedDelayedMessages []keyRange
+	for _, keep := range pinned {
+		pinnedMessages = append(pinnedMessages, keep.messages)
+		pinnedDelayedMessages = append(pinnedDelayedMessages, keep.delayedMessages)
+	}
+	prunedKeysRange, err := deleteFromLastPrunedUptoEndKey(ctx, m.transactionStreamer.db, messagePrefix, &m.cachedPrunedMessages, uint64(messageCount), pinnedMessages, dryRun, &m.messagesPruned)
 	if err != nil {
 		return fmt.Errorf("error deleting last batch messages: %w", err)
 	}
 	if len(prunedKeysRange) > 0 {
-		log.Info("Pruned last batch messages:", "first pruned key", prunedKeysRange[0], "last pruned key", prunedKeysRange[len(prunedKeysRange)-1])
+		if dryRun {
+			log.Info("Dry run, would prune last batch messages:", "first key", prunedKeysRange[0], "last key", prunedKeysRange[len(prunedKeysRange)-1], "count", m.messagesPruned.Load())
+		} else {
+			log.Info("Pruned last batch messages:", "first pruned key", prunedKeysRange[0], "last pruned key", prunedKeysRange[len(prunedKeysRange)-1])
+		}
 	}
 
-	prunedKeysRange, err = deleteFromLastPrunedUptoEndKey(ctx, m.inboxTracker.db, rlpDelayedMessagePrefix, &m.cachedPrunedDelayedMessages, delayedMessageCount)
+	prunedKeysRange, err = deleteFromLastPrunedUptoEndKey(ctx, m.inboxTracker.db, rlpDelayedMessagePrefix, &m.cachedPrunedDelayedMessages, delayedMessageCount, pinnedDelayedMessages, dryRun, &m.delayedMessagesPruned)
 	if err != nil {
 		return fmt.Errorf("error deleting last batch delayed messages: %w", err)
 	}
 	if len(prunedKeysRange) > 0 {
-		log.Info("Pruned last batch delayed messages:", "first pruned key", prunedKeysRange[0], "last pruned key", prunedKeysRange[len(prunedKeysRange)-1])
+		if dryRun {
+			log.Info("Dry run, would prune last batch delayed messages:", "first key", prunedKeysRange[0], "last key", prunedKeysRange[len(prunedKeysRange)-1], "count", m.delayedMessagesPruned.Load())
+		} else {
+			log.Info("Pruned last batch delayed messages:", "first pruned key", prunedKeysRange[0], "last pruned key", prunedKeysRange[len(prunedKeysRange)-1])
+		}
 	}
 	return nil
 }
 
-// deleteFromLastPrunedUptoEndKey is similar to deleteFromRange but automatically populates the start key
-// cachedStartMinKey must not be nil. It's set to the new start key at the end of this function if successful.
-func deleteFromLastPrunedUptoEndKey(ctx context.Context, db ethdb.Database, prefix []byte, cachedStartMinKey *uint64, endMinKey uint64) ([]uint64, error) {
+// deleteFromLastPrunedUptoEndKey is similar to deleteFromRange but automatically populates the start key,
+// and skips the keys in the pinned ranges, which must be sorted by their start.
+// cachedStartMinKey must not be nil. It's set to the new start key at the end of this function if successful and not a dry run.
+// The new start key is the first pinned key that was skipped if any, so that a range which is no longer pinned is pruned next time.
+func deleteFromLastPrunedUptoEndKey(ctx context.Context, db ethdb.Database, prefix []byte, cachedStartMinKey *uint64, endMinKey uint64, pinned []keyRange, dryRun bool, progress *atomic.Uint64) ([]uint64, error) {
 	startMinKey := *cachedStartMinKey
 	if startMinKey == 0 {
 		startIter := db.NewIterator(prefix, uint64ToKey(1))
@@ -149,9 +434,43 @@ func deleteFromLastPrunedUptoEndKey(ctx context.Context, db ethdb.Database, pref
 		*cachedStartMinKey = startMinKey
 		return nil, nil
 	}
-	keys, err := deleteFromRange(ctx, db, prefix, startMinKey, endMinKey-1)
-	if err == nil {
-		*cachedStartMinKey = endMinKey - 1
+	var prunedKeysRange []uint64
+	nextStartMinKey := endMinKey - 1
+	deleteUpTo := func(end uint64) error {
+		keys, err := deleteFromRange(ctx, db, prefix, startMinKey, end, dryRun, progress)
+		if err != nil {
+			return err
+		}
+		if len(prunedKeysRange) == 0 {
+			prunedKeysRange = keys
+		} else if len(keys) > 0 {
+			prunedKeysRange = []uint64{prunedKeysRange[0], keys[len(keys)-1]}
+		}
+		return nil
+	}
+	for _, keep := range pinned {
+		if keep.start >= endMinKey-1 {
+			break
+		}
+		if keep.end <= startMinKey {
+			continue
+		}
+		if keep.start > startMinKey {
+			if err := deleteUpTo(keep.start); err != nil {
+				return nil, err
+			}
+			startMinKey = keep.start
+		}
+		nextStartMinKey = arbmath.MinInt(nextStartMinKey, startMinKey)
+		startMinKey = keep.end
+	}
+	if startMinKey < endMinKey-1 {
+		if err := deleteUpTo(endMinKey - 1); err != nil {
+			return nil, err
+		}
+	}
+	if !dryRun {
+		*cachedStartMinKey = nextStartMinKey
 	}
-	return keys, err
+	return prunedKeysRange, nil
 }
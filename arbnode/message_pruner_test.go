@@ -5,11 +5,18 @@ package arbnode
 
 import (
 	"context"
+	"fmt"
+	"math/big"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
 	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/validator"
 )
 
 func TestMessagePrunerWithPruningEligibleMessagePresent(t *testing.T) {
@@ -18,7 +25,7 @@ func TestMessagePrunerWithPruningEligibleMessagePresent(t *testing.T) {
 
 	messagesCount := uint64(2 * 100 * 1024)
 	inboxTrackerDb, transactionStreamerDb, pruner := setupDatabase(t, 2*100*1024, 2*100*1024)
-	err := pruner.deleteOldMessagesFromDB(ctx, arbutil.MessageIndex(messagesCount), messagesCount)
+	err := pruner.deleteOldMessagesFromDB(ctx, arbutil.MessageIndex(messagesCount), messagesCount, nil, false)
 	Require(t, err)
 
 	checkDbKeys(t, messagesCount, transactionStreamerDb, messagePrefix)
@@ -33,12 +40,12 @@ func TestMessagePrunerTwoHalves(t *testing.T) {
 	messagesCount := uint64(10)
 	_, transactionStreamerDb, pruner := setupDatabase(t, messagesCount, messagesCount)
 	// In first iteration message till messagesCount/2 are tried to be deleted.
-	err := pruner.deleteOldMessagesFromDB(ctx, arbutil.MessageIndex(messagesCount/2), messagesCount/2)
+	err := pruner.deleteOldMessagesFromDB(ctx, arbutil.MessageIndex(messagesCount/2), messagesCount/2, nil, false)
 	Require(t, err)
 	// In first iteration all the message till messagesCount/2 are deleted.
 	checkDbKeys(t, messagesCount/2, transactionStreamerDb, messagePrefix)
 	// In second iteration message till messagesCount are tried to be deleted.
-	err = pruner.deleteOldMessagesFromDB(ctx, arbutil.MessageIndex(messagesCount), messagesCount)
+	err = pruner.deleteOldMessagesFromDB(ctx, arbutil.MessageIndex(messagesCount), messagesCount, nil, false)
 	Require(t, err)
 	// In second iteration all the message till messagesCount are deleted.
 	checkDbKeys(t, messagesCount, transactionStreamerDb, messagePrefix)
@@ -52,7 +59,7 @@ func TestMessagePrunerPruneTillLessThenEqualTo(t *testing.T) {
 	inboxTrackerDb, transactionStreamerDb, pruner := setupDatabase(t, 2*messagesCount, 20)
 	err := inboxTrackerDb.Delete(dbKey(messagePrefix, 9))
 	Require(t, err)
-	err = pruner.deleteOldMessagesFromDB(ctx, arbutil.MessageIndex(messagesCount), messagesCount)
+	err = pruner.deleteOldMessagesFromDB(ctx, arbutil.MessageIndex(messagesCount), messagesCount, nil, false)
 	Require(t, err)
 	hasKey, err := transactionStreamerDb.Has(dbKey(messagePrefix, messagesCount))
 	Require(t, err)
@@ -67,7 +74,7 @@ func TestMessagePrunerWithNoPruningEligibleMessagePresent(t *testing.T) {
 
 	messagesCount := uint64(10)
 	inboxTrackerDb, transactionStreamerDb, pruner := setupDatabase(t, messagesCount, messagesCount)
-	err := pruner.deleteOldMessagesFromDB(ctx, arbutil.MessageIndex(messagesCount), messagesCount)
+	err := pruner.deleteOldMessagesFromDB(ctx, arbutil.MessageIndex(messagesCount), messagesCount, nil, false)
 	Require(t, err)
 
 	checkDbKeys(t, uint64(messagesCount), transactionStreamerDb, messagePrefix)
@@ -75,6 +82,151 @@ func TestMessagePrunerWithNoPruningEligibleMessagePresent(t *testing.T) {
 
 }
 
+func TestMessagePrunerDryRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	messagesCount := uint64(10)
+	inboxTrackerDb, transactionStreamerDb, pruner := setupDatabase(t, messagesCount, messagesCount)
+	err := pruner.deleteOldMessagesFromDB(ctx, arbutil.MessageIndex(messagesCount), messagesCount, nil, true)
+	Require(t, err)
+	checkDbKeysPresent(t, transactionStreamerDb, messagePrefix, 0, messagesCount, true)
+	checkDbKeysPresent(t, inboxTrackerDb, rlpDelayedMessagePrefix, 0, messagesCount, true)
+	// Keys 1 through messagesCount-2 would be pruned
+	if pruned := pruner.messagesPruned.Load(); pruned != messagesCount-2 {
+		Fail(t, "dry run counted", pruned, "messages to prune")
+	}
+	if pruned := pruner.delayedMessagesPruned.Load(); pruned != messagesCount-2 {
+		Fail(t, "dry run counted", pruned, "delayed messages to prune")
+	}
+
+	// A dry run doesn't affect later prunes
+	err = pruner.deleteOldMessagesFromDB(ctx, arbutil.MessageIndex(messagesCount), messagesCount, nil, false)
+	Require(t, err)
+	checkDbKeys(t, messagesCount, transactionStreamerDb, messagePrefix)
+	checkDbKeys(t, messagesCount, inboxTrackerDb, rlpDelayedMessagePrefix)
+}
+
+func TestMessagePrunerKeepDurationAndPinnedBatches(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Ten batches of ten messages and one delayed message each, of which the last four were posted recently.
+	// All the messages were sequenced a while ago, so only the parent chain blocks tell the recent batches apart.
+	batchCount := uint64(10)
+	now := time.Now()
+	transactionStreamerDb := rawdb.NewMemoryDatabase()
+	inboxTrackerDb := rawdb.NewMemoryDatabase()
+	parentChain := stubParentChain{}
+	for batch := uint64(0); batch < batchCount; batch++ {
+		postedAt := now.Add(-2 * time.Hour)
+		if batch >= 6 {
+			postedAt = now
+		}
+		parentChain[100+batch] = uint64(postedAt.Unix())
+		for i := uint64(0); i < 10; i++ {
+			message := arbostypes.MessageWithMetadata{
+				Message: &arbostypes.L1IncomingMessage{
+					Header: &arbostypes.L1IncomingMessageHeader{Timestamp: uint64(now.Add(-2 * time.Hour).Unix())},
+				},
+				DelayedMessagesRead: batch + 1,
+			}
+			data, err := rlp.EncodeToBytes(&message)
+			Require(t, err)
+			Require(t, transactionStreamerDb.Put(dbKey(messagePrefix, batch*10+i), data))
+		}
+		Require(t, inboxTrackerDb.Put(dbKey(rlpDelayedMessagePrefix, batch), []byte{}))
+		metadata, err := rlp.EncodeToBytes(&BatchMetadata{
+			MessageCount:        arbutil.MessageIndex((batch + 1) * 10),
+			DelayedMessageCount: batch + 1,
+			ParentChainBlock:    100 + batch,
+		})
+		Require(t, err)
+		Require(t, inboxTrackerDb.Put(dbKey(sequencerBatchMetaPrefix, batch), metadata))
+	}
+	count, err := rlp.EncodeToBytes(batchCount)
+	Require(t, err)
+	Require(t, inboxTrackerDb.Put(sequencerBatchCountKey, count))
+
+	config := DefaultMessagePrunerConfig
+	config.MinBatchesLeft = 0
+	config.KeepDuration = time.Hour
+	config.PinnedBatches = []string{"2-3"}
+	Require(t, config.Validate())
+	inboxTracker, err := NewInboxTracker(inboxTrackerDb, nil, nil)
+	Require(t, err)
+	pruner := NewMessagePruner(&TransactionStreamer{db: transactionStreamerDb}, inboxTracker, parentChain, func() *MessagePrunerConfig { return &config })
+	globalState := validator.GoGlobalState{Batch: batchCount}
+
+	// The dry run reports what the prune deletes
+	pruner.pruningLock.Lock()
+	pruner.runPrune(ctx, globalState, true, true)
+	status := pruner.Status().OnDemand
+	if status == nil || status.Running || status.Error != "" || status.MessageCount != 60 || status.DelayedMessageCount != 6 {
+		Fail(t, "unexpected dry run status", status)
+	}
+	if status.MessagesPruned != 38 || status.DelayedMessagesPruned != 2 {
+		Fail(t, "unexpected dry run counts", status.MessagesPruned, status.DelayedMessagesPruned)
+	}
+	checkDbKeysPresent(t, transactionStreamerDb, messagePrefix, 0, 100, true)
+
+	// Only the old batches are pruned, except for the pinned ones, and the usual first and last keys
+	pruner.pruningLock.Lock()
+	pruner.runPrune(ctx, globalState, false, false)
+	checkDbKeysPresent(t, transactionStreamerDb, messagePrefix, 0, 1, true)
+	checkDbKeysPresent(t, transactionStreamerDb, messagePrefix, 1, 20, false)
+	checkDbKeysPresent(t, transactionStreamerDb, messagePrefix, 20, 40, true)
+	checkDbKeysPresent(t, transactionStreamerDb, messagePrefix, 40, 59, false)
+	checkDbKeysPresent(t, transactionStreamerDb, messagePrefix, 59, 100, true)
+	checkDbKeysPresent(t, inboxTrackerDb, rlpDelayedMessagePrefix, 0, 1, true)
+	checkDbKeysPresent(t, inboxTrackerDb, rlpDelayedMessagePrefix, 1, 2, false)
+	checkDbKeysPresent(t, inboxTrackerDb, rlpDelayedMessagePrefix, 2, 4, true)
+	checkDbKeysPresent(t, inboxTrackerDb, rlpDelayedMessagePrefix, 4, 5, false)
+	checkDbKeysPresent(t, inboxTrackerDb, rlpDelayedMessagePrefix, 5, 10, true)
+	if status := pruner.Status().Scheduled; status == nil || status.MessagesPruned != 38 || status.DelayedMessagesPruned != 2 {
+		Fail(t, "unexpected prune status", status)
+	}
+
+	// Batches which are no longer pinned are pruned by the next prune
+	config.PinnedBatches = nil
+	Require(t, config.Validate())
+	pruner.pruningLock.Lock()
+	pruner.runPrune(ctx, globalState, false, false)
+	checkDbKeysPresent(t, transactionStreamerDb, messagePrefix, 0, 1, true)
+	checkDbKeysPresent(t, transactionStreamerDb, messagePrefix, 1, 59, false)
+	checkDbKeysPresent(t, inboxTrackerDb, rlpDelayedMessagePrefix, 1, 5, false)
+	report := pruner.Status()
+	if report.Scheduled == nil || report.Scheduled.MessagesPruned != 20 || report.Scheduled.DelayedMessagesPruned != 2 {
+		Fail(t, "unexpected prune status", report.Scheduled)
+	}
+
+	// The on-demand dry run is still reported after the scheduled prunes
+	if report.OnDemand == nil || !report.OnDemand.DryRun || report.OnDemand.MessagesPruned != 38 {
+		Fail(t, "on-demand prune status was lost", report.OnDemand)
+	}
+}
+
+func TestMessagePrunerInvalidPinnedBatches(t *testing.T) {
+	for _, pinned := range []string{"", "a", "5-", "-5", "5-4"} {
+		config := DefaultMessagePrunerConfig
+		config.PinnedBatches = []string{pinned}
+		if err := config.Validate(); err == nil {
+			Fail(t, "invalid pinned batches", pinned, "passed validation")
+		}
+	}
+}
+
+// stubParentChain maps parent chain block numbers to their timestamps
+type stubParentChain map[uint64]uint64
+
+func (c stubParentChain) HeaderByNumber(_ context.Context, number *big.Int) (*types.Header, error) {
+	timestamp, ok := c[number.Uint64()]
+	if !ok {
+		return nil, fmt.Errorf("block %v not found", number)
+	}
+	return &types.Header{Number: number, Time: timestamp}, nil
+}
+
 func setupDatabase(t *testing.T, messageCount, delayedMessageCount uint64) (ethdb.Database, ethdb.Database, *MessagePruner) {
 
 	transactionStreamerDb := rawdb.NewMemoryDatabase()
@@ -111,3 +263,14 @@ func checkDbKeys(t *testing.T, endCount uint64, db ethdb.Database, prefix []byte
 		}
 	}
 }
+
+func checkDbKeysPresent(t *testing.T, db ethdb.Database, prefix []byte, start, end uint64, present bool) {
+	t.Helper()
+	for i := start; i < end; i++ {
+		hasKey, err := db.Has(dbKey(prefix, i))
+		Require(t, err)
+		if hasKey != present {
+			Fail(t, "Key", i, "with prefix", string(prefix), "presence is", hasKey, "expected", present)
+		}
+	}
+}
@@ -336,6 +336,17 @@ func (c *Config) Validate() error {
 	if err := c.Staker.Validate(); err != nil {
 		return err
 	}
+	if err := c.MessagePruner.Validate(); err != nil {
+		return err
+	}
+	if err := c.ResourceMgmt.Validate(); err != nil {
+		return err
+	}
+	if c.SeqCoordinator.Enable {
+		if err := c.SeqCoordinator.Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -483,6 +494,7 @@ type Node struct {
 	BlockValidator          *staker.BlockValidator
 	StatelessBlockValidator *staker.StatelessBlockValidator
 	Staker                  *staker.Staker
+	StakerDataPoster        *dataposter.DataPoster
 	BroadcastServer         *broadcaster.Broadcaster
 	BroadcastClients        *broadcastclients.BroadcastClients
 	SeqCoordinator          *SeqCoordinator
@@ -664,7 +676,7 @@ func createNodeImpl(
 	}
 
 	if config.SeqCoordinator.Enable {
-		coordinator, err = NewSeqCoordinator(dataSigner, bpVerifier, txStreamer, exec.Sequencer, syncMonitor, config.SeqCoordinator)
+		coordinator, err = NewSeqCoordinator(dataSigner, bpVerifier, txStreamer, exec.Sequencer, syncMonitor, arbDb, config.SeqCoordinator)
 		if err != nil {
 			return nil, err
 		}
@@ -672,7 +684,11 @@ func createNodeImpl(
 		return nil, errors.New("sequencer must be enabled with coordinator, unless dangerous.no-coordinator set")
 	}
 	dbs := []ethdb.Database{chainDb, arbDb}
-	maintenanceRunner, err := NewMaintenanceRunner(func() *MaintenanceConfig { return &configFetcher.Get().Maintenance }, coordinator, dbs)
+	maintenanceTasks := []MaintenanceTask{
+		newDbCompactionTask(dbs),
+		newTxIndexTask(chainDb),
+	}
+	maintenanceRunner, err := NewMaintenanceRunner(func() *MaintenanceConfig { return &configFetcher.Get().Maintenance }, coordinator, maintenanceTasks)
 	if err != nil {
 		return nil, err
 	}
@@ -714,6 +730,7 @@ func createNodeImpl(
 			BlockValidator:          nil,
 			StatelessBlockValidator: nil,
 			Staker:                  nil,
+			StakerDataPoster:        nil,
 			BroadcastServer:         broadcastServer,
 			BroadcastClients:        broadcastClients,
 			SeqCoordinator:          coordinator,
@@ -814,6 +831,7 @@ func createNodeImpl(
 	}
 
 	var stakerObj *staker.Staker
+	var stakerDataPoster *dataposter.DataPoster
 	var messagePruner *MessagePruner
 
 	if config.Staker.Enable {
@@ -828,6 +846,7 @@ func createNodeImpl(
 		if err != nil {
 			return nil, err
 		}
+		stakerDataPoster = dp
 		getExtraGas := func() uint64 { return configFetcher.Get().Staker.ExtraGas }
 		// TODO: factor this out into separate helper, and split rest of node
 		// creation into multiple helpers.
@@ -860,7 +879,7 @@ func createNodeImpl(
 
 		var confirmedNotifiers []staker.LatestConfirmedNotifier
 		if config.MessagePruner.Enable && !config.Caching.Archive {
-			messagePruner = NewMessagePruner(txStreamer, inboxTracker, func() *MessagePrunerConfig { return &configFetcher.Get().MessagePruner })
+			messagePruner = NewMessagePruner(txStreamer, inboxTracker, l1client, func() *MessagePrunerConfig { return &configFetcher.Get().MessagePruner })
 			confirmedNotifiers = append(confirmedNotifiers, messagePruner)
 		}
 
@@ -916,6 +935,7 @@ func createNodeImpl(
 		BlockValidator:          blockValidator,
 		StatelessBlockValidator: statelessBlockValidator,
 		Staker:                  stakerObj,
+		StakerDataPoster:        stakerDataPoster,
 		BroadcastServer:         broadcastServer,
 		BroadcastClients:        broadcastClients,
 		SeqCoordinator:          coordinator,
@@ -971,6 +991,46 @@ func CreateNode(
 			Public: false,
 		})
 	}
+	if currentNode.BatchPoster != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "arbbatchposter",
+			Version:   "1.0",
+			Service:   dataposter.NewDataPosterAPI(currentNode.BatchPoster.dataPoster, decodeBatchPosterPosition),
+			Public:    false,
+		})
+	}
+	if currentNode.StakerDataPoster != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "arbstakerdataposter",
+			Version:   "1.0",
+			Service:   dataposter.NewDataPosterAPI(currentNode.StakerDataPoster, nil),
+			Public:    false,
+		})
+	}
+	if currentNode.MaintenanceRunner != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "arbmaintenance",
+			Version:   "1.0",
+			Service:   &MaintenanceAPI{runner: currentNode.MaintenanceRunner},
+			Public:    false,
+		})
+	}
+	if currentNode.MessagePruner != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "arbmessagepruner",
+			Version:   "1.0",
+			Service:   &MessagePrunerAPI{pruner: currentNode.MessagePruner},
+			Public:    false,
+		})
+	}
+	if currentNode.SeqCoordinator != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "arbseqcoordinator",
+			Version:   "1.0",
+			Service:   &SeqCoordinatorAPI{coordinator: currentNode.SeqCoordinator},
+			Public:    false,
+		})
+	}
 
 	apis = append(apis, rpc.API{
 		Namespace: "arb",
@@ -989,6 +1049,15 @@ func CreateNode(
 		),
 		Public: false,
 	})
+	if currentNode.Execution.Sequencer != nil && config.Sequencer.Bundles.Enable {
+		apis = append(apis, rpc.API{
+			Namespace:     execution.BundleAPINamespace,
+			Version:       "1.0",
+			Service:       execution.NewBundleAPI(currentNode.Execution.Sequencer),
+			Public:        false,
+			Authenticated: true,
+		})
+	}
 	apis = append(apis, rpc.API{
 		Namespace: "arbtrace",
 		Version:   "1.0",
@@ -1074,7 +1143,10 @@ func (n *Node) Start(ctx context.Context) error {
 		return fmt.Errorf("error starting transaction puiblisher: %w", err)
 	}
 	if n.SeqCoordinator != nil {
-		n.SeqCoordinator.Start(ctx)
+		err = n.SeqCoordinator.Start(ctx)
+		if err != nil {
+			return fmt.Errorf("error starting seq coordinator: %w", err)
+		}
 	}
 	if n.MaintenanceRunner != nil {
 		n.MaintenanceRunner.Start(ctx)
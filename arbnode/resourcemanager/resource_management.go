@@ -5,13 +5,21 @@ package resourcemanager
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
 	"os"
 	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/ethereum/go-ethereum/log"
@@ -26,35 +34,78 @@ var (
 	limitCheckFailureCounter    = metrics.NewRegisteredCounter("arb/rpc/limitcheck/failure", nil)
 	nitroMemLimit               = metrics.GetOrRegisterGauge("arb/memory/limit", nil)
 	nitroMemUsage               = metrics.GetOrRegisterGauge("arb/memory/usage", nil)
+	nitroCPULoad                = metrics.GetOrRegisterGaugeFloat64("arb/cpu/load", nil)
+	nitroGoroutines             = metrics.GetOrRegisterGauge("arb/goroutines", nil)
+	nitroOpenFiles              = metrics.GetOrRegisterGauge("arb/openfiles", nil)
+	limitCheckUtilizationGauge  = metrics.GetOrRegisterGaugeFloat64("arb/rpc/limitcheck/utilization", nil)
 	errNotSupported             = errors.New("not supported")
 )
 
+// The JSON-RPC method of a request is only looked up if its body is at most
+// this large, which is geth's default limit on the size of a request.
+const maxMethodLookupBodySize = 5 * 1024 * 1024
+
 // Init adds the resource manager's httpServer to a custom hook in geth.
 // Geth will add it to the stack of http.Handlers so that it is run
-// prior to RPC request handling.
+// prior to RPC request handling. The limits are read from config on each
+// request, so that they can be hot reloaded.
 //
 // Must be run before the go-ethereum stack is set up (ethereum/go-ethereum/node.New).
-func Init(conf *Config) error {
-	if conf.MemFreeLimit == "" {
-		return nil
-	}
-
-	limit, err := parseMemLimit(conf.MemFreeLimit)
-	if err != nil {
+func Init(config ConfigFetcher) error {
+	if err := config().Validate(); err != nil {
 		return err
 	}
-
 	node.WrapHTTPHandler = func(srv http.Handler) (http.Handler, error) {
-		var c limitChecker
-		c, err := newCgroupsMemoryLimitCheckerIfSupported(limit)
+		return newHttpServer(srv, config), nil
+	}
+	return nil
+}
+
+// limits are the checker and method weights for a config.
+type limits struct {
+	config  *Config
+	c       limitChecker
+	weights map[string]float64
+	// The smallest and largest weight of any request
+	minWeight, maxWeight float64
+}
+
+func newLimits(config *Config) *limits {
+	var checkers multiLimitChecker
+	if config.MemFreeLimit != "" {
+		c, err := newCgroupsMemoryLimitCheckerIfSupported(config.memFreeLimit)
 		if errors.Is(err, errNotSupported) {
 			log.Error("No method for determining memory usage and limits was discovered, disabled memory limit RPC throttling")
-			c = &trivialLimitChecker{}
+		} else {
+			checkers = append(checkers, c)
+		}
+	}
+	if config.CPULoadLimit > 0 {
+		checkers = append(checkers, newCPULoadLimitChecker(config.CPULoadLimit))
+	}
+	if config.GoroutineLimit > 0 {
+		checkers = append(checkers, newGoroutineLimitChecker(config.GoroutineLimit))
+	}
+	if config.OpenFilesLimit > 0 {
+		c := newOpenFilesLimitChecker(defaultFdDirectory, config.OpenFilesLimit)
+		if isSupported(c) {
+			checkers = append(checkers, c)
+		} else {
+			log.Error("No method for counting open files was discovered, disabled open files limit RPC throttling")
 		}
+	}
 
-		return newHttpServer(srv, c), nil
+	l := &limits{config: config, c: &trivialLimitChecker{}, weights: config.methodWeights, minWeight: 1, maxWeight: 1}
+	if len(checkers) == 1 {
+		l.c = checkers[0]
+	} else if len(checkers) > 1 {
+		l.c = checkers
 	}
-	return nil
+	for _, weight := range l.weights {
+		l.minWeight = math.Min(l.minWeight, weight)
+		l.maxWeight = math.Max(l.maxWeight, weight)
+	}
+	return l
 }
 
 func parseMemLimit(limitStr string) (int, error) {
@@ -83,44 +134,135 @@ func parseMemLimit(limitStr string) (int, error) {
 	return limit, nil
 }
 
+// parseMethodWeights parses weights given as method=weight.
+func parseMethodWeights(weights []string) (map[string]float64, error) {
+	parsed := make(map[string]float64, len(weights))
+	for _, entry := range weights {
+		method, weightStr, found := strings.Cut(entry, "=")
+		method = strings.TrimSpace(method)
+		if !found || method == "" {
+			return nil, fmt.Errorf("invalid method weight %q, expected method=weight", entry)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(weightStr), 64)
+		if err != nil || weight <= 0 || math.IsInf(weight, 0) {
+			return nil, fmt.Errorf("invalid weight in method weight %q, expected a positive number", entry)
+		}
+		if _, exists := parsed[method]; exists {
+			return nil, fmt.Errorf("duplicate method weight for %s", method)
+		}
+		parsed[method] = weight
+	}
+	return parsed, nil
+}
+
 // Config contains the configuration for resourcemanager functionality.
-// Currently only a memory limit is supported, other limits may be added
-// in the future.
+// Requests are throttled once any of the enabled limits is reached, and
+// method weights make some requests be throttled before others.
 type Config struct {
-	MemFreeLimit string `koanf:"mem-free-limit" reload:"hot"`
+	MemFreeLimit   string   `koanf:"mem-free-limit" reload:"hot"`
+	CPULoadLimit   float64  `koanf:"cpu-load-limit" reload:"hot"`
+	GoroutineLimit int      `koanf:"goroutine-limit" reload:"hot"`
+	OpenFilesLimit int      `koanf:"open-files-limit" reload:"hot"`
+	MethodWeights  []string `koanf:"method-weights" reload:"hot"`
+
+	memFreeLimit  int
+	methodWeights map[string]float64
+}
+
+type ConfigFetcher func() *Config
+
+func (c *Config) Validate() error {
+	c.memFreeLimit = 0
+	if c.MemFreeLimit != "" {
+		limit, err := parseMemLimit(c.MemFreeLimit)
+		if err != nil {
+			return err
+		}
+		c.memFreeLimit = limit
+	}
+	if c.CPULoadLimit < 0 {
+		return fmt.Errorf("invalid cpu load limit %v", c.CPULoadLimit)
+	}
+	if c.GoroutineLimit < 0 {
+		return fmt.Errorf("invalid goroutine limit %d", c.GoroutineLimit)
+	}
+	if c.OpenFilesLimit < 0 {
+		return fmt.Errorf("invalid open files limit %d", c.OpenFilesLimit)
+	}
+	weights, err := parseMethodWeights(c.MethodWeights)
+	if err != nil {
+		return err
+	}
+	c.methodWeights = weights
+	return nil
 }
 
 // DefaultConfig has the defaul resourcemanager configuration,
 // all limits are disabled.
 var DefaultConfig = Config{
-	MemFreeLimit: "",
+	MemFreeLimit:   "",
+	CPULoadLimit:   0,
+	GoroutineLimit: 0,
+	OpenFilesLimit: 0,
+	MethodWeights:  nil,
 }
 
 // ConfigAddOptions adds the configuration options for resourcemanager.
 func ConfigAddOptions(prefix string, f *pflag.FlagSet) {
 	f.String(prefix+".mem-free-limit", DefaultConfig.MemFreeLimit, "RPC calls are throttled if free system memory excluding the page cache is below this amount, expressed in bytes or multiples of bytes with suffix B, K, M, G. The limit should be set such that sufficient free memory is left for the page cache in order for the system to be performant")
+	f.Float64(prefix+".cpu-load-limit", DefaultConfig.CPULoadLimit, "RPC calls are throttled if the CPU time used by the node, as a fraction of the time available on all CPUs, is above this amount (e.g. 0.9), 0 to disable")
+	f.Int(prefix+".goroutine-limit", DefaultConfig.GoroutineLimit, "RPC calls are throttled if the node is running more than this many goroutines, 0 to disable")
+	f.Int(prefix+".open-files-limit", DefaultConfig.OpenFilesLimit, "RPC calls are throttled if the node has more than this many open file descriptors, 0 to disable")
+	f.StringSlice(prefix+".method-weights", DefaultConfig.MethodWeights, "weights of JSON-RPC methods as method=weight (e.g. debug_traceTransaction=4), a method is throttled once resource usage reaches the limits divided by its weight, methods not listed have a weight of 1 and a batch has the largest weight of its methods")
 }
 
 // httpServer implements http.Handler and wraps calls to inner with a resource
 // limit check.
 type httpServer struct {
-	inner http.Handler
-	c     limitChecker
+	inner  http.Handler
+	config ConfigFetcher
+	// The limits of the latest config, rebuilt when it's reloaded
+	limits      atomic.Pointer[limits]
+	limitsMutex sync.Mutex
 }
 
-func newHttpServer(inner http.Handler, c limitChecker) *httpServer {
-	return &httpServer{inner: inner, c: c}
+func newHttpServer(inner http.Handler, config ConfigFetcher) *httpServer {
+	return &httpServer{inner: inner, config: config}
+}
+
+func (s *httpServer) currentLimits() *limits {
+	config := s.config()
+	if l := s.limits.Load(); l != nil && l.config == config {
+		return l
+	}
+	s.limitsMutex.Lock()
+	defer s.limitsMutex.Unlock()
+	if l := s.limits.Load(); l != nil && l.config == config {
+		return l
+	}
+	l := newLimits(config)
+	s.limits.Store(l)
+	return l
 }
 
 // ServeHTTP passes req to inner unless any configured system resource
-// limit is exceeded, in which case it returns a HTTP 429 error.
+// limit, scaled by the weight of the request's JSON-RPC method, is
+// exceeded, in which case it returns a HTTP 429 error.
 func (s *httpServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	start := time.Now()
-	exceeded, err := s.c.isLimitExceeded()
-	limitCheckDurationHistogram.Update(time.Since(start).Nanoseconds())
+	l := s.currentLimits()
+	utilization, err := l.c.utilization()
 	if err != nil {
-		log.Error("Error checking memory limit", "err", err, "checker", s.c.String())
-	} else if exceeded {
+		log.Error("Error checking resource limits", "err", err, "checker", l.c.String())
+	}
+	limitCheckUtilizationGauge.Update(utilization)
+	// The request body is only read if its weight decides whether it's throttled
+	exceeded := utilization*l.minWeight >= 1
+	if !exceeded && utilization*l.maxWeight >= 1 {
+		exceeded = utilization*l.requestWeight(req) >= 1
+	}
+	limitCheckDurationHistogram.Update(time.Since(start).Nanoseconds())
+	if exceeded {
 		http.Error(w, "Too many requests", http.StatusTooManyRequests)
 		limitCheckFailureCounter.Inc(1)
 		return
@@ -130,16 +272,101 @@ func (s *httpServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	s.inner.ServeHTTP(w, req)
 }
 
+type replayedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// requestWeight returns the largest weight of the JSON-RPC methods called by
+// req, or 1 if they can't be determined. The body of req is replaced so that
+// it can still be read in full.
+func (l *limits) requestWeight(req *http.Request) float64 {
+	if req.Body == nil || req.Body == http.NoBody {
+		return 1
+	}
+	var read bytes.Buffer
+	methods, err := jsonRpcMethods(io.TeeReader(io.LimitReader(req.Body, maxMethodLookupBodySize), &read))
+	req.Body = replayedBody{io.MultiReader(&read, req.Body), req.Body}
+	if err != nil || len(methods) == 0 {
+		return 1
+	}
+	weight := 0.0
+	for _, method := range methods {
+		methodWeight, ok := l.weights[method]
+		if !ok {
+			methodWeight = 1
+		}
+		weight = math.Max(weight, methodWeight)
+	}
+	return weight
+}
+
+// jsonRpcMethods returns the methods of the JSON-RPC call or batch of calls read from r.
+func jsonRpcMethods(r io.Reader) ([]string, error) {
+	type call struct {
+		Method string `json:"method"`
+	}
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+	if raw[0] != '[' {
+		var single call
+		if err := json.Unmarshal(raw, &single); err != nil {
+			return nil, err
+		}
+		return []string{single.Method}, nil
+	}
+	var batch []call
+	if err := json.Unmarshal(raw, &batch); err != nil {
+		return nil, err
+	}
+	methods := make([]string, 0, len(batch))
+	for _, c := range batch {
+		methods = append(methods, c.Method)
+	}
+	return methods, nil
+}
+
 type limitChecker interface {
-	isLimitExceeded() (bool, error)
+	// utilization returns how much of a resource is used relative to its
+	// limit, which is reached at 1.
+	utilization() (float64, error)
 	String() string
 }
 
 func isSupported(c limitChecker) bool {
-	_, err := c.isLimitExceeded()
+	_, err := c.utilization()
 	return err == nil
 }
 
+// multiLimitChecker checks several limits, its utilization is the highest
+// utilization of any of them. A checker failing doesn't stop the others from
+// being checked.
+type multiLimitChecker []limitChecker
+
+func (m multiLimitChecker) utilization() (float64, error) {
+	var highest float64
+	var errs []error
+	for _, c := range m {
+		utilization, err := c.utilization()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.String(), err))
+			continue
+		}
+		highest = math.Max(highest, utilization)
+	}
+	return highest, errors.Join(errs...)
+}
+
+func (m multiLimitChecker) String() string {
+	names := make([]string, 0, len(m))
+	for _, c := range m {
+		names = append(names, c.String())
+	}
+	return strings.Join(names, ",")
+}
+
 // newCgroupsMemoryLimitCheckerIfSupported attempts to auto-discover whether
 // Cgroups V1 or V2 is supported for checking system memory limits.
 func newCgroupsMemoryLimitCheckerIfSupported(memLimitBytes int) (*cgroupsMemoryLimitChecker, error) {
@@ -161,8 +388,8 @@ func newCgroupsMemoryLimitCheckerIfSupported(memLimitBytes int) (*cgroupsMemoryL
 // trivialLimitChecker checks no limits, so its limits are never exceeded.
 type trivialLimitChecker struct{}
 
-func (_ trivialLimitChecker) isLimitExceeded() (bool, error) {
-	return false, nil
+func (_ trivialLimitChecker) utilization() (float64, error) {
+	return 0, nil
 }
 
 func (_ trivialLimitChecker) String() string { return "trivial" }
@@ -202,8 +429,8 @@ func newCgroupsMemoryLimitChecker(files cgroupsMemoryFiles, memLimitBytes int) *
 	}
 }
 
-// isLimitExceeded checks if the system memory free is less than the limit.
-// It returns true if the limit is exceeded.
+// utilization checks how much of the system memory, less the free memory
+// limit, is used. The limit is exceeded once the free memory is below it.
 //
 // container_memory_working_set_bytes in prometheus is calculated as
 // memory.usage_in_bytes - inactive page cache bytes, see
@@ -223,20 +450,20 @@ func newCgroupsMemoryLimitChecker(files cgroupsMemoryFiles, memLimitBytes int) *
 // free memory for the page cache, to avoid cache thrashing on chain state
 // access. How much "reasonable" is will depend on access patterns, state
 // size, and your application's tolerance for latency.
-func (c *cgroupsMemoryLimitChecker) isLimitExceeded() (bool, error) {
+func (c *cgroupsMemoryLimitChecker) utilization() (float64, error) {
 	var limit, usage, active, inactive int
 	var err error
 	if limit, err = readIntFromFile(c.files.limitFile); err != nil {
-		return false, err
+		return 0, err
 	}
 	if usage, err = readIntFromFile(c.files.usageFile); err != nil {
-		return false, err
+		return 0, err
 	}
 	if active, err = readFromMemStats(c.files.statsFile, c.files.activeRe); err != nil {
-		return false, err
+		return 0, err
 	}
 	if inactive, err = readFromMemStats(c.files.statsFile, c.files.inactiveRe); err != nil {
-		return false, err
+		return 0, err
 	}
 
 	memLimit := limit - c.memLimitBytes
@@ -244,7 +471,10 @@ func (c *cgroupsMemoryLimitChecker) isLimitExceeded() (bool, error) {
 	nitroMemLimit.Update(int64(memLimit))
 	nitroMemUsage.Update(int64(memUsage))
 
-	return memUsage >= memLimit, nil
+	if memLimit <= 0 {
+		return math.Inf(1), nil
+	}
+	return float64(memUsage) / float64(memLimit), nil
 }
 
 func (c cgroupsMemoryLimitChecker) String() string {
@@ -256,6 +486,7 @@ func readIntFromFile(fileName string) (int, error) {
 	if err != nil {
 		return 0, err
 	}
+	defer file.Close()
 
 	var limit int
 	if _, err = fmt.Fscanf(file, "%d", &limit); err != nil {
@@ -269,6 +500,7 @@ func readFromMemStats(fileName string, re *regexp.Regexp) (int, error) {
 	if err != nil {
 		return 0, err
 	}
+	defer file.Close()
 
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
@@ -287,3 +519,132 @@ func readFromMemStats(fileName string, re *regexp.Regexp) (int, error) {
 
 	return 0, errors.New("total_inactive_file not found in " + fileName)
 }
+
+// The CPU load is averaged over at least this long, so that it isn't sampled
+// on every request.
+const cpuLoadSampleInterval = time.Second
+
+// cpuLoadLimitChecker checks the CPU time used by this process, as a fraction
+// of the time available on all CPUs. The load is averaged from the previous
+// sample, which is taken at most once per cpuLoadSampleInterval while requests
+// are being checked.
+type cpuLoadLimitChecker struct {
+	limit   float64
+	numCPU  int
+	cpuTime func() (time.Duration, error)
+	now     func() time.Time
+
+	mutex          sync.Mutex
+	sampledAt      time.Time
+	sampledCPUTime time.Duration
+	load           float64
+}
+
+func newCPULoadLimitChecker(limit float64) *cpuLoadLimitChecker {
+	return &cpuLoadLimitChecker{
+		limit:   limit,
+		numCPU:  runtime.NumCPU(),
+		cpuTime: processCPUTime,
+		now:     time.Now,
+	}
+}
+
+func (c *cpuLoadLimitChecker) utilization() (float64, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := c.now()
+	if !c.sampledAt.IsZero() && now.Sub(c.sampledAt) < cpuLoadSampleInterval {
+		return c.load / c.limit, nil
+	}
+	cpuTime, err := c.cpuTime()
+	if err != nil {
+		return 0, err
+	}
+	if !c.sampledAt.IsZero() {
+		c.load = float64(cpuTime-c.sampledCPUTime) / float64(now.Sub(c.sampledAt)) / float64(c.numCPU)
+		nitroCPULoad.Update(c.load)
+	}
+	c.sampledAt = now
+	c.sampledCPUTime = cpuTime
+	return c.load / c.limit, nil
+}
+
+func (c *cpuLoadLimitChecker) String() string {
+	return "CPULoadLimitChecker"
+}
+
+// processCPUTime returns the user and system CPU time used by this process.
+func processCPUTime() (time.Duration, error) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, err
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano()), nil
+}
+
+// goroutineLimitChecker checks the number of goroutines running in this process.
+type goroutineLimitChecker struct {
+	limit int
+}
+
+func newGoroutineLimitChecker(limit int) *goroutineLimitChecker {
+	return &goroutineLimitChecker{limit: limit}
+}
+
+func (c *goroutineLimitChecker) utilization() (float64, error) {
+	goroutines := runtime.NumGoroutine()
+	nitroGoroutines.Update(int64(goroutines))
+	return float64(goroutines) / float64(c.limit), nil
+}
+
+func (c *goroutineLimitChecker) String() string {
+	return "GoroutineLimitChecker"
+}
+
+const defaultFdDirectory = "/proc/self/fd"
+
+// The open files are counted at most this often, as listing them is costly
+// when there are many.
+const openFilesSampleInterval = time.Second
+
+// openFilesLimitChecker checks the number of file descriptors this process
+// has open, by counting the entries of its fd directory at most once per
+// openFilesSampleInterval.
+type openFilesLimitChecker struct {
+	fdDirectory string
+	limit       int
+	now         func() time.Time
+
+	mutex     sync.Mutex
+	sampledAt time.Time
+	openFiles int
+}
+
+func newOpenFilesLimitChecker(fdDirectory string, limit int) *openFilesLimitChecker {
+	return &openFilesLimitChecker{fdDirectory: fdDirectory, limit: limit, now: time.Now}
+}
+
+func (c *openFilesLimitChecker) utilization() (float64, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := c.now()
+	if c.sampledAt.IsZero() || now.Sub(c.sampledAt) >= openFilesSampleInterval {
+		dir, err := os.Open(c.fdDirectory)
+		if err != nil {
+			return 0, err
+		}
+		defer dir.Close()
+		fds, err := dir.Readdirnames(-1)
+		if err != nil {
+			return 0, err
+		}
+		c.openFiles = len(fds)
+		c.sampledAt = now
+		nitroOpenFiles.Update(int64(c.openFiles))
+	}
+	return float64(c.openFiles) / float64(c.limit), nil
+}
+
+func (c *openFilesLimitChecker) String() string {
+	return "OpenFilesLimitChecker"
+}
@@ -4,10 +4,18 @@
 package resourcemanager
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func updateFakeCgroupFiles(c *cgroupsMemoryLimitChecker, limit, usage, inactive, active int) error {
@@ -52,7 +60,7 @@ func makeCgroupsTestDir(cgroupDir string) cgroupsMemoryFiles {
 func TestCgroupsFailIfCantOpen(t *testing.T) {
 	testFiles := makeCgroupsTestDir(t.TempDir())
 	c := newCgroupsMemoryLimitChecker(testFiles, 1024*1024*512)
-	if _, err := c.isLimitExceeded(); err == nil {
+	if _, err := c.utilization(); err == nil {
 		t.Fatal("Should fail open if can't read files")
 	}
 }
@@ -132,14 +140,248 @@ func TestCgroupsMemoryLimit(t *testing.T) {
 			if err := updateFakeCgroupFiles(c, tc.sysLimit, tc.usage, tc.inactive, tc.active); err != nil {
 				t.Fatalf("Updating cgroup files: %v", err)
 			}
-			exceeded, err := c.isLimitExceeded()
+			utilization, err := c.utilization()
 			if err != nil {
 				t.Fatalf("Checking if limit exceeded: %v", err)
 			}
-			if exceeded != tc.want {
-				t.Errorf("isLimitExceeded() = %t, want %t", exceeded, tc.want)
+			if exceeded := utilization >= 1; exceeded != tc.want {
+				t.Errorf("utilization() = %v, exceeded %t, want %t", utilization, exceeded, tc.want)
 			}
 		},
 		)
 	}
 }
+
+func TestCPULoadLimit(t *testing.T) {
+	var cpuTime time.Duration
+	now := time.Unix(0, 0)
+	c := &cpuLoadLimitChecker{
+		limit:   0.5,
+		numCPU:  4,
+		cpuTime: func() (time.Duration, error) { return cpuTime, nil },
+		now:     func() time.Time { return now },
+	}
+	check := func(want float64) {
+		t.Helper()
+		utilization, err := c.utilization()
+		if err != nil {
+			t.Fatalf("Checking cpu load: %v", err)
+		}
+		if utilization != want {
+			t.Errorf("utilization() = %v, want %v", utilization, want)
+		}
+	}
+
+	// The first sample has nothing to average from
+	cpuTime = time.Hour
+	check(0)
+
+	// Half of 4 CPUs were used over the sample interval
+	now = now.Add(cpuLoadSampleInterval)
+	cpuTime += 2 * cpuLoadSampleInterval
+	check(1)
+
+	// The load isn't sampled again until the interval has passed
+	now = now.Add(cpuLoadSampleInterval / 2)
+	cpuTime += 4 * cpuLoadSampleInterval
+	check(1)
+	now = now.Add(cpuLoadSampleInterval / 2)
+	check(2)
+
+	c.cpuTime = func() (time.Duration, error) { return 0, errors.New("no cpu time") }
+	now = now.Add(cpuLoadSampleInterval)
+	if _, err := c.utilization(); err == nil {
+		t.Error("cpu load checker should fail if the cpu time can't be read")
+	}
+}
+
+func TestOpenFilesLimit(t *testing.T) {
+	fdDir := t.TempDir()
+	addFakeFds := func(from, to int) {
+		for i := from; i < to; i++ {
+			if err := os.WriteFile(filepath.Join(fdDir, fmt.Sprint(i)), nil, 0600); err != nil {
+				t.Fatalf("Creating fake fd: %v", err)
+			}
+		}
+	}
+	now := time.Unix(0, 0)
+	c := newOpenFilesLimitChecker(fdDir, 4)
+	c.now = func() time.Time { return now }
+	check := func(want float64) {
+		t.Helper()
+		utilization, err := c.utilization()
+		if err != nil {
+			t.Fatalf("Checking open files: %v", err)
+		}
+		if utilization != want {
+			t.Errorf("utilization() = %v, want %v", utilization, want)
+		}
+	}
+	addFakeFds(0, 3)
+	check(0.75)
+
+	// The files aren't counted again until the interval has passed
+	addFakeFds(3, 4)
+	check(0.75)
+	now = now.Add(openFilesSampleInterval)
+	check(1)
+
+	if isSupported(newOpenFilesLimitChecker(filepath.Join(fdDir, "missing"), 4)) {
+		t.Error("open files checker should be unsupported without an fd directory")
+	}
+}
+
+type fakeLimitChecker struct {
+	load float64
+	err  error
+}
+
+func (c *fakeLimitChecker) utilization() (float64, error) { return c.load, c.err }
+
+func (c *fakeLimitChecker) String() string { return "fake" }
+
+func TestMultiLimitChecker(t *testing.T) {
+	c := multiLimitChecker{
+		&fakeLimitChecker{load: 0.5},
+		&fakeLimitChecker{err: errors.New("broken")},
+		&fakeLimitChecker{load: 1.5},
+	}
+	utilization, err := c.utilization()
+	if err == nil {
+		t.Error("failing checker should be reported")
+	}
+	if utilization != 1.5 {
+		t.Errorf("utilization() = %v, want 1.5", utilization)
+	}
+}
+
+func TestMethodWeights(t *testing.T) {
+	config := DefaultConfig
+	config.MethodWeights = []string{"debug_traceTransaction=4", "eth_getLogs = 2", "eth_chainId=0.5"}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validating config: %v", err)
+	}
+	checker := &fakeLimitChecker{}
+	var served string
+	server := newHttpServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			t.Fatalf("Reading request body: %v", err)
+		}
+		served = string(body)
+	}), func() *Config { return &config })
+	limits := newLimits(&config)
+	limits.c = checker
+	server.limits.Store(limits)
+
+	for _, tc := range []struct {
+		desc string
+		load float64
+		body string
+		want int
+	}{
+		{
+			desc: "expensive method within its limit",
+			load: 0.2,
+			body: `{"jsonrpc":"2.0","id":1,"method":"debug_traceTransaction","params":[]}`,
+			want: http.StatusOK,
+		},
+		{
+			desc: "expensive method over its limit",
+			load: 0.25,
+			body: `{"jsonrpc":"2.0","id":1,"method":"debug_traceTransaction","params":[]}`,
+			want: http.StatusTooManyRequests,
+		},
+		{
+			desc: "unweighted method within the limit",
+			load: 0.9,
+			body: `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`,
+			want: http.StatusOK,
+		},
+		{
+			desc: "batch with an expensive method",
+			load: 0.6,
+			body: `[{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"},{"jsonrpc":"2.0","id":2,"method":"eth_getLogs","params":[{}]}]`,
+			want: http.StatusTooManyRequests,
+		},
+		{
+			desc: "unweighted method over the limit",
+			load: 1,
+			body: `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`,
+			want: http.StatusTooManyRequests,
+		},
+		{
+			desc: "cheap method over the limit",
+			load: 1.5,
+			body: `{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]}`,
+			want: http.StatusOK,
+		},
+		{
+			desc: "cheap method over its limit",
+			load: 2,
+			body: `{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]}`,
+			want: http.StatusTooManyRequests,
+		},
+		{
+			desc: "unparseable request is unweighted",
+			load: 0.9,
+			body: `{"jsonrpc":"2.0","method":"debug_traceTransaction"`,
+			want: http.StatusOK,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			checker.load = tc.load
+			served = ""
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body)))
+			if recorder.Code != tc.want {
+				t.Errorf("status = %d, want %d", recorder.Code, tc.want)
+			}
+			if tc.want == http.StatusOK && served != tc.body {
+				t.Errorf("served body %q, want %q", served, tc.body)
+			}
+		})
+	}
+}
+
+func TestHotReloadLimits(t *testing.T) {
+	limited := DefaultConfig
+	limited.GoroutineLimit = 1
+	unlimited := DefaultConfig
+	for _, config := range []*Config{&limited, &unlimited} {
+		if err := config.Validate(); err != nil {
+			t.Fatalf("Validating config: %v", err)
+		}
+	}
+	var current atomic.Pointer[Config]
+	current.Store(&limited)
+	server := newHttpServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}), current.Load)
+	serve := func() int {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"method":"eth_chainId"}`)))
+		return recorder.Code
+	}
+
+	if code := serve(); code != http.StatusTooManyRequests {
+		t.Errorf("status with goroutine limit = %d, want %d", code, http.StatusTooManyRequests)
+	}
+	current.Store(&unlimited)
+	if code := serve(); code != http.StatusOK {
+		t.Errorf("status after removing goroutine limit = %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestInvalidMethodWeights(t *testing.T) {
+	for _, weights := range [][]string{
+		{"debug_traceTransaction"},
+		{"=2"},
+		{"eth_getLogs=0"},
+		{"eth_getLogs=-1"},
+		{"eth_getLogs=expensive"},
+		{"eth_getLogs=2", "eth_getLogs=3"},
+	} {
+		if _, err := parseMethodWeights(weights); err == nil {
+			t.Errorf("invalid method weights %v were parsed", weights)
+		}
+	}
+}
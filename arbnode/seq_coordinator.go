@@ -17,10 +17,12 @@ import (
 	"github.com/go-redis/redis/v8"
 	flag "github.com/spf13/pflag"
 
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 
 	"github.com/offchainlabs/nitro/arbnode/execution"
+	"github.com/offchainlabs/nitro/arbnode/redislock"
 	"github.com/offchainlabs/nitro/arbos/arbostypes"
 	"github.com/offchainlabs/nitro/arbutil"
 	"github.com/offchainlabs/nitro/util/arbmath"
@@ -37,7 +39,7 @@ var (
 type SeqCoordinator struct {
 	stopwaiter.StopWaiter
 
-	redisutil.RedisCoordinator
+	backend SeqCoordinatorBackend
 
 	sync             *SyncMonitor
 	streamer         *TransactionStreamer
@@ -46,8 +48,9 @@ type SeqCoordinator struct {
 	signer           *signature.SignVerify
 	config           SeqCoordinatorConfig // warning: static, don't use for hot reloadable fields
 
-	prevChosenSequencer  string
-	reportedWantsLockout bool
+	prevChosenSequencer   string
+	reportedWantsLockout  bool
+	reportedLockoutExpiry bool // from workthread
 
 	lockoutUntil int64 // atomic
 
@@ -55,11 +58,19 @@ type SeqCoordinator struct {
 	avoidLockout      int        // If > 0, prevents acquiring the lockout but not extending the lockout if no alternative sequencer wants the lockout. Protected by chosenUpdateMutex.
 
 	redisErrors int // error counter, from workthread
+
+	historyMutex      sync.Mutex
+	pendingEvents     []*redisutil.CoordinatorEvent // protected by historyMutex
+	historyFlushMutex sync.Mutex                    // held while writing pending events, so they're recorded in order
 }
 
+// recording a history event gives up after this long
+const historyRecordTimeout = 5 * time.Second
+
 type SeqCoordinatorConfig struct {
 	Enable                bool          `koanf:"enable"`
 	ChosenHealthcheckAddr string        `koanf:"chosen-healthcheck-addr"`
+	Backend               string        `koanf:"backend"`
 	RedisUrl              string        `koanf:"redis-url"`
 	LockoutDuration       time.Duration `koanf:"lockout-duration"`
 	LockoutSpare          time.Duration `koanf:"lockout-spare"`
@@ -69,10 +80,12 @@ type SeqCoordinatorConfig struct {
 	HandoffTimeout        time.Duration `koanf:"handoff-timeout"`
 	SafeShutdownDelay     time.Duration `koanf:"safe-shutdown-delay"`
 	ReleaseRetries        int           `koanf:"release-retries"`
+	HistoryLength         int           `koanf:"history-length"`
 	// Max message per poll.
 	MsgPerPoll arbutil.MessageIndex       `koanf:"msg-per-poll"`
 	MyUrl      string                     `koanf:"my-url"`
 	Signer     signature.SignVerifyConfig `koanf:"signer"`
+	Raft       SeqCoordinatorRaftConfig   `koanf:"raft"`
 }
 
 func (c *SeqCoordinatorConfig) Url() string {
@@ -82,8 +95,26 @@ func (c *SeqCoordinatorConfig) Url() string {
 	return c.MyUrl
 }
 
+func (c *SeqCoordinatorConfig) Validate() error {
+	if c.HistoryLength < 0 {
+		return errors.New("seq coordinator history-length must not be negative")
+	}
+	switch c.Backend {
+	case SeqCoordinatorBackendRedis:
+		return nil
+	case SeqCoordinatorBackendRaft:
+		if c.Url() == redisutil.INVALID_URL {
+			return errors.New("seq coordinator raft backend requires my-url to be set")
+		}
+		return c.Raft.Validate()
+	default:
+		return fmt.Errorf("invalid seq coordinator backend %q, must be %q or %q", c.Backend, SeqCoordinatorBackendRedis, SeqCoordinatorBackendRaft)
+	}
+}
+
 func SeqCoordinatorConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Bool(prefix+".enable", DefaultSeqCoordinatorConfig.Enable, "enable sequence coordinator")
+	f.String(prefix+".backend", DefaultSeqCoordinatorConfig.Backend, "what to coordinate via: \""+SeqCoordinatorBackendRedis+"\", or \""+SeqCoordinatorBackendRaft+"\" to replicate the coordination state between the sequencers themselves")
 	f.String(prefix+".redis-url", DefaultSeqCoordinatorConfig.RedisUrl, "the Redis URL to coordinate via")
 	f.String(prefix+".chosen-healthcheck-addr", DefaultSeqCoordinatorConfig.ChosenHealthcheckAddr, "if non-empty, launch an HTTP service binding to this address that returns status code 200 when chosen and 503 otherwise")
 	f.Duration(prefix+".lockout-duration", DefaultSeqCoordinatorConfig.LockoutDuration, "")
@@ -94,14 +125,17 @@ func SeqCoordinatorConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Duration(prefix+".handoff-timeout", DefaultSeqCoordinatorConfig.HandoffTimeout, "the maximum amount of time to spend waiting for another sequencer to accept the lockout when handing it off on shutdown or db compaction")
 	f.Duration(prefix+".safe-shutdown-delay", DefaultSeqCoordinatorConfig.SafeShutdownDelay, "if non-zero will add delay after transferring control")
 	f.Int(prefix+".release-retries", DefaultSeqCoordinatorConfig.ReleaseRetries, "the number of times to retry releasing the wants lockout and chosen one status on shutdown")
+	f.Int(prefix+".history-length", DefaultSeqCoordinatorConfig.HistoryLength, "the number of the latest chosen sequencer changes kept in the coordinator history (0 to not record them)")
 	f.Uint64(prefix+".msg-per-poll", uint64(DefaultSeqCoordinatorConfig.MsgPerPoll), "will only be marked as wanting the lockout if not too far behind")
 	f.String(prefix+".my-url", DefaultSeqCoordinatorConfig.MyUrl, "url for this sequencer if it is the chosen")
 	signature.SignVerifyConfigAddOptions(prefix+".signer", f)
+	SeqCoordinatorRaftConfigAddOptions(prefix+".raft", f)
 }
 
 var DefaultSeqCoordinatorConfig = SeqCoordinatorConfig{
 	Enable:                false,
 	ChosenHealthcheckAddr: "",
+	Backend:               SeqCoordinatorBackendRedis,
 	RedisUrl:              "",
 	LockoutDuration:       time.Minute,
 	LockoutSpare:          30 * time.Second,
@@ -110,14 +144,17 @@ var DefaultSeqCoordinatorConfig = SeqCoordinatorConfig{
 	HandoffTimeout:        30 * time.Second,
 	SafeShutdownDelay:     5 * time.Second,
 	ReleaseRetries:        4,
+	HistoryLength:         1000,
 	RetryInterval:         50 * time.Millisecond,
 	MsgPerPoll:            2000,
 	MyUrl:                 redisutil.INVALID_URL,
 	Signer:                signature.DefaultSignVerifyConfig,
+	Raft:                  DefaultSeqCoordinatorRaftConfig,
 }
 
 var TestSeqCoordinatorConfig = SeqCoordinatorConfig{
 	Enable:            false,
+	Backend:           SeqCoordinatorBackendRedis,
 	RedisUrl:          "",
 	LockoutDuration:   time.Second * 2,
 	LockoutSpare:      time.Millisecond * 10,
@@ -126,28 +163,29 @@ var TestSeqCoordinatorConfig = SeqCoordinatorConfig{
 	HandoffTimeout:    time.Millisecond * 200,
 	SafeShutdownDelay: time.Millisecond * 100,
 	ReleaseRetries:    4,
+	HistoryLength:     100,
 	RetryInterval:     time.Millisecond * 3,
 	MsgPerPoll:        20,
 	MyUrl:             redisutil.INVALID_URL,
 	Signer:            signature.DefaultSignVerifyConfig,
+	Raft:              TestSeqCoordinatorRaftConfig,
 }
 
-func NewSeqCoordinator(dataSigner signature.DataSignerFunc, bpvalidator *contracts.AddressVerifier, streamer *TransactionStreamer, sequencer *execution.Sequencer, sync *SyncMonitor, config SeqCoordinatorConfig) (*SeqCoordinator, error) {
-	redisCoordinator, err := redisutil.NewRedisCoordinator(config.RedisUrl)
-	if err != nil {
-		return nil, err
-	}
+func NewSeqCoordinator(dataSigner signature.DataSignerFunc, bpvalidator *contracts.AddressVerifier, streamer *TransactionStreamer, sequencer *execution.Sequencer, sync *SyncMonitor, arbDb ethdb.Database, config SeqCoordinatorConfig) (*SeqCoordinator, error) {
 	signer, err := signature.NewSignVerify(&config.Signer, dataSigner, bpvalidator)
 	if err != nil {
 		return nil, err
 	}
 	coordinator := &SeqCoordinator{
-		RedisCoordinator: *redisCoordinator,
-		sync:             sync,
-		streamer:         streamer,
-		sequencer:        sequencer,
-		config:           config,
-		signer:           signer,
+		sync:      sync,
+		streamer:  streamer,
+		sequencer: sequencer,
+		config:    config,
+		signer:    signer,
+	}
+	coordinator.backend, err = newSeqCoordinatorBackend(&coordinator.config, arbDb, coordinator.signedBytesToMsgCount)
+	if err != nil {
+		return nil, err
 	}
 	if sequencer != nil {
 		sequencer.Pause()
@@ -200,19 +238,6 @@ func atomicTimeRead(addr *int64) time.Time {
 	return time.UnixMilli(asint64)
 }
 
-func execTestPipe(pipe redis.Pipeliner, ctx context.Context) error {
-	cmders, err := pipe.Exec(ctx)
-	if err != nil {
-		return err
-	}
-	for _, cmder := range cmders {
-		if err := cmder.Err(); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
 func (c *SeqCoordinator) msgCountToSignedBytes(msgCount arbutil.MessageIndex) ([]byte, error) {
 	var msgCountBytes [8]byte
 	binary.BigEndian.PutUint64(msgCountBytes[:], uint64(msgCount))
@@ -237,10 +262,13 @@ func (c *SeqCoordinator) signedBytesToMsgCount(ctx context.Context, data []byte)
 	return arbutil.MessageIndex(binary.BigEndian.Uint64(msgCountBytes)), nil
 }
 
-// Acquires or refreshes the chosen one lockout and optionally writes a message into redis atomically.
+// Acquires or refreshes the chosen one lockout and optionally writes a message atomically.
 func (c *SeqCoordinator) acquireLockoutAndWriteMessage(ctx context.Context, msgCountExpected, msgCountToWrite arbutil.MessageIndex, lastmsg *arbostypes.MessageWithMetadata) error {
-	var messageData *string
-	var messageSigData *string
+	req := &LockoutRequest{
+		Url:              c.config.Url(),
+		MsgCountExpected: msgCountExpected,
+		MsgCount:         msgCountToWrite,
+	}
 	if lastmsg != nil {
 		msgBytes, err := json.Marshal(lastmsg)
 		if err != nil {
@@ -251,104 +279,108 @@ func (c *SeqCoordinator) acquireLockoutAndWriteMessage(ctx context.Context, msgC
 			return err
 		}
 		if c.config.Signer.SymmetricSign {
-			messageString := string(append(msgSig, msgBytes...))
-			messageData = &messageString
+			req.Message = append(msgSig, msgBytes...)
 		} else {
-			messageString := string(msgBytes)
-			sigString := string(msgSig)
-			messageData = &messageString
-			messageSigData = &sigString
+			req.Message = msgBytes
+			req.MessageSig = msgSig
 		}
 	}
-	msgCountMsg, err := c.msgCountToSignedBytes(msgCountToWrite)
+	var err error
+	req.SignedMsgCount, err = c.msgCountToSignedBytes(msgCountToWrite)
 	if err != nil {
 		return err
 	}
 	c.wantsLockoutMutex.Lock()
 	defer c.wantsLockoutMutex.Unlock()
-	setWantsLockout := c.avoidLockout <= 0
-	lockoutUntil := time.Now().Add(c.config.LockoutDuration)
-	err = c.Client.Watch(ctx, func(tx *redis.Tx) error {
-		current, err := tx.Get(ctx, redisutil.CHOSENSEQ_KEY).Result()
-		var wasEmpty bool
-		if errors.Is(err, redis.Nil) {
-			wasEmpty = true
-			err = nil
-		}
-		if err != nil {
-			return err
-		}
-		if !wasEmpty && (current != c.config.Url()) {
-			return fmt.Errorf("%w: failed to catch lock. redis shows chosen: %s", execution.ErrRetrySequencer, current)
-		}
-		remoteMsgCount, err := c.getRemoteMsgCountImpl(ctx, tx)
-		if err != nil {
-			return err
-		}
-		if remoteMsgCount > msgCountExpected {
-			if messageData == nil && c.CurrentlyChosen() {
-				// this was called from update(), while msgCount was changed by a call from SequencingMessage
-				// no need to do anything
-				return nil
-			}
-			log.Info("coordinator failed to become main", "expected", msgCountExpected, "found", remoteMsgCount, "message is nil?", messageData == nil)
-			return fmt.Errorf("%w: failed to catch lock. expected msg %d found %d", execution.ErrRetrySequencer, msgCountExpected, remoteMsgCount)
-		}
-		pipe := tx.TxPipeline()
-		initialDuration := c.config.LockoutDuration
-		if initialDuration < 2*time.Second {
-			initialDuration = 2 * time.Second
-		}
-		if wasEmpty {
-			pipe.Set(ctx, redisutil.CHOSENSEQ_KEY, c.config.Url(), initialDuration)
-		}
-		pipe.Set(ctx, redisutil.MSG_COUNT_KEY, msgCountMsg, c.config.SeqNumDuration)
-		if messageData != nil {
-			pipe.Set(ctx, redisutil.MessageKeyFor(msgCountToWrite-1), *messageData, c.config.SeqNumDuration)
-			if messageSigData != nil {
-				pipe.Set(ctx, redisutil.MessageSigKeyFor(msgCountToWrite-1), *messageSigData, c.config.SeqNumDuration)
-			}
-		}
-		pipe.PExpireAt(ctx, redisutil.CHOSENSEQ_KEY, lockoutUntil)
-		if setWantsLockout {
-			myWantsLockoutKey := redisutil.WantsLockoutKeyFor(c.config.Url())
-			pipe.Set(ctx, myWantsLockoutKey, redisutil.WANTS_LOCKOUT_VAL, initialDuration)
-			pipe.PExpireAt(ctx, myWantsLockoutKey, lockoutUntil)
-		}
-		err = execTestPipe(pipe, ctx)
-		if errors.Is(err, redis.TxFailedErr) {
-			return fmt.Errorf("%w: failed to catch sequencer lock", execution.ErrRetrySequencer)
-		}
-		if err != nil {
-			return fmt.Errorf("chosen sequencer failed to update redis: %w", err)
-		}
-		return nil
-	}, redisutil.CHOSENSEQ_KEY, redisutil.MSG_COUNT_KEY)
-
-	if err != nil {
+	req.SetWantsLockout = c.avoidLockout <= 0
+	req.LockoutUntil = time.Now().Add(c.config.LockoutDuration)
+	req.KeepIfAhead = lastmsg == nil && c.CurrentlyChosen()
+	if err := c.backend.AcquireLockout(ctx, req); err != nil {
 		return err
 	}
-	if setWantsLockout {
+	if req.SetWantsLockout {
 		c.reportedWantsLockout = true
 	}
 	isActiveSequencer.Update(1)
-	atomicTimeWrite(&c.lockoutUntil, lockoutUntil.Add(-c.config.LockoutSpare))
+	atomicTimeWrite(&c.lockoutUntil, req.LockoutUntil.Add(-c.config.LockoutSpare))
 	return nil
 }
 
-func (c *SeqCoordinator) getRemoteMsgCountImpl(ctx context.Context, r redis.Cmdable) (arbutil.MessageIndex, error) {
-	resStr, err := r.Get(ctx, redisutil.MSG_COUNT_KEY).Result()
-	if errors.Is(err, redis.Nil) {
-		return 0, nil
-	}
-	if err != nil {
+func (c *SeqCoordinator) GetRemoteMsgCount() (arbutil.MessageIndex, error) {
+	ctx := c.GetContext()
+	signed, err := c.backend.SignedMsgCount(ctx)
+	if err != nil || signed == nil {
 		return 0, err
 	}
-	return c.signedBytesToMsgCount(ctx, []byte(resStr))
+	return c.signedBytesToMsgCount(ctx, signed)
 }
 
-func (c *SeqCoordinator) GetRemoteMsgCount() (arbutil.MessageIndex, error) {
-	return c.getRemoteMsgCountImpl(c.GetContext(), c.Client)
+// RecommendSequencerWantingLockout returns the top priority sequencer wanting the lockout
+func (c *SeqCoordinator) RecommendSequencerWantingLockout(ctx context.Context) (string, error) {
+	return c.backend.RecommendSequencerWantingLockout(ctx)
+}
+
+// CurrentChosenSequencer retrieves the current chosen sequencer holding the lock
+func (c *SeqCoordinator) CurrentChosenSequencer(ctx context.Context) (string, error) {
+	return c.backend.CurrentChosenSequencer(ctx)
+}
+
+// GetHistory returns up to count of the latest coordinator events, newest first, or all of them if count is 0
+func (c *SeqCoordinator) GetHistory(ctx context.Context, count int) ([]redisutil.CoordinatorEvent, error) {
+	return c.backend.GetHistory(ctx, count)
+}
+
+// recordEvent adds an event to the coordinator history in the background, so that the lockout updates it
+// records aren't delayed by it. Failures are only logged, as the history is informational.
+func (c *SeqCoordinator) recordEvent(ctx context.Context, event string, chosen string, reason string) {
+	if c.config.HistoryLength == 0 {
+		return
+	}
+	var msgCount arbutil.MessageIndex
+	if c.streamer != nil {
+		var err error
+		msgCount, err = c.streamer.GetMessageCount()
+		if err != nil {
+			log.Warn("coordinator cannot read message count for its history", "err", err)
+		}
+	}
+	c.historyMutex.Lock()
+	c.pendingEvents = append(c.pendingEvents, &redisutil.CoordinatorEvent{
+		Time:      time.Now(),
+		Sequencer: c.config.Url(),
+		Event:     event,
+		Chosen:    chosen,
+		MsgCount:  msgCount,
+		Reason:    reason,
+	})
+	c.historyMutex.Unlock()
+	if err := c.LaunchThreadSafe(c.flushHistory); err != nil {
+		// Stopped already, as when shutting down
+		c.flushHistory(ctx)
+	}
+}
+
+// flushHistory records the pending events in the order they happened
+func (c *SeqCoordinator) flushHistory(ctx context.Context) {
+	c.historyFlushMutex.Lock()
+	defer c.historyFlushMutex.Unlock()
+	c.historyMutex.Lock()
+	events := c.pendingEvents
+	c.pendingEvents = nil
+	c.historyMutex.Unlock()
+	for _, event := range events {
+		recordCtx, cancel := context.WithTimeout(ctx, historyRecordTimeout)
+		err := c.backend.RecordEvent(recordCtx, event)
+		cancel()
+		if err != nil {
+			log.Warn("coordinator failed recording event in its history", "event", event.Event, "err", err)
+		}
+	}
+}
+
+// MaintenanceLock returns a lock which only one sequencer can hold at a time, kept by the coordinator's backend.
+func (c *SeqCoordinator) MaintenanceLock(config redislock.SimpleCfgFetcher) (MaintenanceLock, error) {
+	return c.backend.MaintenanceLock(config)
 }
 
 func (c *SeqCoordinator) wantsLockoutUpdate(ctx context.Context) error {
@@ -362,18 +394,9 @@ func (c *SeqCoordinator) wantsLockoutUpdateWithMutex(ctx context.Context) error
 	if c.avoidLockout > 0 {
 		return nil
 	}
-	myWantsLockoutKey := redisutil.WantsLockoutKeyFor(c.config.Url())
 	wantsLockoutUntil := time.Now().Add(c.config.LockoutDuration)
-	pipe := c.Client.TxPipeline()
-	initialDuration := c.config.LockoutDuration
-	if initialDuration < 2*time.Second {
-		initialDuration = 2 * time.Second
-	}
-	pipe.Set(ctx, myWantsLockoutKey, redisutil.WANTS_LOCKOUT_VAL, initialDuration)
-	pipe.PExpireAt(ctx, myWantsLockoutKey, wantsLockoutUntil)
-	err := execTestPipe(pipe, ctx)
-	if err != nil {
-		return fmt.Errorf("failed to update wants lockout key in redis: %w", err)
+	if err := c.backend.SetWantsLockout(ctx, c.config.Url(), wantsLockoutUntil); err != nil {
+		return err
 	}
 	c.reportedWantsLockout = true
 	return nil
@@ -382,37 +405,7 @@ func (c *SeqCoordinator) wantsLockoutUpdateWithMutex(ctx context.Context) error
 func (c *SeqCoordinator) chosenOneRelease(ctx context.Context) error {
 	atomicTimeWrite(&c.lockoutUntil, time.Time{})
 	isActiveSequencer.Update(0)
-	releaseErr := c.Client.Watch(ctx, func(tx *redis.Tx) error {
-		current, err := tx.Get(ctx, redisutil.CHOSENSEQ_KEY).Result()
-		if errors.Is(err, redis.Nil) {
-			return nil
-		}
-		if err != nil {
-			return err
-		}
-		if current != c.config.Url() {
-			return nil
-		}
-		pipe := tx.TxPipeline()
-		pipe.Del(ctx, redisutil.CHOSENSEQ_KEY)
-		err = execTestPipe(pipe, ctx)
-		if err != nil {
-			return fmt.Errorf("chosen sequencer failed to update redis: %w", err)
-		}
-		return nil
-	}, redisutil.CHOSENSEQ_KEY)
-	if releaseErr == nil {
-		return nil
-	}
-	// got error - was it still released?
-	current, readErr := c.Client.Get(ctx, redisutil.CHOSENSEQ_KEY).Result()
-	if errors.Is(readErr, redis.Nil) {
-		return nil
-	}
-	if current != c.config.Url() {
-		return nil
-	}
-	return releaseErr
+	return c.backend.ReleaseLockout(ctx, c.config.Url())
 }
 
 func (c *SeqCoordinator) wantsLockoutRelease(ctx context.Context) error {
@@ -421,14 +414,8 @@ func (c *SeqCoordinator) wantsLockoutRelease(ctx context.Context) error {
 	if !c.reportedWantsLockout {
 		return nil
 	}
-	myWantsLockoutKey := redisutil.WantsLockoutKeyFor(c.config.Url())
-	releaseErr := c.Client.Del(ctx, myWantsLockoutKey).Err()
-	if releaseErr != nil {
-		// got error - was it still deleted?
-		readErr := c.Client.Get(ctx, myWantsLockoutKey).Err()
-		if !errors.Is(readErr, redis.Nil) {
-			return releaseErr
-		}
+	if err := c.backend.ReleaseWantsLockout(ctx, c.config.Url()); err != nil {
+		return err
 	}
 	c.reportedWantsLockout = false
 	return nil
@@ -468,6 +455,7 @@ func (c *SeqCoordinator) updateWithLockout(ctx context.Context, nextChosen strin
 		}
 		c.prevChosenSequencer = setPrevChosenTo
 		log.Info("released chosen-coordinator lock", "myUrl", c.config.Url(), "nextChosen", nextChosen)
+		c.recordEvent(ctx, redisutil.EVENT_RELEASED, nextChosen, "higher priority sequencer wants the lockout")
 		return c.noRedisError()
 	}
 	// Was, and still is, the active sequencer
@@ -477,6 +465,11 @@ func (c *SeqCoordinator) updateWithLockout(ctx context.Context, nextChosen strin
 		// if we recently sequenced - no need for an update
 		return c.noRedisError()
 	}
+	if !c.CurrentlyChosen() && !c.reportedLockoutExpiry {
+		log.Warn("chosen sequencer lockout expired before it was refreshed", "myUrl", c.config.Url())
+		c.recordEvent(ctx, redisutil.EVENT_LOCKOUT_EXPIRED, "", "lockout wasn't refreshed in time")
+		c.reportedLockoutExpiry = true
+	}
 	localMsgCount, err := c.streamer.GetMessageCount()
 	if err != nil {
 		log.Error("coordinator cannot read message count", "err", err)
@@ -487,6 +480,7 @@ func (c *SeqCoordinator) updateWithLockout(ctx context.Context, nextChosen strin
 		log.Warn("coordinator failed chosen-one keepalive", "err", err)
 		return c.retryAfterRedisError()
 	}
+	c.reportedLockoutExpiry = false
 	return c.noRedisError()
 }
 
@@ -514,7 +508,7 @@ func (c *SeqCoordinator) update(ctx context.Context) time.Duration {
 		}
 	}
 
-	// read messages from redis
+	// read messages from the coordination backend
 	localMsgCount, err := c.streamer.GetMessageCount()
 	if err != nil {
 		log.Error("cannot read message count", "err", err)
@@ -533,18 +527,14 @@ func (c *SeqCoordinator) update(ctx context.Context) time.Duration {
 	msgToRead := localMsgCount
 	var msgReadErr error
 	for msgToRead < readUntil {
-		var resString string
-		resString, msgReadErr = c.Client.Get(ctx, redisutil.MessageKeyFor(msgToRead)).Result()
+		var rsBytes, sigBytes []byte
+		rsBytes, sigBytes, msgReadErr = c.backend.Message(ctx, msgToRead)
 		if msgReadErr != nil {
 			log.Warn("coordinator failed reading message", "pos", msgToRead, "err", msgReadErr)
 			break
 		}
-		rsBytes := []byte(resString)
-		var sigString string
-		var sigBytes []byte
 		sigSeparateKey := true
-		sigString, msgReadErr = c.Client.Get(ctx, redisutil.MessageSigKeyFor(msgToRead)).Result()
-		if errors.Is(msgReadErr, redis.Nil) {
+		if sigBytes == nil {
 			// no separate signature. Try reading old-style sig
 			if len(rsBytes) < 32 {
 				log.Warn("signature not found for msg", "pos", msgToRead)
@@ -554,11 +544,6 @@ func (c *SeqCoordinator) update(ctx context.Context) time.Duration {
 			sigBytes = rsBytes[:32]
 			rsBytes = rsBytes[32:]
 			sigSeparateKey = false
-		} else if msgReadErr != nil {
-			log.Warn("coordinator failed reading sig", "pos", msgToRead, "err", msgReadErr)
-			break
-		} else {
-			sigBytes = []byte(sigString)
 		}
 		msgReadErr = c.signer.VerifySignature(ctx, sigBytes, arbmath.UintToBytes(uint64(msgToRead)), rsBytes)
 		if msgReadErr != nil {
@@ -568,7 +553,7 @@ func (c *SeqCoordinator) update(ctx context.Context) time.Duration {
 		var message arbostypes.MessageWithMetadata
 		err = json.Unmarshal(rsBytes, &message)
 		if err != nil {
-			log.Warn("coordinator failed to parse message from redis", "pos", msgToRead, "err", err)
+			log.Warn("coordinator failed to parse message", "pos", msgToRead, "err", err)
 			msgReadErr = fmt.Errorf("failed to parse message: %w", err)
 			// redis messages spelled "INVALID" will be parsed as invalid L1 message, but only one at a time
 			if len(messages) > 0 || string(rsBytes) != redisutil.INVALID_VAL {
@@ -640,6 +625,8 @@ func (c *SeqCoordinator) update(ctx context.Context) time.Duration {
 				return c.retryAfterRedisError()
 			}
 			log.Info("caught chosen-coordinator lock", "myUrl", c.config.Url())
+			c.reportedLockoutExpiry = false
+			c.recordEvent(ctx, redisutil.EVENT_ACQUIRED, c.config.Url(), "top priority sequencer wanting the lockout")
 			if c.delayedSequencer != nil {
 				err = c.delayedSequencer.ForceSequenceDelayed(ctx)
 				if err != nil {
@@ -724,12 +711,17 @@ func (c *SeqCoordinator) launchHealthcheckServer(ctx context.Context) {
 	}
 }
 
-func (c *SeqCoordinator) Start(ctxIn context.Context) {
+func (c *SeqCoordinator) Start(ctxIn context.Context) error {
+	// The backend is needed to release the lockout after stopping
+	if err := c.backend.Start(ctxIn); err != nil {
+		return fmt.Errorf("error starting seq coordinator backend: %w", err)
+	}
 	c.StopWaiter.Start(ctxIn, c)
 	c.CallIteratively(c.update)
 	if c.config.ChosenHealthcheckAddr != "" {
 		c.StopWaiter.LaunchThread(c.launchHealthcheckServer)
 	}
+	return nil
 }
 
 // Calls check() every c.config.RetryInterval until it returns true, or the context times out.
@@ -752,7 +744,7 @@ func (c *SeqCoordinator) PrepareForShutdown() {
 	ctx := c.StopWaiter.GetContext()
 	// Any errors/failures here are logged in these methods
 	c.AvoidLockout(ctx)
-	c.TryToHandoffChosenOne(ctx)
+	c.TryToHandoffChosenOne(ctx, "shutdown")
 }
 
 func (c *SeqCoordinator) StopAndWait() {
@@ -770,18 +762,22 @@ func (c *SeqCoordinator) StopAndWait() {
 			time.Sleep(c.retryAfterRedisError())
 		}
 	}
+	wasChosen := c.CurrentlyChosen()
 	for i := 0; i < c.config.ReleaseRetries || c.config.ReleaseRetries < 0; i++ {
 		log.Info("releasing chosen one", "myUrl", c.config.Url(), "attempt", i)
 		err := c.chosenOneRelease(parentCtx)
 		if err == nil {
 			c.noRedisError()
+			if wasChosen {
+				c.recordEvent(parentCtx, redisutil.EVENT_RELEASED, "", "shutdown")
+			}
 			break
 		} else {
 			log.Error("failed to release chosen one status on shutdown", "err", err)
 			time.Sleep(c.retryAfterRedisError())
 		}
 	}
-	_ = c.Client.Close()
+	_ = c.backend.Close()
 }
 
 func (c *SeqCoordinator) CurrentlyChosen() bool {
@@ -813,24 +809,26 @@ func (c *SeqCoordinator) AvoidLockout(ctx context.Context) bool {
 	return true
 }
 
-// Returns true on success.
-func (c *SeqCoordinator) TryToHandoffChosenOne(ctx context.Context) bool {
-	ctx, cancel := context.WithTimeout(ctx, c.config.HandoffTimeout)
+// Returns true on success. The reason is recorded in the coordinator history.
+func (c *SeqCoordinator) TryToHandoffChosenOne(ctx context.Context, reason string) bool {
+	waitCtx, cancel := context.WithTimeout(ctx, c.config.HandoffTimeout)
 	defer cancel()
 	if c.CurrentlyChosen() {
 		log.Info("waiting for another sequencer to become chosen...", "timeout", c.config.HandoffTimeout, "myUrl", c.config.Url())
-		success := c.waitFor(ctx, func() bool {
+		success := c.waitFor(waitCtx, func() bool {
 			return !c.CurrentlyChosen()
 		})
 		if success {
-			wantsLockout, err := c.RecommendSequencerWantingLockout(ctx)
+			wantsLockout, err := c.RecommendSequencerWantingLockout(waitCtx)
 			if err == nil {
 				log.Info("released chosen one status; a new sequencer hopefully wants to acquire it", "delay", c.config.SafeShutdownDelay, "wantsLockout", wantsLockout)
 			} else {
 				log.Warn("succeeded in releasing chosen one status but failed to get sequencer wanting lockout", "err", err)
 			}
+			c.recordEvent(ctx, redisutil.EVENT_HANDOFF, wantsLockout, reason)
 		} else {
 			log.Error("timed out waiting for another sequencer to become chosen", "timeout", c.config.HandoffTimeout)
+			c.recordEvent(ctx, redisutil.EVENT_HANDOFF_FAILED, c.config.Url(), reason)
 		}
 		return success
 	}
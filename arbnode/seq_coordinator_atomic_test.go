@@ -122,13 +122,12 @@ func TestRedisSeqCoordinatorAtomic(t *testing.T) {
 	for i := 0; i < NumOfThreads; i++ {
 		config := coordConfig
 		config.MyUrl = fmt.Sprint(i)
-		redisCoordinator, err := redisutil.NewRedisCoordinator(config.RedisUrl)
-		Require(t, err)
 		coordinator := &SeqCoordinator{
-			RedisCoordinator: *redisCoordinator,
-			config:           config,
-			signer:           nullSigner,
+			config: config,
+			signer: nullSigner,
 		}
+		coordinator.backend, err = newRedisSeqCoordinatorBackend(&coordinator.config, coordinator.signedBytesToMsgCount)
+		Require(t, err)
 		go coordinatorTestThread(ctx, coordinator, &testData)
 	}
 
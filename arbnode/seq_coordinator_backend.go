@@ -0,0 +1,87 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+
+	"github.com/offchainlabs/nitro/arbnode/dataposter/storage"
+	"github.com/offchainlabs/nitro/arbnode/redislock"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/redisutil"
+)
+
+const (
+	SeqCoordinatorBackendRedis = "redis"
+	SeqCoordinatorBackendRaft  = "raft"
+)
+
+// LockoutRequest acquires or refreshes the chosen sequencer lockout, and
+// writes the message count and optionally a message along with it.
+type LockoutRequest struct {
+	Url              string               `json:"url"`
+	MsgCountExpected arbutil.MessageIndex `json:"msgCountExpected"`
+	MsgCount         arbutil.MessageIndex `json:"msgCount"`
+	SignedMsgCount   []byte               `json:"signedMsgCount"`
+	// The message at MsgCount-1, and its signature if it isn't prepended to the message
+	Message      []byte    `json:"message,omitempty"`
+	MessageSig   []byte    `json:"messageSig,omitempty"`
+	LockoutUntil time.Time `json:"lockoutUntil"`
+	// Also marks the sequencer as wanting the lockout until LockoutUntil
+	SetWantsLockout bool `json:"setWantsLockout"`
+	// If the stored message count is ahead of the one expected, succeed without writing anything
+	KeepIfAhead bool `json:"keepIfAhead"`
+}
+
+// SeqCoordinatorBackend holds the state the sequencers coordinate through.
+// Message counts and messages are stored signed, and verified by the reader.
+type SeqCoordinatorBackend interface {
+	// RecommendSequencerWantingLockout returns the top priority sequencer wanting the lockout, or "" if none does
+	RecommendSequencerWantingLockout(ctx context.Context) (string, error)
+	// CurrentChosenSequencer returns the sequencer holding the lockout, or "" if none does
+	CurrentChosenSequencer(ctx context.Context) (string, error)
+	// AcquireLockout atomically applies the request, failing with execution.ErrRetrySequencer
+	// if another sequencer holds the lockout or the message count isn't the one expected
+	AcquireLockout(ctx context.Context, req *LockoutRequest) error
+	// ReleaseLockout releases the lockout if the sequencer holds it
+	ReleaseLockout(ctx context.Context, url string) error
+	SetWantsLockout(ctx context.Context, url string, until time.Time) error
+	ReleaseWantsLockout(ctx context.Context, url string) error
+	// SignedMsgCount returns nil if the message count was never written
+	SignedMsgCount(ctx context.Context) ([]byte, error)
+	// Message returns the message at pos, and its signature if it isn't prepended to the message
+	Message(ctx context.Context, pos arbutil.MessageIndex) ([]byte, []byte, error)
+	// RecordEvent prepends the event to the coordinator history, which is shared by all sequencers
+	RecordEvent(ctx context.Context, event *redisutil.CoordinatorEvent) error
+	// GetHistory returns up to count of the latest coordinator events, newest first, or all of them if count is 0
+	GetHistory(ctx context.Context, count int) ([]redisutil.CoordinatorEvent, error)
+	// MaintenanceLock returns a lock shared by all sequencers, which lets only one of them go on maintenance at a time
+	MaintenanceLock(config redislock.SimpleCfgFetcher) (MaintenanceLock, error)
+	Start(ctx context.Context) error
+	Close() error
+}
+
+// MaintenanceLock is held by at most one sequencer at a time, until it's released or its lockout duration passes.
+type MaintenanceLock interface {
+	AttemptLock(ctx context.Context) bool
+	Release(ctx context.Context)
+}
+
+type msgCountReader func(ctx context.Context, signed []byte) (arbutil.MessageIndex, error)
+
+func newSeqCoordinatorBackend(config *SeqCoordinatorConfig, arbDb ethdb.Database, readMsgCount msgCountReader) (SeqCoordinatorBackend, error) {
+	switch config.Backend {
+	case SeqCoordinatorBackendRedis:
+		return newRedisSeqCoordinatorBackend(config, readMsgCount)
+	case SeqCoordinatorBackendRaft:
+		return newRaftSeqCoordinatorBackend(config, rawdb.NewTable(arbDb, storage.SeqCoordinatorRaftPrefix))
+	default:
+		return nil, fmt.Errorf("unknown seq coordinator backend %q", config.Backend)
+	}
+}
@@ -0,0 +1,395 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/arbnode/execution"
+	"github.com/offchainlabs/nitro/arbnode/redislock"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/raft"
+	"github.com/offchainlabs/nitro/util/redisutil"
+)
+
+type SeqCoordinatorRaftConfig struct {
+	Cluster          raft.Config `koanf:"cluster"`
+	RetainedMessages uint64      `koanf:"retained-messages"`
+}
+
+var DefaultSeqCoordinatorRaftConfig = SeqCoordinatorRaftConfig{
+	Cluster:          raft.DefaultConfig,
+	RetainedMessages: 1000,
+}
+
+var TestSeqCoordinatorRaftConfig = SeqCoordinatorRaftConfig{
+	Cluster:          raft.TestConfig,
+	RetainedMessages: 100,
+}
+
+func SeqCoordinatorRaftConfigAddOptions(prefix string, f *flag.FlagSet) {
+	raft.ConfigAddOptions(prefix+".cluster", f)
+	f.Uint64(prefix+".retained-messages", DefaultSeqCoordinatorRaftConfig.RetainedMessages, "number of the latest messages kept in the replicated state for other sequencers to read")
+}
+
+func (c *SeqCoordinatorRaftConfig) Validate() error {
+	if c.RetainedMessages == 0 {
+		return errors.New("seq coordinator raft retained-messages must be positive")
+	}
+	return c.Cluster.Validate()
+}
+
+const (
+	raftAcquireLockout      = "acquireLockout"
+	raftReleaseLockout      = "releaseLockout"
+	raftSetWantsLockout     = "setWantsLockout"
+	raftReleaseWantsLockout = "releaseWantsLockout"
+	raftRecordEvent         = "recordEvent"
+	// The maintenance lock is held by the sequencer url until it's released or expires
+	raftAcquireMaintenanceLock = "acquireMaintenanceLock"
+	raftReleaseMaintenanceLock = "releaseMaintenanceLock"
+)
+
+type raftSeqCoordinatorCommand struct {
+	Op      string                      `json:"op"`
+	Lockout *LockoutRequest             `json:"lockout,omitempty"`
+	Url     string                      `json:"url,omitempty"`
+	Until   int64                       `json:"until,omitempty"` // unix milliseconds
+	Event   *redisutil.CoordinatorEvent `json:"event,omitempty"`
+	// The number of events to keep in the history, part of the command so every replica trims it the same way
+	HistoryLength int `json:"historyLength,omitempty"`
+}
+
+type raftSeqCoordinatorResult struct {
+	Error string `json:"error,omitempty"`
+	Retry bool   `json:"retry,omitempty"`
+}
+
+type raftStoredMessage struct {
+	Message []byte `json:"message"`
+	Sig     []byte `json:"sig,omitempty"`
+}
+
+// raftSeqCoordinatorState is the state replicated between the sequencers.
+// Expiry times are compared against the time commands were appended by the
+// raft leader, so that every sequencer applies them the same way.
+type raftSeqCoordinatorState struct {
+	Chosen         string                                     `json:"chosen"`
+	ChosenUntil    int64                                      `json:"chosenUntil"`
+	MsgCount       arbutil.MessageIndex                       `json:"msgCount"`
+	SignedMsgCount []byte                                     `json:"signedMsgCount"`
+	Messages       map[arbutil.MessageIndex]raftStoredMessage `json:"messages"`
+	WantsLockout   map[string]int64                           `json:"wantsLockout"`
+	// Newest first
+	History                []redisutil.CoordinatorEvent `json:"history"`
+	MaintenanceLock        string                       `json:"maintenanceLock"`
+	MaintenanceLockedUntil int64                        `json:"maintenanceLockedUntil"`
+}
+
+type raftSeqCoordinatorStateMachine struct {
+	retainedMessages uint64
+
+	mutex sync.Mutex
+	state raftSeqCoordinatorState
+}
+
+func newRaftSeqCoordinatorStateMachine(retainedMessages uint64) *raftSeqCoordinatorStateMachine {
+	return &raftSeqCoordinatorStateMachine{
+		retainedMessages: retainedMessages,
+		state: raftSeqCoordinatorState{
+			Messages:     make(map[arbutil.MessageIndex]raftStoredMessage),
+			WantsLockout: make(map[string]int64),
+		},
+	}
+}
+
+func (m *raftSeqCoordinatorStateMachine) chosen(now time.Time) string {
+	if now.UnixMilli() >= m.state.ChosenUntil {
+		return ""
+	}
+	return m.state.Chosen
+}
+
+func (m *raftSeqCoordinatorStateMachine) Apply(command []byte, now time.Time) []byte {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var cmd raftSeqCoordinatorCommand
+	var res raftSeqCoordinatorResult
+	if err := json.Unmarshal(command, &cmd); err != nil {
+		res.Error = fmt.Sprintf("invalid command: %v", err)
+	} else {
+		res = m.apply(&cmd, now)
+	}
+	result, err := json.Marshal(&res)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+func (m *raftSeqCoordinatorStateMachine) apply(cmd *raftSeqCoordinatorCommand, now time.Time) raftSeqCoordinatorResult {
+	for url, until := range m.state.WantsLockout {
+		if now.UnixMilli() >= until {
+			delete(m.state.WantsLockout, url)
+		}
+	}
+	switch cmd.Op {
+	case raftAcquireLockout:
+		req := cmd.Lockout
+		if req == nil {
+			return raftSeqCoordinatorResult{Error: "missing lockout request"}
+		}
+		if chosen := m.chosen(now); chosen != "" && chosen != req.Url {
+			return raftSeqCoordinatorResult{Error: fmt.Sprintf("failed to catch lock. raft shows chosen: %s", chosen), Retry: true}
+		}
+		if m.state.MsgCount > req.MsgCountExpected {
+			if req.KeepIfAhead {
+				return raftSeqCoordinatorResult{}
+			}
+			return raftSeqCoordinatorResult{Error: fmt.Sprintf("failed to catch lock. expected msg %d found %d", req.MsgCountExpected, m.state.MsgCount), Retry: true}
+		}
+		m.state.Chosen = req.Url
+		m.state.ChosenUntil = req.LockoutUntil.UnixMilli()
+		m.state.MsgCount = req.MsgCount
+		m.state.SignedMsgCount = req.SignedMsgCount
+		if req.Message != nil {
+			m.state.Messages[req.MsgCount-1] = raftStoredMessage{Message: req.Message, Sig: req.MessageSig}
+			for pos := range m.state.Messages {
+				if uint64(pos)+m.retainedMessages < uint64(req.MsgCount) {
+					delete(m.state.Messages, pos)
+				}
+			}
+		}
+		if req.SetWantsLockout {
+			m.state.WantsLockout[req.Url] = req.LockoutUntil.UnixMilli()
+		}
+	case raftReleaseLockout:
+		if m.state.Chosen == cmd.Url {
+			m.state.Chosen = ""
+			m.state.ChosenUntil = 0
+		}
+	case raftSetWantsLockout:
+		m.state.WantsLockout[cmd.Url] = cmd.Until
+	case raftReleaseWantsLockout:
+		delete(m.state.WantsLockout, cmd.Url)
+	case raftRecordEvent:
+		if cmd.Event == nil {
+			return raftSeqCoordinatorResult{Error: "missing event"}
+		}
+		m.state.History = append([]redisutil.CoordinatorEvent{*cmd.Event}, m.state.History...)
+		if len(m.state.History) > cmd.HistoryLength {
+			m.state.History = m.state.History[:cmd.HistoryLength]
+		}
+	case raftAcquireMaintenanceLock:
+		if holder := m.state.MaintenanceLock; holder != "" && holder != cmd.Url && now.UnixMilli() < m.state.MaintenanceLockedUntil {
+			return raftSeqCoordinatorResult{Error: fmt.Sprintf("maintenance lock is held by %s", holder), Retry: true}
+		}
+		m.state.MaintenanceLock = cmd.Url
+		m.state.MaintenanceLockedUntil = cmd.Until
+	case raftReleaseMaintenanceLock:
+		if m.state.MaintenanceLock == cmd.Url {
+			m.state.MaintenanceLock = ""
+			m.state.MaintenanceLockedUntil = 0
+		}
+	default:
+		return raftSeqCoordinatorResult{Error: fmt.Sprintf("unknown command %q", cmd.Op)}
+	}
+	return raftSeqCoordinatorResult{}
+}
+
+func (m *raftSeqCoordinatorStateMachine) Snapshot() ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return json.Marshal(&m.state)
+}
+
+func (m *raftSeqCoordinatorStateMachine) Restore(snapshot []byte) error {
+	state := raftSeqCoordinatorState{
+		Messages:     make(map[arbutil.MessageIndex]raftStoredMessage),
+		WantsLockout: make(map[string]int64),
+	}
+	if err := json.Unmarshal(snapshot, &state); err != nil {
+		return err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.state = state
+	return nil
+}
+
+// raftSeqCoordinatorBackend replicates the coordination state between the
+// sequencers themselves, so there's no external service they depend on. The
+// raft leader isn't necessarily the chosen sequencer: writes go through the
+// leader, while reads are served from the local replica, which may lag a
+// heartbeat behind. Sequencer priorities are the order of the raft peers.
+type raftSeqCoordinatorBackend struct {
+	url           string
+	node          *raft.Node
+	state         *raftSeqCoordinatorStateMachine
+	priorities    []string
+	historyLength int
+}
+
+func newRaftSeqCoordinatorBackend(config *SeqCoordinatorConfig, db ethdb.KeyValueStore) (*raftSeqCoordinatorBackend, error) {
+	peers, err := config.Raft.Cluster.ParsePeers()
+	if err != nil {
+		return nil, err
+	}
+	priorities := make([]string, 0, len(peers))
+	for _, peer := range peers {
+		priorities = append(priorities, peer.ID)
+	}
+	state := newRaftSeqCoordinatorStateMachine(config.Raft.RetainedMessages)
+	node, err := raft.NewNode(config.Url(), &config.Raft.Cluster, db, state)
+	if err != nil {
+		return nil, err
+	}
+	return &raftSeqCoordinatorBackend{
+		url:           config.Url(),
+		node:          node,
+		state:         state,
+		priorities:    priorities,
+		historyLength: config.HistoryLength,
+	}, nil
+}
+
+func (b *raftSeqCoordinatorBackend) propose(ctx context.Context, cmd *raftSeqCoordinatorCommand) error {
+	command, err := json.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+	resultBytes, err := b.node.Propose(ctx, command)
+	if err != nil {
+		if errors.Is(err, raft.ErrNoLeader) || errors.Is(err, raft.ErrNotLeader) {
+			return fmt.Errorf("%w: %v", execution.ErrRetrySequencer, err)
+		}
+		return err
+	}
+	var result raftSeqCoordinatorResult
+	if err := json.Unmarshal(resultBytes, &result); err != nil {
+		return err
+	}
+	if result.Retry {
+		return fmt.Errorf("%w: %s", execution.ErrRetrySequencer, result.Error)
+	}
+	if result.Error != "" {
+		return errors.New(result.Error)
+	}
+	return nil
+}
+
+func (b *raftSeqCoordinatorBackend) RecommendSequencerWantingLockout(ctx context.Context) (string, error) {
+	b.state.mutex.Lock()
+	defer b.state.mutex.Unlock()
+	now := time.Now().UnixMilli()
+	for _, url := range b.priorities {
+		if now < b.state.state.WantsLockout[url] {
+			return url, nil
+		}
+	}
+	log.Error("no sequencer appears to want the lockout on raft", "priorities", b.priorities)
+	return "", nil
+}
+
+func (b *raftSeqCoordinatorBackend) CurrentChosenSequencer(ctx context.Context) (string, error) {
+	b.state.mutex.Lock()
+	defer b.state.mutex.Unlock()
+	return b.state.chosen(time.Now()), nil
+}
+
+func (b *raftSeqCoordinatorBackend) AcquireLockout(ctx context.Context, req *LockoutRequest) error {
+	return b.propose(ctx, &raftSeqCoordinatorCommand{Op: raftAcquireLockout, Lockout: req})
+}
+
+func (b *raftSeqCoordinatorBackend) ReleaseLockout(ctx context.Context, url string) error {
+	return b.propose(ctx, &raftSeqCoordinatorCommand{Op: raftReleaseLockout, Url: url})
+}
+
+func (b *raftSeqCoordinatorBackend) SetWantsLockout(ctx context.Context, url string, until time.Time) error {
+	return b.propose(ctx, &raftSeqCoordinatorCommand{Op: raftSetWantsLockout, Url: url, Until: until.UnixMilli()})
+}
+
+func (b *raftSeqCoordinatorBackend) ReleaseWantsLockout(ctx context.Context, url string) error {
+	return b.propose(ctx, &raftSeqCoordinatorCommand{Op: raftReleaseWantsLockout, Url: url})
+}
+
+func (b *raftSeqCoordinatorBackend) SignedMsgCount(ctx context.Context) ([]byte, error) {
+	b.state.mutex.Lock()
+	defer b.state.mutex.Unlock()
+	return b.state.state.SignedMsgCount, nil
+}
+
+func (b *raftSeqCoordinatorBackend) Message(ctx context.Context, pos arbutil.MessageIndex) ([]byte, []byte, error) {
+	b.state.mutex.Lock()
+	defer b.state.mutex.Unlock()
+	stored, ok := b.state.state.Messages[pos]
+	if !ok {
+		return nil, nil, fmt.Errorf("message %v isn't retained", pos)
+	}
+	return stored.Message, stored.Sig, nil
+}
+
+func (b *raftSeqCoordinatorBackend) RecordEvent(ctx context.Context, event *redisutil.CoordinatorEvent) error {
+	return b.propose(ctx, &raftSeqCoordinatorCommand{Op: raftRecordEvent, Event: event, HistoryLength: b.historyLength})
+}
+
+func (b *raftSeqCoordinatorBackend) GetHistory(ctx context.Context, count int) ([]redisutil.CoordinatorEvent, error) {
+	b.state.mutex.Lock()
+	defer b.state.mutex.Unlock()
+	history := b.state.state.History
+	if count > 0 && count < len(history) {
+		history = history[:count]
+	}
+	return append([]redisutil.CoordinatorEvent(nil), history...), nil
+}
+
+func (b *raftSeqCoordinatorBackend) MaintenanceLock(config redislock.SimpleCfgFetcher) (MaintenanceLock, error) {
+	return &raftMaintenanceLock{backend: b, config: config}, nil
+}
+
+func (b *raftSeqCoordinatorBackend) Start(ctx context.Context) error {
+	return b.node.Start(ctx)
+}
+
+func (b *raftSeqCoordinatorBackend) Close() error {
+	if b.node.Started() {
+		b.node.StopAndWait()
+	}
+	return nil
+}
+
+// raftMaintenanceLock is the replicated counterpart of the redis maintenance lock.
+// It's held by the sequencer's url for the lock's lockout duration.
+type raftMaintenanceLock struct {
+	backend *raftSeqCoordinatorBackend
+	config  redislock.SimpleCfgFetcher
+}
+
+func (l *raftMaintenanceLock) AttemptLock(ctx context.Context) bool {
+	until := time.Now().Add(l.config().LockoutDuration)
+	err := l.backend.propose(ctx, &raftSeqCoordinatorCommand{Op: raftAcquireMaintenanceLock, Url: l.backend.url, Until: until.UnixMilli()})
+	if err != nil {
+		if !errors.Is(err, execution.ErrRetrySequencer) {
+			log.Error("failed to acquire the raft maintenance lock", "err", err)
+		}
+		return false
+	}
+	return true
+}
+
+func (l *raftMaintenanceLock) Release(ctx context.Context) {
+	err := l.backend.propose(ctx, &raftSeqCoordinatorCommand{Op: raftReleaseMaintenanceLock, Url: l.backend.url})
+	if err != nil {
+		log.Error("failed to release the raft maintenance lock", "err", err)
+	}
+}
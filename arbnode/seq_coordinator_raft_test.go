@@ -0,0 +1,256 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+
+	"github.com/offchainlabs/nitro/arbnode/execution"
+	"github.com/offchainlabs/nitro/arbnode/redislock"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/raft/rafttest"
+	"github.com/offchainlabs/nitro/util/redisutil"
+	"github.com/offchainlabs/nitro/util/signature"
+)
+
+func createRaftTestCoordinators(t *testing.T, ctx context.Context, count int) []*SeqCoordinator {
+	coordConfig := TestSeqCoordinatorConfig
+	coordConfig.Backend = SeqCoordinatorBackendRaft
+	coordConfig.LockoutDuration = time.Millisecond * 200
+	// Leaves time for proposals to reach the raft leader before the lockout expires
+	coordConfig.LockoutSpare = time.Millisecond * 50
+	coordConfig.Signer.ECDSA.AcceptSequencer = false
+	coordConfig.Signer.SymmetricFallback = true
+	coordConfig.Signer.SymmetricSign = true
+	coordConfig.Signer.Symmetric.Dangerous.DisableSignatureVerification = true
+	coordConfig.Signer.Symmetric.SigningKey = ""
+	nullSigner, err := signature.NewSignVerify(&coordConfig.Signer, nil, nil)
+	Require(t, err)
+
+	tlsConfig := &coordConfig.Raft.Cluster.TLS
+	tlsConfig.Cert, tlsConfig.Key, tlsConfig.CA = rafttest.TLSFiles(t)
+	addrs := rafttest.Addrs(t, count)
+	coordConfig.Raft.Cluster.Peers = nil
+	for i, addr := range addrs {
+		coordConfig.Raft.Cluster.Peers = append(coordConfig.Raft.Cluster.Peers, fmt.Sprintf("seq%d=%s", i, addr))
+	}
+	var coordinators []*SeqCoordinator
+	for i := 0; i < count; i++ {
+		config := coordConfig
+		config.MyUrl = fmt.Sprintf("seq%d", i)
+		config.Raft.Cluster.Addr = addrs[i]
+		Require(t, config.Validate())
+		coordinator := &SeqCoordinator{
+			config: config,
+			signer: nullSigner,
+		}
+		backend, err := newRaftSeqCoordinatorBackend(&coordinator.config, rawdb.NewMemoryDatabase())
+		Require(t, err)
+		coordinator.backend = backend
+		Require(t, backend.Start(ctx))
+		t.Cleanup(func() { _ = backend.Close() })
+		coordinators = append(coordinators, coordinator)
+	}
+	return coordinators
+}
+
+func waitForRaftCoordinators(t *testing.T, coordinators []*SeqCoordinator, check func(*SeqCoordinator) bool) {
+	t.Helper()
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		done := true
+		for _, coord := range coordinators {
+			if !check(coord) {
+				done = false
+			}
+		}
+		if done {
+			return
+		}
+	}
+	Fail(t, "raft coordinators didn't converge")
+}
+
+func TestRaftSeqCoordinator(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	coordinators := createRaftTestCoordinators(t, ctx, 3)
+	for _, coord := range coordinators {
+		coord.StopWaiter.Start(ctx, coord)
+	}
+	waitForRaftCoordinators(t, coordinators, func(coord *SeqCoordinator) bool {
+		return coord.backend.(*raftSeqCoordinatorBackend).node.Leader() != ""
+	})
+
+	// Every sequencer sees the top priority sequencer wanting the lockout
+	for _, coord := range coordinators[1:] {
+		Require(t, coord.wantsLockoutUpdate(ctx))
+	}
+	waitForRaftCoordinators(t, coordinators, func(coord *SeqCoordinator) bool {
+		recommended, err := coord.RecommendSequencerWantingLockout(ctx)
+		return err == nil && recommended == "seq1"
+	})
+	Require(t, coordinators[0].wantsLockoutUpdate(ctx))
+	waitForRaftCoordinators(t, coordinators, func(coord *SeqCoordinator) bool {
+		recommended, err := coord.RecommendSequencerWantingLockout(ctx)
+		return err == nil && recommended == "seq0"
+	})
+
+	// Only one sequencer can hold the lockout
+	Require(t, coordinators[0].acquireLockoutAndWriteMessage(ctx, 0, 1, &arbostypes.EmptyTestMessageWithMetadata))
+	err := coordinators[1].acquireLockoutAndWriteMessage(ctx, 1, 2, &arbostypes.EmptyTestMessageWithMetadata)
+	if !errors.Is(err, execution.ErrRetrySequencer) {
+		Fail(t, "second sequencer acquired the lockout", err)
+	}
+	Require(t, coordinators[0].SequencingMessage(1, &arbostypes.EmptyTestMessageWithMetadata))
+
+	// The message count and messages are replicated
+	waitForRaftCoordinators(t, coordinators, func(coord *SeqCoordinator) bool {
+		count, err := coord.GetRemoteMsgCount()
+		return err == nil && count == 2
+	})
+	for _, coord := range coordinators {
+		for pos := arbutil.MessageIndex(0); pos < 2; pos++ {
+			message, _, err := coord.backend.Message(ctx, pos)
+			Require(t, err)
+			if len(message) == 0 {
+				Fail(t, "empty message", pos, "on", coord.config.Url())
+			}
+		}
+		chosen, err := coord.CurrentChosenSequencer(ctx)
+		Require(t, err)
+		if chosen != "seq0" {
+			Fail(t, "unexpected chosen sequencer", chosen, "on", coord.config.Url())
+		}
+	}
+
+	// After release, the lockout goes to a sequencer that's caught up
+	Require(t, coordinators[0].chosenOneRelease(ctx))
+	err = coordinators[1].acquireLockoutAndWriteMessage(ctx, 1, 1, nil)
+	if !errors.Is(err, execution.ErrRetrySequencer) {
+		Fail(t, "sequencer behind acquired the lockout", err)
+	}
+	Require(t, coordinators[1].acquireLockoutAndWriteMessage(ctx, 2, 2, nil))
+	waitForRaftCoordinators(t, coordinators, func(coord *SeqCoordinator) bool {
+		chosen, err := coord.CurrentChosenSequencer(ctx)
+		return err == nil && chosen == "seq1"
+	})
+
+	// Coordinator events are replicated to every sequencer
+	coordinators[0].recordEvent(ctx, redisutil.EVENT_HANDOFF, "seq1", "maintenance")
+	waitForRaftCoordinators(t, coordinators, func(coord *SeqCoordinator) bool {
+		history, err := coord.GetHistory(ctx, 0)
+		return err == nil && len(history) == 1 && history[0].Sequencer == "seq0" && history[0].Chosen == "seq1" && history[0].Reason == "maintenance"
+	})
+}
+
+func TestRaftSeqCoordinatorHistory(t *testing.T) {
+	state := newRaftSeqCoordinatorStateMachine(TestSeqCoordinatorRaftConfig.RetainedMessages)
+	for i := 0; i < 5; i++ {
+		command, err := json.Marshal(&raftSeqCoordinatorCommand{
+			Op:            raftRecordEvent,
+			Event:         &redisutil.CoordinatorEvent{Event: redisutil.EVENT_ACQUIRED, MsgCount: arbutil.MessageIndex(i)},
+			HistoryLength: 3,
+		})
+		Require(t, err)
+		var result raftSeqCoordinatorResult
+		Require(t, json.Unmarshal(state.Apply(command, time.Now()), &result))
+		if result.Error != "" {
+			Fail(t, "failed recording event", result.Error)
+		}
+	}
+
+	// The history is trimmed to its length, newest first, and survives a snapshot
+	snapshot, err := state.Snapshot()
+	Require(t, err)
+	restored := newRaftSeqCoordinatorStateMachine(TestSeqCoordinatorRaftConfig.RetainedMessages)
+	Require(t, restored.Restore(snapshot))
+	for _, history := range [][]redisutil.CoordinatorEvent{state.state.History, restored.state.History} {
+		if len(history) != 3 {
+			Fail(t, "unexpected history length", len(history))
+		}
+		for i, event := range history {
+			if event.MsgCount != arbutil.MessageIndex(4-i) {
+				Fail(t, "unexpected event", i, "in history with msg count", event.MsgCount)
+			}
+		}
+	}
+}
+
+func TestRaftSeqCoordinatorAtomic(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	coordinators := createRaftTestCoordinators(t, ctx, 3)
+	waitForRaftCoordinators(t, coordinators, func(coord *SeqCoordinator) bool {
+		return coord.backend.(*raftSeqCoordinatorBackend).node.Leader() != ""
+	})
+
+	testData := CoordinatorTestData{
+		testStartRound: -1,
+		sequencer:      make([]string, messagesPerRound),
+	}
+	for _, coord := range coordinators {
+		go coordinatorTestThread(ctx, coord, &testData)
+	}
+	testData.waitForCoords.Add(len(coordinators))
+	atomic.StoreInt32(&testData.testStartRound, 0)
+	testData.waitForCoords.Wait()
+	Require(t, testData.err)
+	for i := 0; i < messagesPerRound; i++ {
+		if testData.sequencer[i] == "" {
+			Fail(t, "no sequencer succeeded", "message", i)
+		}
+	}
+}
+
+func TestRaftSeqCoordinatorMaintenanceLock(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	coordinators := createRaftTestCoordinators(t, ctx, 2)
+	waitForRaftCoordinators(t, coordinators, func(coord *SeqCoordinator) bool {
+		return coord.backend.(*raftSeqCoordinatorBackend).node.Leader() != ""
+	})
+
+	lockConfig := redislock.DefaultCfg
+	lockConfig.LockoutDuration = time.Second
+	var locks []MaintenanceLock
+	for _, coord := range coordinators {
+		lock, err := coord.MaintenanceLock(func() *redislock.SimpleCfg { return &lockConfig })
+		Require(t, err)
+		locks = append(locks, lock)
+	}
+
+	// Only one sequencer can go on maintenance at a time
+	if !locks[0].AttemptLock(ctx) {
+		Fail(t, "failed to acquire the maintenance lock")
+	}
+	if !locks[0].AttemptLock(ctx) {
+		Fail(t, "failed to refresh the maintenance lock")
+	}
+	if locks[1].AttemptLock(ctx) {
+		Fail(t, "second sequencer acquired the maintenance lock while it's held")
+	}
+	locks[0].Release(ctx)
+	if !locks[1].AttemptLock(ctx) {
+		Fail(t, "failed to acquire the released maintenance lock")
+	}
+
+	// The lock expires after its lockout duration
+	lockConfig.LockoutDuration = time.Millisecond * 100
+	if !locks[1].AttemptLock(ctx) {
+		Fail(t, "failed to refresh the maintenance lock")
+	}
+	time.Sleep(lockConfig.LockoutDuration)
+	if !locks[0].AttemptLock(ctx) {
+		Fail(t, "failed to acquire the expired maintenance lock")
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbnode/execution"
+	"github.com/offchainlabs/nitro/arbnode/redislock"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/redisutil"
+)
+
+type redisSeqCoordinatorBackend struct {
+	redisutil.RedisCoordinator
+	config       *SeqCoordinatorConfig
+	readMsgCount msgCountReader
+}
+
+func newRedisSeqCoordinatorBackend(config *SeqCoordinatorConfig, readMsgCount msgCountReader) (*redisSeqCoordinatorBackend, error) {
+	redisCoordinator, err := redisutil.NewRedisCoordinator(config.RedisUrl)
+	if err != nil {
+		return nil, err
+	}
+	return &redisSeqCoordinatorBackend{
+		RedisCoordinator: *redisCoordinator,
+		config:           config,
+		readMsgCount:     readMsgCount,
+	}, nil
+}
+
+func execTestPipe(pipe redis.Pipeliner, ctx context.Context) error {
+	cmders, err := pipe.Exec(ctx)
+	if err != nil {
+		return err
+	}
+	for _, cmder := range cmders {
+		if err := cmder.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *redisSeqCoordinatorBackend) initialDuration() time.Duration {
+	if b.config.LockoutDuration < 2*time.Second {
+		return 2 * time.Second
+	}
+	return b.config.LockoutDuration
+}
+
+func (b *redisSeqCoordinatorBackend) AcquireLockout(ctx context.Context, req *LockoutRequest) error {
+	return b.Client.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.Get(ctx, redisutil.CHOSENSEQ_KEY).Result()
+		var wasEmpty bool
+		if errors.Is(err, redis.Nil) {
+			wasEmpty = true
+			err = nil
+		}
+		if err != nil {
+			return err
+		}
+		if !wasEmpty && (current != req.Url) {
+			return fmt.Errorf("%w: failed to catch lock. redis shows chosen: %s", execution.ErrRetrySequencer, current)
+		}
+		remoteMsgCount, err := b.msgCount(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if remoteMsgCount > req.MsgCountExpected {
+			if req.KeepIfAhead {
+				// this was called from update(), while msgCount was changed by a call from SequencingMessage
+				// no need to do anything
+				return nil
+			}
+			log.Info("coordinator failed to become main", "expected", req.MsgCountExpected, "found", remoteMsgCount, "message is nil?", req.Message == nil)
+			return fmt.Errorf("%w: failed to catch lock. expected msg %d found %d", execution.ErrRetrySequencer, req.MsgCountExpected, remoteMsgCount)
+		}
+		pipe := tx.TxPipeline()
+		initialDuration := b.initialDuration()
+		if wasEmpty {
+			pipe.Set(ctx, redisutil.CHOSENSEQ_KEY, req.Url, initialDuration)
+		}
+		pipe.Set(ctx, redisutil.MSG_COUNT_KEY, req.SignedMsgCount, b.config.SeqNumDuration)
+		if req.Message != nil {
+			pipe.Set(ctx, redisutil.MessageKeyFor(req.MsgCount-1), req.Message, b.config.SeqNumDuration)
+			if req.MessageSig != nil {
+				pipe.Set(ctx, redisutil.MessageSigKeyFor(req.MsgCount-1), req.MessageSig, b.config.SeqNumDuration)
+			}
+		}
+		pipe.PExpireAt(ctx, redisutil.CHOSENSEQ_KEY, req.LockoutUntil)
+		if req.SetWantsLockout {
+			myWantsLockoutKey := redisutil.WantsLockoutKeyFor(req.Url)
+			pipe.Set(ctx, myWantsLockoutKey, redisutil.WANTS_LOCKOUT_VAL, initialDuration)
+			pipe.PExpireAt(ctx, myWantsLockoutKey, req.LockoutUntil)
+		}
+		err = execTestPipe(pipe, ctx)
+		if errors.Is(err, redis.TxFailedErr) {
+			return fmt.Errorf("%w: failed to catch sequencer lock", execution.ErrRetrySequencer)
+		}
+		if err != nil {
+			return fmt.Errorf("chosen sequencer failed to update redis: %w", err)
+		}
+		return nil
+	}, redisutil.CHOSENSEQ_KEY, redisutil.MSG_COUNT_KEY)
+}
+
+func (b *redisSeqCoordinatorBackend) msgCount(ctx context.Context, r redis.Cmdable) (arbutil.MessageIndex, error) {
+	resStr, err := r.Get(ctx, redisutil.MSG_COUNT_KEY).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return b.readMsgCount(ctx, []byte(resStr))
+}
+
+func (b *redisSeqCoordinatorBackend) SignedMsgCount(ctx context.Context) ([]byte, error) {
+	resStr, err := b.Client.Get(ctx, redisutil.MSG_COUNT_KEY).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(resStr), nil
+}
+
+func (b *redisSeqCoordinatorBackend) SetWantsLockout(ctx context.Context, url string, until time.Time) error {
+	myWantsLockoutKey := redisutil.WantsLockoutKeyFor(url)
+	pipe := b.Client.TxPipeline()
+	pipe.Set(ctx, myWantsLockoutKey, redisutil.WANTS_LOCKOUT_VAL, b.initialDuration())
+	pipe.PExpireAt(ctx, myWantsLockoutKey, until)
+	err := execTestPipe(pipe, ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update wants lockout key in redis: %w", err)
+	}
+	return nil
+}
+
+func (b *redisSeqCoordinatorBackend) ReleaseLockout(ctx context.Context, url string) error {
+	releaseErr := b.Client.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.Get(ctx, redisutil.CHOSENSEQ_KEY).Result()
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if current != url {
+			return nil
+		}
+		pipe := tx.TxPipeline()
+		pipe.Del(ctx, redisutil.CHOSENSEQ_KEY)
+		err = execTestPipe(pipe, ctx)
+		if err != nil {
+			return fmt.Errorf("chosen sequencer failed to update redis: %w", err)
+		}
+		return nil
+	}, redisutil.CHOSENSEQ_KEY)
+	if releaseErr == nil {
+		return nil
+	}
+	// got error - was it still released?
+	current, readErr := b.Client.Get(ctx, redisutil.CHOSENSEQ_KEY).Result()
+	if errors.Is(readErr, redis.Nil) {
+		return nil
+	}
+	if current != url {
+		return nil
+	}
+	return releaseErr
+}
+
+func (b *redisSeqCoordinatorBackend) ReleaseWantsLockout(ctx context.Context, url string) error {
+	myWantsLockoutKey := redisutil.WantsLockoutKeyFor(url)
+	releaseErr := b.Client.Del(ctx, myWantsLockoutKey).Err()
+	if releaseErr != nil {
+		// got error - was it still deleted?
+		readErr := b.Client.Get(ctx, myWantsLockoutKey).Err()
+		if !errors.Is(readErr, redis.Nil) {
+			return releaseErr
+		}
+	}
+	return nil
+}
+
+func (b *redisSeqCoordinatorBackend) Message(ctx context.Context, pos arbutil.MessageIndex) ([]byte, []byte, error) {
+	message, err := b.Client.Get(ctx, redisutil.MessageKeyFor(pos)).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+	sig, err := b.Client.Get(ctx, redisutil.MessageSigKeyFor(pos)).Result()
+	if errors.Is(err, redis.Nil) {
+		return []byte(message), nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading signature: %w", err)
+	}
+	return []byte(message), []byte(sig), nil
+}
+
+func (b *redisSeqCoordinatorBackend) RecordEvent(ctx context.Context, event *redisutil.CoordinatorEvent) error {
+	return b.RedisCoordinator.RecordEvent(ctx, event, b.config.HistoryLength)
+}
+
+func (b *redisSeqCoordinatorBackend) MaintenanceLock(config redislock.SimpleCfgFetcher) (MaintenanceLock, error) {
+	readyToLock := func() bool { return true } // always ready to lock
+	lock, err := redislock.NewSimple(b.Client, config, readyToLock)
+	if err != nil {
+		return nil, fmt.Errorf("creating new simple redis lock: %w", err)
+	}
+	return lock, nil
+}
+
+func (b *redisSeqCoordinatorBackend) Start(context.Context) error {
+	return nil
+}
+
+func (b *redisSeqCoordinatorBackend) Close() error {
+	return b.Client.Close()
+}
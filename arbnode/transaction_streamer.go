@@ -112,7 +112,10 @@ func NewTransactionStreamer(
 		fatalErrChan:       fatalErrChan,
 		config:             config,
 	}
-	streamer.exec.SetTransactionStreamer(streamer)
+	// exec may be nil for offline tools which only read stored messages
+	if exec != nil {
+		exec.SetTransactionStreamer(streamer)
+	}
 	err := streamer.cleanupInconsistentState()
 	if err != nil {
 		return nil, err
@@ -206,9 +209,10 @@ func deleteStartingAt(db ethdb.Database, batch ethdb.Batch, prefix []byte, minKe
 	return iter.Error()
 }
 
-// deleteFromRange deletes key ranging from startMinKey(inclusive) to endMinKey(exclusive)
+// deleteFromRange deletes key ranging from startMinKey(inclusive) to endMinKey(exclusive), or only counts them on a dry run.
+// The number of keys handled is added to progress as it goes.
 // might have deleted some keys even if returning an error
-func deleteFromRange(ctx context.Context, db ethdb.Database, prefix []byte, startMinKey uint64, endMinKey uint64) ([]uint64, error) {
+func deleteFromRange(ctx context.Context, db ethdb.Database, prefix []byte, startMinKey uint64, endMinKey uint64, dryRun bool, progress *atomic.Uint64) ([]uint64, error) {
 	batch := db.NewBatch()
 	startIter := db.NewIterator(prefix, uint64ToKey(startMinKey))
 	defer startIter.Release()
@@ -226,6 +230,10 @@ func deleteFromRange(ctx context.Context, db ethdb.Database, prefix []byte, star
 		} else {
 			prunedKeysRange[1] = currentKey
 		}
+		progress.Add(1)
+		if dryRun {
+			continue
+		}
 		err := batch.Delete(startIter.Key())
 		if err != nil {
 			return nil, err
@@ -13,6 +13,7 @@ import (
 	"io"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
 
 	"github.com/offchainlabs/nitro/arbos/util"
 	"github.com/offchainlabs/nitro/blsSignatures"
@@ -40,6 +41,22 @@ const L1AuthenticatedMessageHeaderFlag byte = 0x40
 // ZeroheavyMessageHeaderFlag indicates that this message is zeroheavy-encoded.
 const ZeroheavyMessageHeaderFlag byte = 0x20
 
+// CodecMessageHeaderFlag indicates that the next byte is the arbcompress.CodecID
+// the rest of the message was compressed with.
+// Only chains that enable codecs (see CodecsEnabled) read it.
+const CodecMessageHeaderFlag byte = 0x10
+
+// CodecMessageArbOSVersion is the ArbOS version codec-compressed batches were introduced in.
+const CodecMessageArbOSVersion uint64 = 11
+
+// CodecsEnabled returns whether a chain reads codec-compressed batches.
+// Batches are parsed before they're executed, so this can't depend on the current ArbOS version;
+// it depends on the version the chain was initialized with instead.
+// On older chains these batches are treated as an unknown format, as they were before codecs existed.
+func CodecsEnabled(chainConfig *params.ChainConfig) bool {
+	return chainConfig.ArbitrumChainParams.InitialArbOSVersion >= CodecMessageArbOSVersion
+}
+
 // BrotliMessageHeaderByte indicates that the message is brotli-compressed.
 const BrotliMessageHeaderByte byte = 0
 
@@ -55,6 +72,10 @@ func IsZeroheavyEncodedHeaderByte(header byte) bool {
 	return (ZeroheavyMessageHeaderFlag & header) > 0
 }
 
+func IsCodecMessageHeaderByte(header byte) bool {
+	return (CodecMessageHeaderFlag & header) > 0
+}
+
 func IsBrotliMessageHeaderByte(b uint8) bool {
 	return b == BrotliMessageHeaderByte
 }
@@ -50,7 +50,7 @@ const maxZeroheavyDecompressedLen = 101*MaxDecompressedLen/100 + 64
 const MaxSegmentsPerSequencerMessage = 100 * 1024
 const MinLifetimeSecondsForDataAvailabilityCert = 7 * 24 * 60 * 60 // one week
 
-func parseSequencerMessage(ctx context.Context, batchNum uint64, data []byte, dasReader DataAvailabilityReader, keysetValidationMode KeysetValidationMode) (*sequencerMessage, error) {
+func parseSequencerMessage(ctx context.Context, batchNum uint64, data []byte, dasReader DataAvailabilityReader, codecsEnabled bool, keysetValidationMode KeysetValidationMode) (*sequencerMessage, error) {
 	if len(data) < 40 {
 		return nil, errors.New("sequencer message missing L1 header")
 	}
@@ -91,26 +91,18 @@ func parseSequencerMessage(ctx context.Context, batchNum uint64, data []byte, da
 	if len(payload) > 0 && IsBrotliMessageHeaderByte(payload[0]) {
 		decompressed, err := arbcompress.Decompress(payload[1:], MaxDecompressedLen)
 		if err == nil {
-			reader := bytes.NewReader(decompressed)
-			stream := rlp.NewStream(reader, uint64(MaxDecompressedLen))
-			for {
-				var segment []byte
-				err := stream.Decode(&segment)
-				if err != nil {
-					if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
-						log.Warn("error parsing sequencer message segment", "err", err.Error())
-					}
-					break
-				}
-				if len(parsedMsg.segments) >= MaxSegmentsPerSequencerMessage {
-					log.Warn("too many segments in sequence batch")
-					break
-				}
-				parsedMsg.segments = append(parsedMsg.segments, segment)
-			}
+			parseSequencerMessageSegments(parsedMsg, decompressed)
 		} else {
 			log.Warn("sequencer msg decompression failed", "err", err)
 		}
+	} else if len(payload) > 1 && codecsEnabled && IsCodecMessageHeaderByte(payload[0]) {
+		codec := arbcompress.CodecID(payload[1])
+		decompressed, err := arbcompress.DecompressWithCodec(codec, payload[2:], MaxDecompressedLen)
+		if err == nil {
+			parseSequencerMessageSegments(parsedMsg, decompressed)
+		} else {
+			log.Warn("sequencer msg decompression failed", "codec", codec, "err", err)
+		}
 	} else {
 		length := len(payload)
 		if length == 0 {
@@ -124,6 +116,26 @@ func parseSequencerMessage(ctx context.Context, batchNum uint64, data []byte, da
 	return parsedMsg, nil
 }
 
+func parseSequencerMessageSegments(parsedMsg *sequencerMessage, decompressed []byte) {
+	reader := bytes.NewReader(decompressed)
+	stream := rlp.NewStream(reader, uint64(MaxDecompressedLen))
+	for {
+		var segment []byte
+		err := stream.Decode(&segment)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+				log.Warn("error parsing sequencer message segment", "err", err.Error())
+			}
+			break
+		}
+		if len(parsedMsg.segments) >= MaxSegmentsPerSequencerMessage {
+			log.Warn("too many segments in sequence batch")
+			break
+		}
+		parsedMsg.segments = append(parsedMsg.segments, segment)
+	}
+}
+
 func RecoverPayloadFromDasBatch(
 	ctx context.Context,
 	batchNum uint64,
@@ -242,6 +254,7 @@ type inboxMultiplexer struct {
 	backend                   InboxBackend
 	delayedMessagesRead       uint64
 	dasReader                 DataAvailabilityReader
+	codecsEnabled             bool
 	cachedSequencerMessage    *sequencerMessage
 	cachedSequencerMessageNum uint64
 	cachedSegmentNum          uint64
@@ -251,11 +264,12 @@ type inboxMultiplexer struct {
 	keysetValidationMode      KeysetValidationMode
 }
 
-func NewInboxMultiplexer(backend InboxBackend, delayedMessagesRead uint64, dasReader DataAvailabilityReader, keysetValidationMode KeysetValidationMode) arbostypes.InboxMultiplexer {
+func NewInboxMultiplexer(backend InboxBackend, delayedMessagesRead uint64, dasReader DataAvailabilityReader, codecsEnabled bool, keysetValidationMode KeysetValidationMode) arbostypes.InboxMultiplexer {
 	return &inboxMultiplexer{
 		backend:              backend,
 		delayedMessagesRead:  delayedMessagesRead,
 		dasReader:            dasReader,
+		codecsEnabled:        codecsEnabled,
 		keysetValidationMode: keysetValidationMode,
 	}
 }
@@ -276,7 +290,7 @@ func (r *inboxMultiplexer) Pop(ctx context.Context) (*arbostypes.MessageWithMeta
 		}
 		r.cachedSequencerMessageNum = r.backend.GetSequencerInboxPosition()
 		var err error
-		r.cachedSequencerMessage, err = parseSequencerMessage(ctx, r.cachedSequencerMessageNum, bytes, r.dasReader, r.keysetValidationMode)
+		r.cachedSequencerMessage, err = parseSequencerMessage(ctx, r.cachedSequencerMessageNum, bytes, r.dasReader, r.codecsEnabled, r.keysetValidationMode)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,42 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbstate
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/offchainlabs/nitro/arbcompress"
+)
+
+func TestParseCodecSequencerMessage(t *testing.T) {
+	ctx := context.Background()
+	segment := append([]byte{BatchSegmentKindL2Message}, []byte("message")...)
+	encoded, err := rlp.EncodeToBytes(segment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, 40, 42+len(encoded))
+	data = append(data, CodecMessageHeaderFlag, byte(arbcompress.CodecNone))
+	data = append(data, encoded...)
+
+	parsed, err := parseSequencerMessage(ctx, 0, data, nil, true, KeysetValidate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.segments) != 1 || !bytes.Equal(parsed.segments[0], segment) {
+		t.Errorf("got segments %v, expected %v", parsed.segments, [][]byte{segment})
+	}
+
+	parsed, err = parseSequencerMessage(ctx, 0, data, nil, false, KeysetValidate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.segments) != 0 {
+		t.Errorf("parsed %v segments from a codec batch on a chain without codecs", len(parsed.segments))
+	}
+}
@@ -45,6 +45,9 @@ type FeedConfig struct {
 }
 
 func (fc *FeedConfig) Validate() error {
+	if err := fc.Input.Arbiter.Validate(); err != nil {
+		return err
+	}
 	return fc.Output.Validate()
 }
 
@@ -71,6 +74,8 @@ type Config struct {
 	URL                     []string                 `koanf:"url"`
 	Verify                  signature.VerifierConfig `koanf:"verify"`
 	EnableCompression       bool                     `koanf:"enable-compression" reload:"hot"`
+	EnableBinary            bool                     `koanf:"enable-binary" reload:"hot"`
+	Arbiter                 ArbiterConfig            `koanf:"arbiter" reload:"hot"`
 }
 
 func (c *Config) Enable() bool {
@@ -88,6 +93,8 @@ func ConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.StringSlice(prefix+".url", DefaultConfig.URL, "URL of sequencer feed source")
 	signature.FeedVerifierConfigAddOptions(prefix+".verify", f)
 	f.Bool(prefix+".enable-compression", DefaultConfig.EnableCompression, "enable per message deflate compression support")
+	f.Bool(prefix+".enable-binary", DefaultConfig.EnableBinary, "request messages in the binary encoding rather than JSON")
+	ArbiterConfigAddOptions(prefix+".arbiter", f)
 }
 
 var DefaultConfig = Config{
@@ -99,6 +106,8 @@ var DefaultConfig = Config{
 	URL:                     []string{""},
 	Timeout:                 20 * time.Second,
 	EnableCompression:       true,
+	EnableBinary:            false,
+	Arbiter:                 DefaultArbiterConfig,
 }
 
 var DefaultTestConfig = Config{
@@ -110,6 +119,53 @@ var DefaultTestConfig = Config{
 	URL:                     []string{""},
 	Timeout:                 200 * time.Millisecond,
 	EnableCompression:       true,
+	EnableBinary:            false,
+	Arbiter:                 DefaultArbiterConfig,
+}
+
+// ArbiterConfig configures merging the messages from multiple feeds before
+// they reach the transaction streamer. The first PrimaryFeeds URLs are
+// primaries, and the rest are only used while no primary is delivering. If
+// PrimaryFeeds is 0 every feed is a primary, so each message is taken from
+// whichever feed delivers it first.
+type ArbiterConfig struct {
+	Enable          bool          `koanf:"enable"`
+	PrimaryFeeds    int           `koanf:"primary-feeds" reload:"hot"`
+	FailoverTimeout time.Duration `koanf:"failover-timeout" reload:"hot"`
+	DedupWindow     uint64        `koanf:"dedup-window"`
+	MaxLag          uint64        `koanf:"max-lag" reload:"hot"`
+}
+
+func (c *ArbiterConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.PrimaryFeeds < 0 {
+		return errors.New("feed arbiter primary-feeds must not be negative")
+	}
+	if c.FailoverTimeout <= 0 {
+		return errors.New("feed arbiter failover-timeout must be positive")
+	}
+	if c.DedupWindow == 0 {
+		return errors.New("feed arbiter dedup-window must be positive")
+	}
+	return nil
+}
+
+func ArbiterConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultArbiterConfig.Enable, "merge and deduplicate messages from all feed URLs before processing them")
+	f.Int(prefix+".primary-feeds", DefaultArbiterConfig.PrimaryFeeds, "number of feed URLs, from the start of the list, that are primaries; the rest are only used while no primary delivers messages (0 means all feeds are primaries)")
+	f.Duration(prefix+".failover-timeout", DefaultArbiterConfig.FailoverTimeout, "duration without a message from any primary feed before failing over to the secondary feeds")
+	f.Uint64(prefix+".dedup-window", DefaultArbiterConfig.DedupWindow, "number of recent sequence numbers to remember for detecting duplicate and conflicting messages, and of messages from secondary feeds kept in case of failing over")
+	f.Uint64(prefix+".max-lag", DefaultArbiterConfig.MaxLag, "number of messages a feed can be behind the latest message before it's reported as unhealthy (0 means no limit)")
+}
+
+var DefaultArbiterConfig = ArbiterConfig{
+	Enable:          false,
+	PrimaryFeeds:    1,
+	FailoverTimeout: 10 * time.Second,
+	DedupWindow:     10000,
+	MaxLag:          100,
 }
 
 type TransactionStreamerInterface interface {
@@ -215,18 +271,23 @@ func (bc *BroadcastClient) connect(ctx context.Context, nextSeqNum arbutil.Messa
 		return nil, nil
 	}
 
-	header := ws.HandshakeHeaderHTTP(http.Header{
+	config := bc.config()
+	httpHeader := http.Header{
 		wsbroadcastserver.HTTPHeaderFeedClientVersion:       []string{strconv.Itoa(wsbroadcastserver.FeedClientVersion)},
 		wsbroadcastserver.HTTPHeaderRequestedSequenceNumber: []string{strconv.FormatUint(uint64(nextSeqNum), 10)},
-	})
+	}
+	if config.EnableBinary {
+		httpHeader[wsbroadcastserver.HTTPHeaderFeedEncoding] = []string{wsbroadcastserver.FeedEncodingBinary}
+	}
+	header := ws.HandshakeHeaderHTTP(httpHeader)
 
 	log.Info("connecting to arbitrum inbox message broadcaster", "url", bc.websocketUrl)
 	var foundChainId bool
 	var foundFeedServerVersion bool
 	var chainId uint64
 	var feedServerVersion uint64
+	feedEncoding := wsbroadcastserver.FeedEncodingJSON
 
-	config := bc.config()
 	var extensions []httphead.Option
 	deflateExt := wsflate.DefaultParameters.Option()
 	if config.EnableCompression {
@@ -269,6 +330,8 @@ func (bc *BroadcastClient) connect(ctx context.Context, nextSeqNum arbutil.Messa
 					)
 					return ErrIncorrectChainId
 				}
+			} else if headerName == wsbroadcastserver.HTTPHeaderFeedEncoding {
+				feedEncoding = headerValue
 			}
 			return nil
 		},
@@ -320,7 +383,7 @@ func (bc *BroadcastClient) connect(ctx context.Context, nextSeqNum arbutil.Messa
 	bc.connMutex.Lock()
 	bc.conn = conn
 	bc.connMutex.Unlock()
-	log.Info("Feed connected", "feedServerVersion", feedServerVersion, "chainId", chainId, "requestedSeqNum", nextSeqNum)
+	log.Info("Feed connected", "feedServerVersion", feedServerVersion, "chainId", chainId, "requestedSeqNum", nextSeqNum, "encoding", feedEncoding)
 
 	return earlyFrameData, nil
 }
@@ -378,7 +441,13 @@ func (bc *BroadcastClient) startBackgroundReader(earlyFrameData io.Reader) {
 
 			if msg != nil {
 				res := broadcaster.BroadcastMessage{}
-				err = json.Unmarshal(msg, &res)
+				// Servers which don't support the binary encoding send JSON,
+				// so decode according to the frame type
+				if op == ws.OpBinary {
+					err = res.UnmarshalBinary(msg)
+				} else {
+					err = json.Unmarshal(msg, &res)
+				}
 				if err != nil {
 					log.Error("error unmarshalling message", "msg", msg, "err", err)
 					continue
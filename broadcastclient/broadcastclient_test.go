@@ -112,6 +112,64 @@ func testReceiveMessages(t *testing.T, clientCompression bool, serverCompression
 
 }
 
+func TestReceiveMessagesWithBinaryEncoding(t *testing.T) {
+	t.Parallel()
+	testReceiveBinaryMessages(t, false, true)
+}
+
+func TestReceiveMessagesWithBinaryEncodingAndCompression(t *testing.T) {
+	t.Parallel()
+	testReceiveBinaryMessages(t, true, true)
+}
+
+func TestReceiveMessagesWithBinaryEncodingDisabledOnServer(t *testing.T) {
+	t.Parallel()
+	testReceiveBinaryMessages(t, true, false)
+}
+
+// testReceiveBinaryMessages connects a JSON client and a binary client to the
+// same server, which both need to receive all the messages.
+func testReceiveBinaryMessages(t *testing.T, compression bool, serverBinary bool) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	broadcasterConfig := wsbroadcastserver.DefaultTestBroadcasterConfig
+	broadcasterConfig.EnableCompression = compression
+	broadcasterConfig.EnableBinary = serverBinary
+
+	messageCount := 1000
+	chainId := uint64(9742)
+
+	privateKey, err := crypto.GenerateKey()
+	Require(t, err)
+	sequencerAddr := crypto.PubkeyToAddress(privateKey.PublicKey)
+	dataSigner := signature.DataSignerFromPrivateKey(privateKey)
+
+	feedErrChan := make(chan error, 10)
+	b := broadcaster.NewBroadcaster(func() *wsbroadcastserver.BroadcasterConfig { return &broadcasterConfig }, chainId, feedErrChan, dataSigner)
+
+	Require(t, b.Initialize())
+	Require(t, b.Start(ctx))
+	defer b.StopAndWait()
+
+	var wg sync.WaitGroup
+	for i, enableBinary := range []bool{false, true} {
+		config := DefaultTestConfig
+		config.EnableCompression = compression
+		config.EnableBinary = enableBinary
+		startMakeBroadcastClient(ctx, t, config, b.ListenerAddr(), i, messageCount, chainId, &wg, &sequencerAddr)
+	}
+
+	go func() {
+		for i := 0; i < messageCount; i++ {
+			Require(t, b.BroadcastSingle(arbostypes.TestMessageWithMetadataAndRequestId, arbutil.MessageIndex(i)))
+		}
+	}()
+
+	wg.Wait()
+}
+
 func TestInvalidSignature(t *testing.T) {
 	t.Parallel()
 	ctx, cancel := context.WithCancel(context.Background())
@@ -0,0 +1,336 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package broadcastclients
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/broadcastclient"
+	"github.com/offchainlabs/nitro/broadcaster"
+)
+
+var (
+	arbiterFailedOverGauge = metrics.NewRegisteredGauge("arb/feed/arbiter/failedover", nil)
+	arbiterForwardedCount  = metrics.NewRegisteredCounter("arb/feed/arbiter/forwarded", nil)
+	arbiterConflictCount   = metrics.NewRegisteredCounter("arb/feed/arbiter/conflicts", nil)
+)
+
+// arbiter merges the messages from several feeds by sequence number, so that
+// each message only reaches the transaction streamer once. Messages from
+// secondary feeds are only forwarded while no primary feed is delivering;
+// until then the ones the primaries haven't delivered yet are kept, so
+// failing over doesn't leave a gap.
+type arbiter struct {
+	config     func() *broadcastclient.ArbiterConfig
+	chainId    uint64
+	txStreamer broadcastclient.TransactionStreamerInterface
+	feeds      []*arbiterFeed
+	created    time.Time
+
+	mutex              sync.Mutex
+	forwarded          map[arbutil.MessageIndex]common.Hash
+	highestForwarded   arbutil.MessageIndex
+	pending            map[arbutil.MessageIndex]arbiterMessage
+	toForward          [][]*broadcaster.BroadcastFeedMessage
+	lastPrimaryMessage time.Time
+	lastMessage        time.Time
+	failedOver         bool
+
+	// Held while forwarding to the transaction streamer, which needs messages
+	// in order, so the mutex doesn't have to be.
+	forwardMutex sync.Mutex
+}
+
+// arbiterMessage is a message received from a feed.
+type arbiterMessage struct {
+	feed    *arbiterFeed
+	message *broadcaster.BroadcastFeedMessage
+	hash    common.Hash
+}
+
+// arbiterFeed is the transaction streamer given to a single feed's client.
+type arbiterFeed struct {
+	arbiter *arbiter
+	index   int
+	url     string
+
+	// Protected by the arbiter's mutex
+	received     bool
+	latestSeqNum arbutil.MessageIndex
+	lastMessage  time.Time
+	lag          int64
+	drops        uint64
+	conflicts    uint64
+
+	lagGauge        metrics.Gauge
+	dropCounter     metrics.Counter
+	conflictCounter metrics.Counter
+}
+
+func newArbiter(config func() *broadcastclient.ArbiterConfig, chainId uint64, txStreamer broadcastclient.TransactionStreamerInterface) *arbiter {
+	now := time.Now()
+	return &arbiter{
+		config:     config,
+		chainId:    chainId,
+		txStreamer: txStreamer,
+		created:    now,
+		forwarded:  make(map[arbutil.MessageIndex]common.Hash),
+		pending:    make(map[arbutil.MessageIndex]arbiterMessage),
+		// Give the primaries a chance to connect before failing over
+		lastPrimaryMessage: now,
+	}
+}
+
+func (a *arbiter) addFeed(url string) *arbiterFeed {
+	index := len(a.feeds)
+	metricPrefix := fmt.Sprintf("arb/feed/arbiter/feed/%d/", index)
+	feed := &arbiterFeed{
+		arbiter:         a,
+		index:           index,
+		url:             url,
+		lagGauge:        metrics.GetOrRegisterGauge(metricPrefix+"lag", nil),
+		dropCounter:     metrics.GetOrRegisterCounter(metricPrefix+"drops", nil),
+		conflictCounter: metrics.GetOrRegisterCounter(metricPrefix+"conflicts", nil),
+	}
+	a.feeds = append(a.feeds, feed)
+	return feed
+}
+
+func (f *arbiterFeed) AddBroadcastMessages(feedMessages []*broadcaster.BroadcastFeedMessage) error {
+	return f.arbiter.addMessages(f, feedMessages, time.Now())
+}
+
+func (f *arbiterFeed) drop() {
+	f.drops++
+	f.dropCounter.Inc(1)
+}
+
+func (a *arbiter) isPrimary(feed *arbiterFeed) bool {
+	primaryFeeds := a.config().PrimaryFeeds
+	return primaryFeeds == 0 || feed.index < primaryFeeds
+}
+
+// updateFailover records that feed delivered messages at now, and returns
+// whether the messages it delivered should be forwarded.
+func (a *arbiter) updateFailover(feed *arbiterFeed, now time.Time) bool {
+	if a.isPrimary(feed) {
+		a.lastPrimaryMessage = now
+		if a.failedOver {
+			log.Info("primary feed is delivering again, stopping use of secondary feeds", "url", feed.url)
+			a.failedOver = false
+			arbiterFailedOverGauge.Update(0)
+		}
+		return true
+	}
+	if !a.failedOver && now.Sub(a.lastPrimaryMessage) > a.config().FailoverTimeout {
+		log.Warn("no message from any primary feed, failing over to secondary feeds", "since", a.lastPrimaryMessage)
+		a.failedOver = true
+		arbiterFailedOverGauge.Update(1)
+	}
+	return a.failedOver
+}
+
+func (a *arbiter) addMessages(feed *arbiterFeed, feedMessages []*broadcaster.BroadcastFeedMessage, now time.Time) error {
+	if len(feedMessages) == 0 {
+		return nil
+	}
+	a.mutex.Lock()
+	active := a.updateFailover(feed, now)
+	feed.lastMessage = now
+	a.lastMessage = now
+	dedupWindow := arbutil.MessageIndex(a.config().DedupWindow)
+	var received []arbiterMessage
+	for _, message := range feedMessages {
+		if !feed.received || message.SequenceNumber > feed.latestSeqNum {
+			feed.latestSeqNum = message.SequenceNumber
+			feed.received = true
+		}
+		hash, err := message.Hash(a.chainId)
+		if err != nil {
+			log.Warn("dropping feed message which can't be hashed", "url", feed.url, "sequenceNumber", message.SequenceNumber, "err", err)
+			feed.drop()
+			continue
+		}
+		received = append(received, arbiterMessage{feed: feed, message: message, hash: hash})
+	}
+	if active {
+		// Messages kept from before failing over come first, as they're older
+		received = append(a.takePending(), received...)
+	}
+
+	var run []*broadcaster.BroadcastFeedMessage
+	for _, m := range received {
+		if !a.checkNew(m, dedupWindow) {
+			m.feed.drop()
+			continue
+		}
+		if !active {
+			a.keepPending(m, dedupWindow)
+			continue
+		}
+		a.forwarded[m.message.SequenceNumber] = m.hash
+		if m.message.SequenceNumber > a.highestForwarded {
+			a.highestForwarded = m.message.SequenceNumber
+		}
+		// The transaction streamer requires consecutive sequence numbers
+		if len(run) > 0 && run[len(run)-1].SequenceNumber+1 != m.message.SequenceNumber {
+			a.toForward = append(a.toForward, run)
+			run = nil
+		}
+		run = append(run, m.message)
+	}
+	if len(run) > 0 {
+		a.toForward = append(a.toForward, run)
+	}
+
+	a.updateLag()
+	a.prune(dedupWindow)
+	a.mutex.Unlock()
+
+	return a.forward()
+}
+
+// checkNew returns whether the message hasn't been forwarded yet, and isn't
+// too old to tell. It must be called with the mutex held.
+func (a *arbiter) checkNew(m arbiterMessage, dedupWindow arbutil.MessageIndex) bool {
+	seqNum := m.message.SequenceNumber
+	forwardedHash, seen := a.forwarded[seqNum]
+	if seen && forwardedHash != m.hash {
+		log.Error(
+			"feed sent a message conflicting with one already processed",
+			"url", m.feed.url,
+			"sequenceNumber", seqNum,
+			"hash", m.hash,
+			"processedHash", forwardedHash,
+		)
+		m.feed.conflicts++
+		m.feed.conflictCounter.Inc(1)
+		arbiterConflictCount.Inc(1)
+	}
+	tooOld := seqNum+dedupWindow < a.highestForwarded
+	return !seen && !tooOld
+}
+
+// keepPending keeps a message from a secondary feed which isn't in use, to
+// forward if the arbiter fails over to it. At most the dedup window's worth of
+// messages are kept, which are the ones right after the latest forwarded one.
+// It must be called with the mutex held.
+func (a *arbiter) keepPending(m arbiterMessage, dedupWindow arbutil.MessageIndex) {
+	seqNum := m.message.SequenceNumber
+	if _, ok := a.pending[seqNum]; ok || seqNum < a.highestForwarded || seqNum > a.highestForwarded+dedupWindow {
+		m.feed.drop()
+		return
+	}
+	a.pending[seqNum] = m
+}
+
+// takePending returns the kept messages which haven't been forwarded since,
+// ordered by sequence number, and forgets them. It must be called with the
+// mutex held.
+func (a *arbiter) takePending() []arbiterMessage {
+	if len(a.pending) == 0 {
+		return nil
+	}
+	pending := make([]arbiterMessage, 0, len(a.pending))
+	for _, m := range a.pending {
+		pending = append(pending, m)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].message.SequenceNumber < pending[j].message.SequenceNumber
+	})
+	a.pending = make(map[arbutil.MessageIndex]arbiterMessage)
+	return pending
+}
+
+// forward passes the messages queued to be forwarded to the transaction
+// streamer, in the order they were queued, without holding the mutex.
+func (a *arbiter) forward() error {
+	a.forwardMutex.Lock()
+	defer a.forwardMutex.Unlock()
+	a.mutex.Lock()
+	toForward := a.toForward
+	a.toForward = nil
+	a.mutex.Unlock()
+	for _, messages := range toForward {
+		arbiterForwardedCount.Inc(int64(len(messages)))
+		if err := a.txStreamer.AddBroadcastMessages(messages); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateLag updates how far behind the latest forwarded message each feed is.
+func (a *arbiter) updateLag() {
+	for _, feed := range a.feeds {
+		if !feed.received {
+			continue
+		}
+		feed.lag = 0
+		if feed.latestSeqNum < a.highestForwarded {
+			feed.lag = int64(a.highestForwarded - feed.latestSeqNum)
+		}
+		feed.lagGauge.Update(feed.lag)
+	}
+}
+
+// feedHealth is what the arbiter knows of a feed's health.
+type feedHealth struct {
+	received    bool
+	lastMessage time.Time
+	lag         int64
+	healthy     bool
+}
+
+// health reports a feed as healthy unless it has been silent for longer than
+// the failover timeout while other feeds delivered, or is lagging by more
+// than the maximum lag. A quiet chain doesn't make feeds unhealthy.
+func (a *arbiter) health(feed *arbiterFeed) feedHealth {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	config := a.config()
+	lastMessage := feed.lastMessage
+	if !feed.received {
+		lastMessage = a.created
+	}
+	healthy := a.lastMessage.Sub(lastMessage) <= config.FailoverTimeout
+	if config.MaxLag > 0 && feed.lag > int64(config.MaxLag) {
+		healthy = false
+	}
+	return feedHealth{
+		received:    feed.received,
+		lastMessage: feed.lastMessage,
+		lag:         feed.lag,
+		healthy:     healthy,
+	}
+}
+
+// prune forgets kept messages which have since been forwarded, and forwarded
+// messages older than the dedup window, once there are twice as many as needed
+// so the cost of pruning is amortized.
+func (a *arbiter) prune(dedupWindow arbutil.MessageIndex) {
+	for seqNum, m := range a.pending {
+		if _, forwarded := a.forwarded[seqNum]; forwarded || seqNum < a.highestForwarded {
+			m.feed.drop()
+			delete(a.pending, seqNum)
+		}
+	}
+	if arbutil.MessageIndex(len(a.forwarded)) <= 2*dedupWindow || a.highestForwarded < dedupWindow {
+		return
+	}
+	oldest := a.highestForwarded - dedupWindow
+	for seqNum := range a.forwarded {
+		if seqNum < oldest {
+			delete(a.forwarded, seqNum)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package broadcastclients
+
+import (
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/broadcastclient"
+	"github.com/offchainlabs/nitro/broadcaster"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+type recordingTxStreamer struct {
+	batches [][]arbutil.MessageIndex
+	onAdd   func()
+}
+
+func (s *recordingTxStreamer) AddBroadcastMessages(feedMessages []*broadcaster.BroadcastFeedMessage) error {
+	if s.onAdd != nil {
+		s.onAdd()
+	}
+	var batch []arbutil.MessageIndex
+	for _, message := range feedMessages {
+		batch = append(batch, message.SequenceNumber)
+	}
+	s.batches = append(s.batches, batch)
+	return nil
+}
+
+func (s *recordingTxStreamer) forwarded() []arbutil.MessageIndex {
+	var all []arbutil.MessageIndex
+	for _, batch := range s.batches {
+		all = append(all, batch...)
+	}
+	return all
+}
+
+func feedMessages(delayedMessagesRead uint64, seqNums ...arbutil.MessageIndex) []*broadcaster.BroadcastFeedMessage {
+	var messages []*broadcaster.BroadcastFeedMessage
+	for _, seqNum := range seqNums {
+		message := arbostypes.TestMessageWithMetadataAndRequestId
+		message.DelayedMessagesRead = delayedMessagesRead
+		messages = append(messages, &broadcaster.BroadcastFeedMessage{
+			SequenceNumber: seqNum,
+			Message:        message,
+		})
+	}
+	return messages
+}
+
+func newTestArbiter(config *broadcastclient.ArbiterConfig, feedCount int) (*arbiter, *recordingTxStreamer) {
+	txStreamer := &recordingTxStreamer{}
+	a := newArbiter(func() *broadcastclient.ArbiterConfig { return config }, 42161, txStreamer)
+	for i := 0; i < feedCount; i++ {
+		a.addFeed("ws://feed")
+	}
+	return a, txStreamer
+}
+
+func expectForwarded(t *testing.T, txStreamer *recordingTxStreamer, expected ...arbutil.MessageIndex) {
+	t.Helper()
+	forwarded := txStreamer.forwarded()
+	if len(forwarded) != len(expected) {
+		Fail(t, "forwarded", forwarded, "expected", expected)
+	}
+	for i := range expected {
+		if forwarded[i] != expected[i] {
+			Fail(t, "forwarded", forwarded, "expected", expected)
+		}
+	}
+}
+
+func TestArbiterDeduplicates(t *testing.T) {
+	config := broadcastclient.DefaultArbiterConfig
+	config.PrimaryFeeds = 2
+	a, txStreamer := newTestArbiter(&config, 2)
+	now := time.Now()
+
+	Require(t, a.addMessages(a.feeds[0], feedMessages(0, 10, 11), now))
+	Require(t, a.addMessages(a.feeds[1], feedMessages(0, 10, 11, 12, 13), now))
+	Require(t, a.addMessages(a.feeds[0], feedMessages(0, 12, 13, 14), now))
+	expectForwarded(t, txStreamer, 10, 11, 12, 13, 14)
+	if len(txStreamer.batches) != 3 {
+		Fail(t, "unexpected batches", txStreamer.batches)
+	}
+	if drops := a.feeds[1].drops; drops != 2 {
+		Fail(t, "feed 1 dropped", drops, "messages, expected 2")
+	}
+	if lag := a.feeds[1].lag; lag != 1 {
+		Fail(t, "feed 1 lag is", lag, "expected 1")
+	}
+}
+
+func TestArbiterSplitsNonConsecutiveMessages(t *testing.T) {
+	config := broadcastclient.DefaultArbiterConfig
+	config.PrimaryFeeds = 2
+	a, txStreamer := newTestArbiter(&config, 2)
+	now := time.Now()
+
+	Require(t, a.addMessages(a.feeds[0], feedMessages(0, 11), now))
+	Require(t, a.addMessages(a.feeds[1], feedMessages(0, 10, 11, 12), now))
+	expectForwarded(t, txStreamer, 11, 10, 12)
+	if len(txStreamer.batches) != 3 {
+		Fail(t, "unexpected batches", txStreamer.batches)
+	}
+}
+
+func TestArbiterDetectsConflicts(t *testing.T) {
+	config := broadcastclient.DefaultArbiterConfig
+	config.PrimaryFeeds = 2
+	a, txStreamer := newTestArbiter(&config, 2)
+	now := time.Now()
+
+	Require(t, a.addMessages(a.feeds[0], feedMessages(0, 10), now))
+	Require(t, a.addMessages(a.feeds[1], feedMessages(1, 10), now))
+	expectForwarded(t, txStreamer, 10)
+	if conflicts := a.feeds[1].conflicts; conflicts != 1 {
+		Fail(t, "feed 1 had", conflicts, "conflicts, expected 1")
+	}
+	if conflicts := a.feeds[0].conflicts; conflicts != 0 {
+		Fail(t, "feed 0 had", conflicts, "conflicts, expected 0")
+	}
+}
+
+func TestArbiterFailover(t *testing.T) {
+	config := broadcastclient.DefaultArbiterConfig
+	config.PrimaryFeeds = 1
+	config.FailoverTimeout = time.Minute
+	a, txStreamer := newTestArbiter(&config, 2)
+	start := a.lastPrimaryMessage
+
+	// The secondary is ignored while the primary is within the timeout
+	Require(t, a.addMessages(a.feeds[1], feedMessages(0, 10), start.Add(time.Second)))
+	Require(t, a.addMessages(a.feeds[0], feedMessages(0, 10), start.Add(2*time.Second)))
+	Require(t, a.addMessages(a.feeds[1], feedMessages(0, 11), start.Add(time.Minute)))
+	expectForwarded(t, txStreamer, 10)
+
+	// Once the primary is silent for longer than the timeout the secondary is used
+	Require(t, a.addMessages(a.feeds[1], feedMessages(0, 11, 12), start.Add(2*time.Minute)))
+	expectForwarded(t, txStreamer, 10, 11, 12)
+	if !a.failedOver {
+		Fail(t, "expected to have failed over to the secondary feed")
+	}
+
+	// The primary is used again as soon as it delivers
+	Require(t, a.addMessages(a.feeds[0], feedMessages(0, 12, 13), start.Add(3*time.Minute)))
+	Require(t, a.addMessages(a.feeds[1], feedMessages(0, 14), start.Add(3*time.Minute)))
+	expectForwarded(t, txStreamer, 10, 11, 12, 13)
+	if a.failedOver {
+		Fail(t, "expected to have stopped using the secondary feed")
+	}
+}
+
+func TestArbiterKeepsSecondaryMessagesUntilFailover(t *testing.T) {
+	config := broadcastclient.DefaultArbiterConfig
+	config.PrimaryFeeds = 1
+	config.FailoverTimeout = time.Minute
+	config.DedupWindow = 4
+	a, txStreamer := newTestArbiter(&config, 2)
+	start := a.lastPrimaryMessage
+
+	// The secondary is ahead of the primary when the primary goes silent
+	Require(t, a.addMessages(a.feeds[0], feedMessages(0, 10), start.Add(time.Second)))
+	Require(t, a.addMessages(a.feeds[1], feedMessages(0, 10, 11, 12), start.Add(2*time.Second)))
+	Require(t, a.addMessages(a.feeds[1], feedMessages(0, 13, 14, 15, 16), start.Add(3*time.Second)))
+	expectForwarded(t, txStreamer, 10)
+	// Only the dedup window's worth of messages after the latest forwarded one are kept
+	if len(a.pending) != 4 {
+		Fail(t, "keeping", len(a.pending), "messages with a dedup window of", config.DedupWindow)
+	}
+
+	// Failing over forwards the kept messages before the new ones, without a gap
+	Require(t, a.addMessages(a.feeds[1], feedMessages(0, 15), start.Add(2*time.Minute)))
+	expectForwarded(t, txStreamer, 10, 11, 12, 13, 14, 15)
+	if len(a.pending) != 0 {
+		Fail(t, "still keeping", len(a.pending), "messages after failing over")
+	}
+}
+
+func TestArbiterForwardsWithoutLock(t *testing.T) {
+	config := broadcastclient.DefaultArbiterConfig
+	a, txStreamer := newTestArbiter(&config, 1)
+	txStreamer.onAdd = func() {
+		// Would deadlock if the arbiter's lock was held
+		a.health(a.feeds[0])
+	}
+	Require(t, a.addMessages(a.feeds[0], feedMessages(0, 10), time.Now()))
+	expectForwarded(t, txStreamer, 10)
+}
+
+func TestArbiterAllPrimaries(t *testing.T) {
+	config := broadcastclient.DefaultArbiterConfig
+	config.PrimaryFeeds = 0
+	a, txStreamer := newTestArbiter(&config, 3)
+	now := time.Now()
+
+	// Each message is taken from whichever feed delivers it first
+	Require(t, a.addMessages(a.feeds[2], feedMessages(0, 10), now))
+	Require(t, a.addMessages(a.feeds[0], feedMessages(0, 10, 11), now))
+	Require(t, a.addMessages(a.feeds[1], feedMessages(0, 10, 11, 12), now))
+	expectForwarded(t, txStreamer, 10, 11, 12)
+	if a.failedOver {
+		Fail(t, "failed over without secondary feeds")
+	}
+}
+
+func TestArbiterHealth(t *testing.T) {
+	config := broadcastclient.DefaultArbiterConfig
+	config.PrimaryFeeds = 0
+	config.FailoverTimeout = time.Minute
+	config.MaxLag = 5
+	a, _ := newTestArbiter(&config, 3)
+	start := a.created
+
+	// Feeds are healthy before anything is received
+	for _, feed := range a.feeds {
+		if !a.health(feed).healthy {
+			Fail(t, "feed", feed.index, "unhealthy on startup")
+		}
+	}
+
+	var seqNums []arbutil.MessageIndex
+	for i := arbutil.MessageIndex(0); i < 10; i++ {
+		seqNums = append(seqNums, i)
+	}
+	Require(t, a.addMessages(a.feeds[0], feedMessages(0, seqNums...), start.Add(time.Second)))
+	Require(t, a.addMessages(a.feeds[1], feedMessages(0, seqNums[:3]...), start.Add(time.Second)))
+	if health := a.health(a.feeds[0]); !health.healthy || health.lag != 0 {
+		Fail(t, "up to date feed reported as", health)
+	}
+	// Feed 1 is 7 messages behind
+	if health := a.health(a.feeds[1]); health.healthy || health.lag != 7 {
+		Fail(t, "lagging feed reported as", health)
+	}
+	if !a.health(a.feeds[2]).healthy {
+		Fail(t, "silent feed unhealthy before the failover timeout")
+	}
+
+	// Feed 2 has been silent for longer than the timeout while others delivered
+	Require(t, a.addMessages(a.feeds[0], feedMessages(0, 10), start.Add(2*time.Minute)))
+	if a.health(a.feeds[2]).healthy {
+		Fail(t, "silent feed healthy after the failover timeout")
+	}
+	// Feed 0 stays healthy however long the chain is quiet
+	if !a.health(a.feeds[0]).healthy {
+		Fail(t, "feed unhealthy while the chain is quiet")
+	}
+}
+
+func TestArbiterPrunes(t *testing.T) {
+	config := broadcastclient.DefaultArbiterConfig
+	config.DedupWindow = 10
+	a, txStreamer := newTestArbiter(&config, 1)
+	now := time.Now()
+
+	for i := arbutil.MessageIndex(0); i < 100; i++ {
+		Require(t, a.addMessages(a.feeds[0], feedMessages(0, i), now))
+	}
+	if len(a.forwarded) > 2*int(config.DedupWindow) {
+		Fail(t, "remembering", len(a.forwarded), "messages with a dedup window of", config.DedupWindow)
+	}
+	// Messages too old to be checked for duplicates are dropped
+	Require(t, a.addMessages(a.feeds[0], feedMessages(0, 5), now))
+	if forwarded := txStreamer.forwarded(); len(forwarded) != 100 {
+		Fail(t, "forwarded", len(forwarded), "messages, expected 100")
+	}
+}
+
+func Require(t *testing.T, err error, printables ...interface{}) {
+	t.Helper()
+	testhelpers.RequireImpl(t, err, printables...)
+}
+
+func Fail(t *testing.T, printables ...interface{}) {
+	t.Helper()
+	testhelpers.FailImpl(t, printables...)
+}
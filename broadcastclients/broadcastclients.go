@@ -6,6 +6,7 @@ package broadcastclients
 import (
 	"context"
 	"sync/atomic"
+	"time"
 
 	"github.com/ethereum/go-ethereum/log"
 
@@ -16,9 +17,14 @@ import (
 
 type BroadcastClients struct {
 	clients []*broadcastclient.BroadcastClient
+	urls    []string
+	// Only set if messages from the feeds are merged before being processed
+	arbiter *arbiter
+	feeds   []*arbiterFeed
 
 	// Use atomic access
-	connected int32
+	connected     int32
+	feedConnected []int32
 }
 
 func NewBroadcastClients(
@@ -38,18 +44,33 @@ func NewBroadcastClients(
 
 	clients := BroadcastClients{}
 	clients.clients = make([]*broadcastclient.BroadcastClient, 0, urlCount)
+	clients.urls = config.URL
+	clients.feedConnected = make([]int32, urlCount)
+	if config.Arbiter.Enable {
+		clients.arbiter = newArbiter(func() *broadcastclient.ArbiterConfig { return &configFetcher().Arbiter }, l2ChainId, txStreamer)
+	}
 	var lastClientErr error
-	for _, address := range config.URL {
+	for i, address := range config.URL {
+		i := i
+		clientTxStreamer := txStreamer
+		if clients.arbiter != nil {
+			feed := clients.arbiter.addFeed(address)
+			clients.feeds = append(clients.feeds, feed)
+			clientTxStreamer = feed
+		}
 		client, err := broadcastclient.NewBroadcastClient(
 			configFetcher,
 			address,
 			l2ChainId,
 			currentMessageCount,
-			txStreamer,
+			clientTxStreamer,
 			confirmedSequenceNumberListener,
 			fatalErrChan,
 			addrVerifier,
-			func(delta int32) { clients.adjustCount(delta) },
+			func(delta int32) {
+				atomic.AddInt32(&clients.feedConnected[i], delta)
+				clients.adjustCount(delta)
+			},
 		)
 		if err != nil {
 			lastClientErr = err
@@ -71,6 +92,37 @@ func (bcs *BroadcastClients) adjustCount(delta int32) {
 	}
 }
 
+// FeedHealth is the health of one of the feeds.
+type FeedHealth struct {
+	URL       string
+	Connected bool
+	// Only known if the feeds are merged by the arbiter
+	LastMessage time.Time
+	Lag         int64
+	Healthy     bool
+}
+
+// Health reports the health of each feed. If the feeds aren't merged by the
+// arbiter, connected feeds are reported as healthy.
+func (bcs *BroadcastClients) Health() []FeedHealth {
+	health := make([]FeedHealth, 0, len(bcs.urls))
+	for i, url := range bcs.urls {
+		feed := FeedHealth{
+			URL:       url,
+			Connected: atomic.LoadInt32(&bcs.feedConnected[i]) > 0,
+		}
+		feed.Healthy = feed.Connected
+		if bcs.arbiter != nil {
+			arbiterHealth := bcs.arbiter.health(bcs.feeds[i])
+			feed.LastMessage = arbiterHealth.lastMessage
+			feed.Lag = arbiterHealth.lag
+			feed.Healthy = feed.Connected && arbiterHealth.healthy
+		}
+		health = append(health, feed)
+	}
+	return health
+}
+
 func (bcs *BroadcastClients) Start(ctx context.Context) {
 	for _, client := range bcs.clients {
 		client.Start(ctx)
@@ -20,9 +20,11 @@ import (
 
 type Broadcaster struct {
 	server        *wsbroadcastserver.WSBroadcastServer
-	catchupBuffer *SequenceNumberCatchupBuffer
-	chainId       uint64
-	dataSigner    signature.DataSignerFunc
+	catchupBuffer wsbroadcastserver.CatchupBuffer
+	// Only set if the catchup buffer is kept in a database
+	persistentCatchupBuffer *PersistentCatchupBuffer
+	chainId                 uint64
+	dataSigner              signature.DataSignerFunc
 }
 
 // BroadcastMessage is the base message type for messages to send over the network.
@@ -61,12 +63,20 @@ type ConfirmedSequenceNumberMessage struct {
 }
 
 func NewBroadcaster(config wsbroadcastserver.BroadcasterConfigFetcher, chainId uint64, feedErrChan chan error, dataSigner signature.DataSignerFunc) *Broadcaster {
-	catchupBuffer := NewSequenceNumberCatchupBuffer(func() bool { return config().LimitCatchup }, func() int { return config().MaxCatchup })
+	var catchupBuffer wsbroadcastserver.CatchupBuffer
+	var persistentCatchupBuffer *PersistentCatchupBuffer
+	if config().PersistentCatchup.Enable {
+		persistentCatchupBuffer = NewPersistentCatchupBuffer(func() *wsbroadcastserver.PersistentCatchupConfig { return &config().PersistentCatchup }, func() bool { return config().LimitCatchup })
+		catchupBuffer = persistentCatchupBuffer
+	} else {
+		catchupBuffer = NewSequenceNumberCatchupBuffer(func() bool { return config().LimitCatchup }, func() int { return config().MaxCatchup })
+	}
 	return &Broadcaster{
-		server:        wsbroadcastserver.NewWSBroadcastServer(config, catchupBuffer, chainId, feedErrChan),
-		catchupBuffer: catchupBuffer,
-		chainId:       chainId,
-		dataSigner:    dataSigner,
+		server:                  wsbroadcastserver.NewWSBroadcastServer(config, catchupBuffer, newFeedFilterParser(chainId).Parse, chainId, feedErrChan),
+		catchupBuffer:           catchupBuffer,
+		persistentCatchupBuffer: persistentCatchupBuffer,
+		chainId:                 chainId,
+		dataSigner:              dataSigner,
 	}
 }
 
@@ -142,7 +152,18 @@ func (b *Broadcaster) GetCachedMessageCount() int {
 	return b.catchupBuffer.GetMessageCount()
 }
 
+// SetReadinessCheck sets the check the feed server's readiness probe fails
+// with if it returns an error. It must be called before Start.
+func (b *Broadcaster) SetReadinessCheck(check func() error) {
+	b.server.SetReadinessCheck(check)
+}
+
 func (b *Broadcaster) Initialize() error {
+	if b.persistentCatchupBuffer != nil {
+		if err := b.persistentCatchupBuffer.Initialize(); err != nil {
+			return err
+		}
+	}
 	return b.server.Initialize()
 }
 
@@ -156,6 +177,11 @@ func (b *Broadcaster) StartWithHeader(ctx context.Context, header ws.HandshakeHe
 
 func (b *Broadcaster) StopAndWait() {
 	b.server.StopAndWait()
+	if b.persistentCatchupBuffer != nil {
+		if err := b.persistentCatchupBuffer.Close(); err != nil {
+			log.Warn("error closing catchup database", "err", err)
+		}
+	}
 }
 
 func (b *Broadcaster) Started() bool {
@@ -0,0 +1,47 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package broadcaster
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// binaryBroadcastMessage is the RLP encoding of a BroadcastMessage, sent to
+// clients which negotiate the binary feed encoding. The messages are encoded
+// the same way as MessageWithMetadata is stored in the database.
+type binaryBroadcastMessage struct {
+	Version                        uint64
+	Messages                       []*BroadcastFeedMessage
+	ConfirmedSequenceNumberMessage *ConfirmedSequenceNumberMessage `rlp:"nil"`
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, which the broadcast
+// server uses for clients which negotiate the binary feed encoding.
+func (m BroadcastMessage) MarshalBinary() ([]byte, error) {
+	if m.Version < 0 {
+		return nil, errors.New("broadcast message version must not be negative")
+	}
+	return rlp.EncodeToBytes(binaryBroadcastMessage{
+		Version:                        uint64(m.Version),
+		Messages:                       m.Messages,
+		ConfirmedSequenceNumberMessage: m.ConfirmedSequenceNumberMessage,
+	})
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (m *BroadcastMessage) UnmarshalBinary(data []byte) error {
+	var decoded binaryBroadcastMessage
+	if err := rlp.DecodeBytes(data, &decoded); err != nil {
+		return err
+	}
+	m.Version = int(decoded.Version)
+	m.Messages = nil
+	if len(decoded.Messages) > 0 {
+		m.Messages = decoded.Messages
+	}
+	m.ConfirmedSequenceNumberMessage = decoded.ConfirmedSequenceNumberMessage
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package broadcaster
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+)
+
+func TestBroadcastMessageBinaryRoundTrip(t *testing.T) {
+	requestId := common.HexToHash("0x1234")
+	batchGasCost := uint64(100000)
+	for _, msg := range []BroadcastMessage{
+		{Version: 1},
+		{
+			Version: 1,
+			ConfirmedSequenceNumberMessage: &ConfirmedSequenceNumberMessage{
+				SequenceNumber: 1234,
+			},
+		},
+		{
+			Version: 1,
+			Messages: []*BroadcastFeedMessage{
+				{
+					SequenceNumber: 12345,
+					Message: arbostypes.MessageWithMetadata{
+						Message: &arbostypes.L1IncomingMessage{
+							Header: &arbostypes.L1IncomingMessageHeader{
+								Kind:        arbostypes.L1MessageType_BatchPostingReport,
+								Poster:      common.HexToAddress("0xa4b000000000000000000073657175656e636572"),
+								BlockNumber: 17000000,
+								Timestamp:   1680000000,
+								RequestId:   &requestId,
+								L1BaseFee:   big.NewInt(30000000000),
+							},
+							L2msg:        []byte{0xde, 0xad, 0xbe, 0xef},
+							BatchGasCost: &batchGasCost,
+						},
+						DelayedMessagesRead: 3333,
+					},
+					Signature: []byte{1, 2, 3},
+				},
+				{
+					SequenceNumber: 12346,
+					Message: arbostypes.MessageWithMetadata{
+						Message: &arbostypes.L1IncomingMessage{
+							Header: &arbostypes.L1IncomingMessageHeader{
+								L1BaseFee: big.NewInt(0),
+							},
+							L2msg: []byte{},
+						},
+						DelayedMessagesRead: 3333,
+					},
+					Signature: []byte{},
+				},
+			},
+			ConfirmedSequenceNumberMessage: &ConfirmedSequenceNumberMessage{
+				SequenceNumber: 12000,
+			},
+		},
+	} {
+		data, err := msg.MarshalBinary()
+		Require(t, err)
+		var decoded BroadcastMessage
+		Require(t, decoded.UnmarshalBinary(data))
+		if !reflect.DeepEqual(msg, decoded) {
+			Fail(t, "decoded message", decoded, "differs from original", msg)
+		}
+		for i, feedMessage := range msg.Messages {
+			expected, err := feedMessage.Hash(42161)
+			Require(t, err)
+			actual, err := decoded.Messages[i].Hash(42161)
+			Require(t, err)
+			if actual != expected {
+				Fail(t, "message", i, "has hash", actual, "after decoding, expected", expected)
+			}
+		}
+	}
+}
+
+func TestBroadcastMessageBinaryRejectsGarbage(t *testing.T) {
+	var decoded BroadcastMessage
+	if err := decoded.UnmarshalBinary([]byte(`{"version":1}`)); err == nil {
+		Fail(t, "decoded JSON as a binary message")
+	}
+}
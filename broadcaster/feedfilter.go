@@ -0,0 +1,211 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package broadcaster
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbos"
+	"github.com/offchainlabs/nitro/util/containers"
+	"github.com/offchainlabs/nitro/wsbroadcastserver"
+)
+
+const (
+	maxFeedFilterEntries = 256
+	// Parsed transactions are cached so that clients with different filters
+	// don't each parse the same messages.
+	feedFilterTxCacheSize = 1024
+)
+
+// FeedFilter is the filter a client can subscribe with by sending it as JSON
+// in the wsbroadcastserver.HTTPHeaderFeedFilter header, for instance
+// {"addresses":["0x..."],"selectors":["0xa9059cbb"]}. The client is then only
+// sent the feed messages with an L2 transaction sent from or to one of the
+// addresses, and calling one of the selectors. An empty list matches
+// everything. Confirmations are always sent so the client can track progress.
+//
+// As messages are skipped, filtered subscriptions can't be used to follow
+// the chain.
+type FeedFilter struct {
+	Addresses []common.Address `json:"addresses,omitempty"`
+	Selectors []hexutil.Bytes  `json:"selectors,omitempty"`
+}
+
+type feedFilterTx struct {
+	from     common.Address
+	to       *common.Address
+	selector [4]byte
+	hasData  bool
+}
+
+// feedFilterParser parses client filters and holds the state they share.
+type feedFilterParser struct {
+	chainId *big.Int
+	signer  types.Signer
+
+	txCacheMutex sync.Mutex
+	txCache      *containers.LruCache[*BroadcastFeedMessage, []feedFilterTx]
+}
+
+func newFeedFilterParser(chainId uint64) *feedFilterParser {
+	chainIdBig := new(big.Int).SetUint64(chainId)
+	return &feedFilterParser{
+		chainId: chainIdBig,
+		signer:  types.NewArbitrumSigner(types.LatestSignerForChainID(chainIdBig)),
+		txCache: containers.NewLruCache[*BroadcastFeedMessage, []feedFilterTx](feedFilterTxCacheSize),
+	}
+}
+
+func (p *feedFilterParser) Parse(value []byte) (wsbroadcastserver.MessageFilter, error) {
+	var spec FeedFilter
+	if err := json.Unmarshal(value, &spec); err != nil {
+		return nil, err
+	}
+	if len(spec.Addresses) == 0 && len(spec.Selectors) == 0 {
+		return nil, errors.New("filter has no addresses or selectors")
+	}
+	if len(spec.Addresses)+len(spec.Selectors) > maxFeedFilterEntries {
+		return nil, fmt.Errorf("filter has more than %d entries", maxFeedFilterEntries)
+	}
+	filter := &feedFilter{parser: p}
+	// The key lists the addresses and selectors in order, so that filters
+	// matching the same transactions have the same key
+	var keyAddresses, keySelectors []string
+	if len(spec.Addresses) > 0 {
+		filter.addresses = make(map[common.Address]struct{}, len(spec.Addresses))
+		for _, addr := range spec.Addresses {
+			if _, ok := filter.addresses[addr]; !ok {
+				keyAddresses = append(keyAddresses, addr.Hex())
+			}
+			filter.addresses[addr] = struct{}{}
+		}
+	}
+	if len(spec.Selectors) > 0 {
+		filter.selectors = make(map[[4]byte]struct{}, len(spec.Selectors))
+		for _, selector := range spec.Selectors {
+			if len(selector) != 4 {
+				return nil, fmt.Errorf("selector %v isn't 4 bytes", selector)
+			}
+			if _, ok := filter.selectors[*(*[4]byte)(selector)]; !ok {
+				keySelectors = append(keySelectors, selector.String())
+			}
+			filter.selectors[*(*[4]byte)(selector)] = struct{}{}
+		}
+	}
+	sort.Strings(keyAddresses)
+	sort.Strings(keySelectors)
+	filter.key = strings.Join(keyAddresses, ",") + "/" + strings.Join(keySelectors, ",")
+	return filter, nil
+}
+
+// transactions returns what filters match on for the L2 transactions of a
+// message. Messages which fail to parse have no transactions.
+func (p *feedFilterParser) transactions(message *BroadcastFeedMessage) []feedFilterTx {
+	p.txCacheMutex.Lock()
+	defer p.txCacheMutex.Unlock()
+	if txs, ok := p.txCache.Get(message); ok {
+		return txs
+	}
+	var txs []feedFilterTx
+	if message.Message.Message != nil {
+		// The batch is only needed to compute gas for batch posting reports,
+		// which doesn't matter for filtering
+		noBatchFetcher := func(uint64, common.Hash) []byte { return nil }
+		parsed, err := arbos.ParseL2Transactions(message.Message.Message, p.chainId, noBatchFetcher)
+		if err != nil {
+			log.Debug("failed to parse feed message for filtering", "sequenceNumber", message.SequenceNumber, "err", err)
+		}
+		for _, tx := range parsed {
+			filterTx := feedFilterTx{to: tx.To()}
+			if from, err := types.Sender(p.signer, tx); err == nil {
+				filterTx.from = from
+			}
+			if data := tx.Data(); len(data) >= 4 {
+				copy(filterTx.selector[:], data)
+				filterTx.hasData = true
+			}
+			txs = append(txs, filterTx)
+		}
+	}
+	p.txCache.Add(message, txs)
+	return txs
+}
+
+type feedFilter struct {
+	parser    *feedFilterParser
+	addresses map[common.Address]struct{}
+	selectors map[[4]byte]struct{}
+	key       string
+}
+
+func (f *feedFilter) Key() string {
+	return f.key
+}
+
+func (f *feedFilter) matchesTx(tx *feedFilterTx) bool {
+	if f.addresses != nil {
+		_, fromMatches := f.addresses[tx.from]
+		toMatches := false
+		if tx.to != nil {
+			_, toMatches = f.addresses[*tx.to]
+		}
+		if !fromMatches && !toMatches {
+			return false
+		}
+	}
+	if f.selectors != nil {
+		if !tx.hasData {
+			return false
+		}
+		if _, ok := f.selectors[tx.selector]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *feedFilter) matches(message *BroadcastFeedMessage) bool {
+	txs := f.parser.transactions(message)
+	for i := range txs {
+		if f.matchesTx(&txs[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *feedFilter) Filter(bmi interface{}) interface{} {
+	var bm BroadcastMessage
+	switch m := bmi.(type) {
+	case BroadcastMessage:
+		bm = m
+	case *BroadcastMessage:
+		bm = *m
+	default:
+		log.Error("requested to filter message of unknown type", "type", fmt.Sprintf("%T", bmi))
+		return nil
+	}
+	var messages []*BroadcastFeedMessage
+	for _, message := range bm.Messages {
+		if f.matches(message) {
+			messages = append(messages, message)
+		}
+	}
+	if len(messages) == 0 && bm.ConfirmedSequenceNumberMessage == nil {
+		return nil
+	}
+	bm.Messages = messages
+	return bm
+}
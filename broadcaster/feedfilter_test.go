@@ -0,0 +1,150 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package broadcaster
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/offchainlabs/nitro/arbos"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+)
+
+const testFilterChainId = 42161
+
+func signedTxFeedMessage(t *testing.T, seqNum arbutil.MessageIndex, to common.Address, data []byte) *BroadcastFeedMessage {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	Require(t, err)
+	chainId := big.NewInt(testFilterChainId)
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(chainId), &types.DynamicFeeTx{
+		ChainID:   chainId,
+		To:        &to,
+		Gas:       100000,
+		GasFeeCap: big.NewInt(1),
+		Data:      data,
+	})
+	Require(t, err)
+	txBytes, err := tx.MarshalBinary()
+	Require(t, err)
+	return &BroadcastFeedMessage{
+		SequenceNumber: seqNum,
+		Message: arbostypes.MessageWithMetadata{
+			Message: &arbostypes.L1IncomingMessage{
+				Header: &arbostypes.L1IncomingMessageHeader{
+					Kind:   arbostypes.L1MessageType_L2Message,
+					Poster: common.Address{},
+				},
+				L2msg: append([]byte{arbos.L2MessageKind_SignedTx}, txBytes...),
+			},
+		},
+	}
+}
+
+func filteredSeqNums(t *testing.T, filter interface{ Filter(interface{}) interface{} }, bm interface{}) ([]arbutil.MessageIndex, bool) {
+	t.Helper()
+	filtered := filter.Filter(bm)
+	if filtered == nil {
+		return nil, false
+	}
+	var seqNums []arbutil.MessageIndex
+	for _, message := range filtered.(BroadcastMessage).Messages {
+		seqNums = append(seqNums, message.SequenceNumber)
+	}
+	return seqNums, true
+}
+
+func TestFeedFilter(t *testing.T) {
+	token := common.HexToAddress("0x1234")
+	other := common.HexToAddress("0x5678")
+	transfer := []byte{0xa9, 0x05, 0x9c, 0xbb, 1, 2, 3}
+	approve := []byte{0x09, 0x5e, 0xa7, 0xb3}
+	messages := []*BroadcastFeedMessage{
+		signedTxFeedMessage(t, 1, token, transfer),
+		signedTxFeedMessage(t, 2, token, approve),
+		signedTxFeedMessage(t, 3, other, transfer),
+		signedTxFeedMessage(t, 4, other, nil),
+	}
+	bm := BroadcastMessage{Version: 1, Messages: messages}
+
+	parser := newFeedFilterParser(testFilterChainId)
+	for _, test := range []struct {
+		filter   string
+		expected []arbutil.MessageIndex
+	}{
+		{`{"addresses":["0x0000000000000000000000000000000000001234"]}`, []arbutil.MessageIndex{1, 2}},
+		{`{"selectors":["0xa9059cbb"]}`, []arbutil.MessageIndex{1, 3}},
+		{`{"addresses":["0x0000000000000000000000000000000000001234"],"selectors":["0xa9059cbb"]}`, []arbutil.MessageIndex{1}},
+		{`{"addresses":["0x0000000000000000000000000000000000005678"],"selectors":["0x095ea7b3"]}`, nil},
+	} {
+		filter, err := parser.Parse([]byte(test.filter))
+		Require(t, err)
+		seqNums, sent := filteredSeqNums(t, filter, bm)
+		if len(test.expected) == 0 {
+			if sent {
+				Fail(t, "filter", test.filter, "sent", seqNums, "expected nothing")
+			}
+			continue
+		}
+		if len(seqNums) != len(test.expected) {
+			Fail(t, "filter", test.filter, "sent", seqNums, "expected", test.expected)
+		}
+		for i := range seqNums {
+			if seqNums[i] != test.expected[i] {
+				Fail(t, "filter", test.filter, "sent", seqNums, "expected", test.expected)
+			}
+		}
+	}
+
+	// Confirmations are always sent, including from the catchup buffer
+	filter, err := parser.Parse([]byte(`{"selectors":["0x095ea7b3"]}`))
+	Require(t, err)
+	seqNums, sent := filteredSeqNums(t, filter, &BroadcastMessage{
+		Version:                        1,
+		Messages:                       messages[2:],
+		ConfirmedSequenceNumberMessage: &ConfirmedSequenceNumberMessage{2},
+	})
+	if !sent || len(seqNums) != 0 {
+		Fail(t, "expected only the confirmation to be sent, got", seqNums, sent)
+	}
+
+	// Senders match too
+	tx := new(types.Transaction)
+	Require(t, tx.UnmarshalBinary(messages[0].Message.Message.L2msg[1:]))
+	sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(testFilterChainId)), tx)
+	Require(t, err)
+	filter, err = parser.Parse([]byte(`{"addresses":["` + sender.Hex() + `"]}`))
+	Require(t, err)
+	if seqNums, _ := filteredSeqNums(t, filter, bm); len(seqNums) != 1 || seqNums[0] != 1 {
+		Fail(t, "filtering by sender sent", seqNums)
+	}
+
+	// Filters matching the same transactions share their output
+	first, err := parser.Parse([]byte(`{"addresses":["0x0000000000000000000000000000000000001234","0x0000000000000000000000000000000000005678"],"selectors":["0xa9059cbb"]}`))
+	Require(t, err)
+	second, err := parser.Parse([]byte(`{"addresses":["0x0000000000000000000000000000000000005678","0x0000000000000000000000000000000000001234","0x0000000000000000000000000000000000005678"],"selectors":["0xA9059CBB"]}`))
+	Require(t, err)
+	if first.Key() != second.Key() {
+		Fail(t, "equivalent filters have different keys", first.Key(), second.Key())
+	}
+	if first.Key() == filter.Key() {
+		Fail(t, "different filters have the same key", first.Key())
+	}
+
+	for _, invalid := range []string{
+		`{}`,
+		`not json`,
+		`{"selectors":["0xa9059c"]}`,
+		`{"addresses":["0x1234"]}`,
+	} {
+		if _, err := parser.Parse([]byte(invalid)); err == nil {
+			Fail(t, "parsed invalid filter", invalid)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package broadcaster
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/wsbroadcastserver"
+)
+
+var (
+	persistentCatchupMessagePrefix = []byte("m") // + sequence number -> persistedFeedMessage
+	persistentCatchupLastKey       = []byte("_last")
+)
+
+const (
+	// Cached messages are read and sent to clients in chunks of at most this
+	// many messages, so that a large catchup isn't held in memory at once.
+	persistentCatchupChunkSize = 1024
+	// How often to check for messages older than the maximum age
+	persistentCatchupAgePruneInterval = time.Minute
+)
+
+type persistedFeedMessage struct {
+	Timestamp uint64
+	Message   *BroadcastFeedMessage
+}
+
+// PersistentCatchupBuffer is a catchup buffer kept in a database, so a
+// restarted broadcaster can still send clients the messages they missed.
+// Messages are kept until the size or age limits are reached, rather than
+// until they are confirmed. Like SequenceNumberCatchupBuffer, everything
+// but GetMessageCount is only called from the client manager's thread, but
+// the messages are read from the database and sent to a registering client
+// from the client's own thread, so that other clients aren't held up.
+type PersistentCatchupBuffer struct {
+	config       func() *wsbroadcastserver.PersistentCatchupConfig
+	limitCatchup func() bool
+	db           ethdb.Database
+
+	empty        bool
+	first        arbutil.MessageIndex
+	last         arbutil.MessageIndex
+	lastAgePrune time.Time
+	messageCount int32
+}
+
+func NewPersistentCatchupBuffer(config func() *wsbroadcastserver.PersistentCatchupConfig, limitCatchup func() bool) *PersistentCatchupBuffer {
+	return &PersistentCatchupBuffer{
+		config:       config,
+		limitCatchup: limitCatchup,
+		empty:        true,
+	}
+}
+
+func persistentCatchupKey(seqNum arbutil.MessageIndex) []byte {
+	key := make([]byte, len(persistentCatchupMessagePrefix)+8)
+	copy(key, persistentCatchupMessagePrefix)
+	binary.BigEndian.PutUint64(key[len(persistentCatchupMessagePrefix):], uint64(seqNum))
+	return key
+}
+
+// Initialize opens the database and finds the messages already stored in it.
+func (b *PersistentCatchupBuffer) Initialize() error {
+	config := b.config()
+	db, err := rawdb.Open(rawdb.OpenOptions{
+		Type:      config.DBEngine,
+		Directory: config.Directory,
+		Namespace: "broadcaster/catchup/",
+		Cache:     16,
+		Handles:   16,
+	})
+	if err != nil {
+		return fmt.Errorf("opening catchup database: %w", err)
+	}
+	b.db = db
+
+	it := db.NewIterator(persistentCatchupMessagePrefix, nil)
+	defer it.Release()
+	if !it.Next() {
+		return it.Error()
+	}
+	b.first = arbutil.MessageIndex(binary.BigEndian.Uint64(it.Key()[len(persistentCatchupMessagePrefix):]))
+	lastBytes, err := db.Get(persistentCatchupLastKey)
+	if err != nil {
+		return fmt.Errorf("reading last catchup message: %w", err)
+	}
+	if len(lastBytes) != 8 {
+		return errors.New("invalid last catchup message")
+	}
+	b.last = arbutil.MessageIndex(binary.BigEndian.Uint64(lastBytes))
+	if b.last < b.first {
+		return fmt.Errorf("last catchup message %v is before first %v", b.last, b.first)
+	}
+	b.empty = false
+	b.updateMessageCount()
+	log.Info("loaded catchup database", "firstSequenceNumber", b.first, "lastSequenceNumber", b.last)
+	return nil
+}
+
+func (b *PersistentCatchupBuffer) Close() error {
+	if b.db == nil {
+		return nil
+	}
+	return b.db.Close()
+}
+
+func (b *PersistentCatchupBuffer) updateMessageCount() {
+	var count int32
+	if !b.empty {
+		count = int32(b.last - b.first + 1)
+	}
+	atomic.StoreInt32(&b.messageCount, count)
+}
+
+func (b *PersistentCatchupBuffer) GetMessageCount() int {
+	return int(atomic.LoadInt32(&b.messageCount))
+}
+
+func (b *PersistentCatchupBuffer) OnRegisterClient(clientConnection *wsbroadcastserver.ClientConnection) (error, int, time.Duration) {
+	start := time.Now()
+	if b.empty {
+		cachedMessagesSentHistogram.Update(0)
+		return nil, 0, time.Since(start)
+	}
+	requestedSeqNum := clientConnection.RequestedSeqNum()
+	if requestedSeqNum > b.last {
+		// Past end, nothing to send
+		cachedMessagesSentHistogram.Update(0)
+		return nil, 0, time.Since(start)
+	}
+	if requestedSeqNum < b.first && b.limitCatchup() && b.first > maxRequestedSeqNumOffset && requestedSeqNum < b.first-maxRequestedSeqNumOffset {
+		// Requested seqnum is too old, don't send any cache
+		cachedMessagesSentHistogram.Update(0)
+		return nil, 0, time.Since(start)
+	}
+	if requestedSeqNum < b.first {
+		requestedSeqNum = b.first
+	}
+
+	// Messages broadcast from now on are queued for the client, and sent after these
+	last := b.last
+	clientConnection.SetCatchup(func(ctx context.Context) error {
+		catchupStart := time.Now()
+		sent, err := b.readCatchup(ctx, requestedSeqNum, last, func(messages []*BroadcastFeedMessage) error {
+			return clientConnection.WriteCatchup(ctx, &BroadcastMessage{
+				Version:  1,
+				Messages: messages,
+			})
+		})
+		if err != nil {
+			log.Error("error sending client cached messages", "error", err, "client", clientConnection.Name, "elapsed", time.Since(catchupStart))
+			return err
+		}
+		cachedMessagesSentHistogram.Update(int64(sent))
+		return nil
+	})
+	return nil, int(last-requestedSeqNum) + 1, time.Since(start)
+}
+
+// readCatchup reads the stored messages from first to last inclusive, and
+// passes them to send in chunks. It returns the number of messages sent.
+func (b *PersistentCatchupBuffer) readCatchup(ctx context.Context, first, last arbutil.MessageIndex, send func([]*BroadcastFeedMessage) error) (int, error) {
+	sent := 0
+	it := b.db.NewIterator(persistentCatchupMessagePrefix, persistentCatchupKey(first)[len(persistentCatchupMessagePrefix):])
+	defer it.Release()
+	chunk := make([]*BroadcastFeedMessage, 0, persistentCatchupChunkSize)
+	for it.Next() {
+		var persisted persistedFeedMessage
+		if err := rlp.DecodeBytes(it.Value(), &persisted); err != nil {
+			log.Error("error decoding cached message", "key", it.Key(), "err", err)
+			return sent, err
+		}
+		if persisted.Message.SequenceNumber > last {
+			break
+		}
+		chunk = append(chunk, persisted.Message)
+		if len(chunk) == persistentCatchupChunkSize {
+			if err := send(chunk); err != nil {
+				return sent, err
+			}
+			sent += len(chunk)
+			chunk = make([]*BroadcastFeedMessage, 0, persistentCatchupChunkSize)
+			if ctx.Err() != nil {
+				return sent, ctx.Err()
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return sent, err
+	}
+	if len(chunk) > 0 {
+		if err := send(chunk); err != nil {
+			return sent, err
+		}
+		sent += len(chunk)
+	}
+	return sent, nil
+}
+
+// deleteRange deletes the stored messages with sequence numbers from first
+// to last inclusive.
+func (b *PersistentCatchupBuffer) deleteRange(batch ethdb.Batch, first, last arbutil.MessageIndex) error {
+	for seqNum := first; seqNum <= last; seqNum++ {
+		if err := batch.Delete(persistentCatchupKey(seqNum)); err != nil {
+			return err
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	return nil
+}
+
+func (b *PersistentCatchupBuffer) OnDoBroadcast(bmi interface{}) error {
+	broadcastMessage, ok := bmi.(BroadcastMessage)
+	if !ok {
+		msg := "requested to broadcast message of unknown type"
+		log.Error(msg)
+		return errors.New(msg)
+	}
+	defer b.updateMessageCount()
+
+	if confirmMsg := broadcastMessage.ConfirmedSequenceNumberMessage; confirmMsg != nil {
+		confirmedSequenceNumberGauge.Update(int64(confirmMsg.SequenceNumber))
+	}
+
+	now := time.Now()
+	batch := b.db.NewBatch()
+	// Pruning by age reads the database, so it's done before any other changes
+	if maxAge := b.config().MaxAge; maxAge > 0 && !b.empty && now.Sub(b.lastAgePrune) >= persistentCatchupAgePruneInterval {
+		if err := b.pruneByAge(batch, now.Add(-maxAge)); err != nil {
+			return err
+		}
+		b.lastAgePrune = now
+	}
+	for _, newMsg := range broadcastMessage.Messages {
+		if !b.empty {
+			expectedSequenceNumber := b.last + 1
+			if newMsg.SequenceNumber < expectedSequenceNumber {
+				log.Info("Skipping already seen message", "seqNum", newMsg.SequenceNumber)
+				continue
+			}
+			if newMsg.SequenceNumber > expectedSequenceNumber {
+				log.Warn(
+					"Message requested to be broadcast has unexpected sequence number; discarding to seqNum from catchup database",
+					"seqNum", newMsg.SequenceNumber,
+					"expectedSeqNum", expectedSequenceNumber,
+				)
+				if err := b.deleteRange(batch, b.first, b.last); err != nil {
+					return err
+				}
+				b.empty = true
+			}
+		}
+		value, err := rlp.EncodeToBytes(persistedFeedMessage{
+			Timestamp: uint64(now.Unix()),
+			Message:   newMsg,
+		})
+		if err != nil {
+			return err
+		}
+		if err := batch.Put(persistentCatchupKey(newMsg.SequenceNumber), value); err != nil {
+			return err
+		}
+		if b.empty {
+			b.first = newMsg.SequenceNumber
+			b.empty = false
+		}
+		b.last = newMsg.SequenceNumber
+	}
+
+	if !b.empty {
+		maxMessages := b.config().MaxMessages
+		if count := uint64(b.last-b.first) + 1; count > maxMessages {
+			newFirst := b.first + arbutil.MessageIndex(count-maxMessages)
+			if err := b.deleteRange(batch, b.first, newFirst-1); err != nil {
+				return err
+			}
+			b.first = newFirst
+		}
+		var lastBytes [8]byte
+		binary.BigEndian.PutUint64(lastBytes[:], uint64(b.last))
+		if err := batch.Put(persistentCatchupLastKey, lastBytes[:]); err != nil {
+			return err
+		}
+	} else if err := batch.Delete(persistentCatchupLastKey); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// pruneByAge deletes messages stored before cutoff. The last message is
+// always kept so the next sequence number stays known.
+func (b *PersistentCatchupBuffer) pruneByAge(batch ethdb.Batch, cutoff time.Time) error {
+	it := b.db.NewIterator(persistentCatchupMessagePrefix, persistentCatchupKey(b.first)[len(persistentCatchupMessagePrefix):])
+	defer it.Release()
+	newFirst := b.first
+	for newFirst < b.last && it.Next() {
+		var persisted persistedFeedMessage
+		if err := rlp.DecodeBytes(it.Value(), &persisted); err != nil {
+			return err
+		}
+		if int64(persisted.Timestamp) >= cutoff.Unix() {
+			break
+		}
+		newFirst++
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if newFirst > b.first {
+		if err := b.deleteRange(batch, b.first, newFirst-1); err != nil {
+			return err
+		}
+		b.first = newFirst
+	}
+	return nil
+}
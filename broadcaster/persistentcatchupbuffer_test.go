@@ -0,0 +1,166 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package broadcaster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/wsbroadcastserver"
+)
+
+func newTestPersistentCatchupBuffer(t *testing.T, config *wsbroadcastserver.PersistentCatchupConfig) *PersistentCatchupBuffer {
+	t.Helper()
+	b := NewPersistentCatchupBuffer(func() *wsbroadcastserver.PersistentCatchupConfig { return config }, func() bool { return false })
+	Require(t, b.Initialize())
+	return b
+}
+
+// storedSeqNums checks that the stored messages are exactly those from first
+// to last, and returns their sequence numbers.
+func storedSeqNums(t *testing.T, b *PersistentCatchupBuffer) []arbutil.MessageIndex {
+	t.Helper()
+	var seqNums []arbutil.MessageIndex
+	it := b.db.NewIterator(persistentCatchupMessagePrefix, nil)
+	defer it.Release()
+	for it.Next() {
+		var persisted persistedFeedMessage
+		Require(t, rlp.DecodeBytes(it.Value(), &persisted))
+		seqNums = append(seqNums, persisted.Message.SequenceNumber)
+	}
+	Require(t, it.Error())
+	if len(seqNums) != b.GetMessageCount() {
+		Fail(t, "stored", len(seqNums), "messages but counted", b.GetMessageCount())
+	}
+	for i, seqNum := range seqNums {
+		if seqNum != b.first+arbutil.MessageIndex(i) {
+			Fail(t, "stored", seqNums, "but first is", b.first)
+		}
+	}
+	if len(seqNums) > 0 && seqNums[len(seqNums)-1] != b.last {
+		Fail(t, "stored", seqNums, "but last is", b.last)
+	}
+	return seqNums
+}
+
+func broadcastSeqNums(t *testing.T, b *PersistentCatchupBuffer, seqNums ...arbutil.MessageIndex) {
+	t.Helper()
+	Require(t, b.OnDoBroadcast(BroadcastMessage{
+		Version:  1,
+		Messages: createDummyBroadcastMessages(seqNums),
+	}))
+}
+
+func TestPersistentCatchupBufferSurvivesRestart(t *testing.T) {
+	config := wsbroadcastserver.DefaultPersistentCatchupConfig
+	config.Enable = true
+	config.Directory = t.TempDir()
+
+	b := newTestPersistentCatchupBuffer(t, &config)
+	if stored := storedSeqNums(t, b); len(stored) != 0 {
+		Fail(t, "new database has messages", stored)
+	}
+	broadcastSeqNums(t, b, 40, 41, 42)
+	// Confirmations don't remove messages
+	Require(t, b.OnDoBroadcast(BroadcastMessage{
+		Version:                        1,
+		ConfirmedSequenceNumberMessage: &ConfirmedSequenceNumberMessage{41},
+	}))
+	broadcastSeqNums(t, b, 42, 43)
+	Require(t, b.Close())
+
+	b = newTestPersistentCatchupBuffer(t, &config)
+	defer func() { Require(t, b.Close()) }()
+	if stored := storedSeqNums(t, b); len(stored) != 4 || b.first != 40 {
+		Fail(t, "unexpected messages after restart", stored)
+	}
+	broadcastSeqNums(t, b, 44)
+	if stored := storedSeqNums(t, b); len(stored) != 5 || b.last != 44 {
+		Fail(t, "unexpected messages", stored)
+	}
+
+	// A gap in the sequence numbers discards the stored messages
+	broadcastSeqNums(t, b, 50, 51)
+	if stored := storedSeqNums(t, b); len(stored) != 2 || b.first != 50 {
+		Fail(t, "unexpected messages after gap", stored)
+	}
+}
+
+func TestPersistentCatchupBufferRetention(t *testing.T) {
+	config := wsbroadcastserver.DefaultPersistentCatchupConfig
+	config.Enable = true
+	config.Directory = t.TempDir()
+	config.MaxMessages = 5
+	config.MaxAge = 0
+
+	b := newTestPersistentCatchupBuffer(t, &config)
+	defer func() { Require(t, b.Close()) }()
+	for i := arbutil.MessageIndex(0); i < 20; i++ {
+		broadcastSeqNums(t, b, i)
+	}
+	if stored := storedSeqNums(t, b); len(stored) != 5 || b.first != 15 {
+		Fail(t, "unexpected messages after exceeding max messages", stored)
+	}
+
+	// Pretend the messages were stored long ago
+	config.MaxAge = time.Hour
+	Require(t, b.pruneByAgeForTest(time.Now().Add(2*time.Hour)))
+	// The last message is kept so the next sequence number is known
+	if stored := storedSeqNums(t, b); len(stored) != 1 || b.first != 19 {
+		Fail(t, "unexpected messages after exceeding max age", stored)
+	}
+	broadcastSeqNums(t, b, 20)
+	if stored := storedSeqNums(t, b); len(stored) != 2 {
+		Fail(t, "unexpected messages", stored)
+	}
+}
+
+func TestPersistentCatchupBufferReadCatchup(t *testing.T) {
+	config := wsbroadcastserver.DefaultPersistentCatchupConfig
+	config.Enable = true
+	config.Directory = t.TempDir()
+	b := newTestPersistentCatchupBuffer(t, &config)
+	defer func() { Require(t, b.Close()) }()
+	var seqNums []arbutil.MessageIndex
+	for i := arbutil.MessageIndex(0); i < 3000; i++ {
+		seqNums = append(seqNums, i)
+	}
+	broadcastSeqNums(t, b, seqNums...)
+
+	// Messages are sent in bounded chunks, and only up to the last one
+	// stored when the client registered
+	next := arbutil.MessageIndex(10)
+	sent, err := b.readCatchup(context.Background(), 10, 2500, func(messages []*BroadcastFeedMessage) error {
+		if len(messages) > persistentCatchupChunkSize {
+			Fail(t, "sent chunk of", len(messages), "messages")
+		}
+		for _, message := range messages {
+			if message.SequenceNumber != next {
+				Fail(t, "sent message", message.SequenceNumber, "expected", next)
+			}
+			next++
+		}
+		return nil
+	})
+	Require(t, err)
+	if sent != 2491 || next != 2501 {
+		Fail(t, "sent", sent, "messages up to", next)
+	}
+}
+
+func (b *PersistentCatchupBuffer) pruneByAgeForTest(now time.Time) error {
+	batch := b.db.NewBatch()
+	if err := b.pruneByAge(batch, now.Add(-b.config().MaxAge)); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	b.updateMessageCount()
+	return nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2021-2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/node"
+
+	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+)
+
+type BatchSimConfig struct {
+	ChainDir         string   `koanf:"chain-dir"`
+	DBEngine         string   `koanf:"db-engine"`
+	FromMessage      uint64   `koanf:"from-message"`
+	ToMessage        uint64   `koanf:"to-message"`
+	BatchOverheadGas uint64   `koanf:"batch-overhead-gas"`
+	MaxSize          []string `koanf:"max-size"`
+	MaxDelay         []string `koanf:"max-delay"`
+	Compression      []string `koanf:"compression"`
+	CompressionLevel []string `koanf:"compression-level"`
+}
+
+func parseBatchSimConfig(args []string) (*BatchSimConfig, error) {
+	f := flag.NewFlagSet("batchsim", flag.ContinueOnError)
+	f.String("chain-dir", "", "chain directory of the (stopped) node to read messages from, as passed to --persistent.chain")
+	f.String("db-engine", "leveldb", "backing database implementation of the node ('leveldb' or 'pebble')")
+	f.Uint64("from-message", 1, "first message to simulate")
+	f.Uint64("to-message", 0, "simulate messages up to but not including this one (0 = all stored messages)")
+	f.Uint64("batch-overhead-gas", 100_000, "estimated L1 gas used by each batch posting transaction in addition to its calldata")
+	f.StringSlice("max-size", []string{strconv.Itoa(arbnode.DefaultBatchPosterConfig.MaxSize)}, "maximum batch sizes to simulate")
+	f.StringSlice("max-delay", []string{arbnode.DefaultBatchPosterConfig.MaxDelay.String()}, "maximum batch posting delays to simulate")
+	f.StringSlice("compression", []string{arbnode.DefaultBatchPosterConfig.Compression}, "batch compression codecs to simulate")
+	f.StringSlice("compression-level", []string{strconv.Itoa(arbnode.DefaultBatchPosterConfig.CompressionLevel)}, "batch compression levels to simulate")
+	genericconf.ConfConfigAddOptions("conf", f)
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var config BatchSimConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// variants returns every combination of the configured batch poster options
+func (c *BatchSimConfig) variants() ([]arbnode.BatchPosterConfig, error) {
+	var variants []arbnode.BatchPosterConfig
+	for _, maxSizeStr := range c.MaxSize {
+		maxSize, err := strconv.Atoi(maxSizeStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max size \"%v\": %w", maxSizeStr, err)
+		}
+		for _, maxDelayStr := range c.MaxDelay {
+			maxDelay, err := time.ParseDuration(maxDelayStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid max delay \"%v\": %w", maxDelayStr, err)
+			}
+			for _, compression := range c.Compression {
+				for _, levelStr := range c.CompressionLevel {
+					level, err := strconv.Atoi(levelStr)
+					if err != nil {
+						return nil, fmt.Errorf("invalid compression level \"%v\": %w", levelStr, err)
+					}
+					variant := arbnode.DefaultBatchPosterConfig
+					variant.MaxSize = maxSize
+					variant.MaxDelay = maxDelay
+					variant.Compression = compression
+					variant.CompressionLevel = level
+					variants = append(variants, variant)
+				}
+			}
+		}
+	}
+	return variants, nil
+}
+
+func main() {
+	if err := mainImpl(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+func mainImpl(args []string) error {
+	config, err := parseBatchSimConfig(args)
+	if err != nil {
+		return err
+	}
+	if config.ChainDir == "" {
+		return errors.New("--chain-dir must be specified")
+	}
+	variants, err := config.variants()
+	if err != nil {
+		return err
+	}
+
+	stackConf := node.DefaultConfig
+	stackConf.DataDir = config.ChainDir
+	stackConf.DBEngine = config.DBEngine
+	// The node's databases live in a directory named after its executable
+	stackConf.Name = "nitro"
+	stackConf.P2P.ListenAddr = ""
+	stackConf.P2P.NoDial = true
+	stackConf.P2P.NoDiscovery = true
+	stack, err := node.New(&stackConf)
+	if err != nil {
+		return err
+	}
+	defer stack.Close()
+	arbDb, err := stack.OpenDatabase("arbitrumdata", 0, 0, "", true)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+
+	streamerConfig := func() *arbnode.TransactionStreamerConfig { return &arbnode.DefaultTransactionStreamerConfig }
+	streamer, err := arbnode.NewTransactionStreamer(arbDb, nil, nil, nil, nil, streamerConfig)
+	if err != nil {
+		return err
+	}
+	msgCount, err := streamer.GetMessageCount()
+	if err != nil {
+		return err
+	}
+	end := arbutil.MessageIndex(config.ToMessage)
+	if end == 0 || end > msgCount {
+		end = msgCount
+	}
+	start := arbutil.MessageIndex(config.FromMessage)
+	if start >= end {
+		return fmt.Errorf("nothing to simulate: from message %v, to message %v", start, end)
+	}
+
+	ctx := context.Background()
+	out := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(out, "max-size\tmax-delay\tcompression\tlevel\tbatches\tmessages\tuncompressed\tcompressed\tratio\test. L1 gas\t\n")
+	for i := range variants {
+		variant := &variants[i]
+		result, err := arbnode.SimulateBatches(ctx, streamer, variant, start, end, config.BatchOverheadGas)
+		if err != nil {
+			return fmt.Errorf("error simulating %v/%v/%v/%v: %w", variant.MaxSize, variant.MaxDelay, variant.Compression, variant.CompressionLevel, err)
+		}
+		var ratio float64
+		if result.UncompressedBytes > 0 {
+			ratio = float64(result.CompressedBytes) / float64(result.UncompressedBytes)
+		}
+		fmt.Fprintf(
+			out,
+			"%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%.3f\t%v\t\n",
+			variant.MaxSize,
+			variant.MaxDelay,
+			variant.Compression,
+			variant.CompressionLevel,
+			result.Batches,
+			result.Messages,
+			result.UncompressedBytes,
+			result.CompressedBytes,
+			ratio,
+			result.EstimatedL1Gas,
+		)
+	}
+	return out.Flush()
+}
@@ -4,6 +4,7 @@
 package conf
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/offchainlabs/nitro/cmd/genericconf"
@@ -31,11 +32,12 @@ var L1ConfigDefault = L1Config{
 }
 
 var DefaultL1WalletConfig = genericconf.WalletConfig{
-	Pathname:      "wallet",
-	Password:      genericconf.WalletConfigDefault.Password,
-	PrivateKey:    genericconf.WalletConfigDefault.PrivateKey,
-	Account:       genericconf.WalletConfigDefault.Account,
-	OnlyCreateKey: genericconf.WalletConfigDefault.OnlyCreateKey,
+	Pathname:       "wallet",
+	Password:       genericconf.WalletConfigDefault.Password,
+	PrivateKey:     genericconf.WalletConfigDefault.PrivateKey,
+	Account:        genericconf.WalletConfigDefault.Account,
+	OnlyCreateKey:  genericconf.WalletConfigDefault.OnlyCreateKey,
+	ExternalSigner: genericconf.WalletConfigDefault.ExternalSigner,
 }
 
 func L1ConfigAddOptions(prefix string, f *flag.FlagSet) {
@@ -49,6 +51,9 @@ func (c *L1Config) ResolveDirectoryNames(chain string) {
 }
 
 func (c *L1Config) Validate() error {
+	if err := c.Wallet.Validate(); err != nil {
+		return fmt.Errorf("invalid parent chain wallet: %w", err)
+	}
 	return c.Connection.Validate()
 }
 
@@ -35,7 +35,7 @@ import (
 func main() {
 	args := os.Args
 	if len(args) < 2 {
-		panic("Usage: datool [client|keygen|generatehash|dumpkeyset] ...")
+		panic("Usage: datool [client|keygen|generatehash|dumpkeyset|keyset|migratelocalfiles] ...")
 	}
 
 	var err error
@@ -48,8 +48,12 @@ func main() {
 		err = generateHash(args[2])
 	case "dumpkeyset":
 		err = dumpKeyset(args[2:])
+	case "keyset":
+		err = startKeyset(args[2:])
+	case "migratelocalfiles":
+		err = migrateLocalFiles(args[2:])
 	default:
-		panic(fmt.Sprintf("Unknown tool '%s' specified, valid tools are 'client', 'keygen', 'generatehash'", args[1]))
+		panic(fmt.Sprintf("Unknown tool '%s' specified, valid tools are 'client', 'keygen', 'generatehash', 'dumpkeyset', 'keyset', 'migratelocalfiles'", args[1]))
 	}
 	if err != nil {
 		panic(err)
@@ -380,3 +384,40 @@ func dumpKeyset(args []string) error {
 
 	return err
 }
+
+// datool migratelocalfiles
+
+type MigrateLocalFilesConfig struct {
+	DataDir string                 `koanf:"data-dir"`
+	Conf    genericconf.ConfConfig `koanf:"conf"`
+}
+
+func parseMigrateLocalFilesConfig(args []string) (*MigrateLocalFilesConfig, error) {
+	f := flag.NewFlagSet("datool migratelocalfiles", flag.ContinueOnError)
+	f.String("data-dir", "", "local file storage data directory to move into the sharded layout")
+	genericconf.ConfConfigAddOptions("conf", f)
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var config MigrateLocalFilesConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	if config.DataDir == "" {
+		return nil, errors.New("--data-dir must be set")
+	}
+	return &config, nil
+}
+
+func migrateLocalFiles(args []string) error {
+	config, err := parseMigrateLocalFilesConfig(args)
+	if err != nil {
+		return err
+	}
+	moved, err := das.MigrateLocalFileStorageToShardedLayout(context.Background(), config.DataDir)
+	fmt.Printf("Moved %d files into the sharded layout\n", moved)
+	return err
+}
@@ -0,0 +1,296 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/blsSignatures"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+	"github.com/offchainlabs/nitro/das"
+	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
+)
+
+// datool keyset ...
+
+func startKeyset(args []string) error {
+	if len(args) == 0 {
+		return errors.New("datool keyset requires one of 'build', 'diff' or 'calldata'")
+	}
+	switch strings.ToLower(args[0]) {
+	case "build":
+		return keysetBuild(args[1:], os.Stdout)
+	case "diff":
+		return keysetDiff(args[1:], os.Stdout)
+	case "calldata":
+		return keysetCalldata(args[1:], os.Stdout)
+	}
+	return fmt.Errorf("datool keyset '%s' not supported, valid arguments are 'build', 'diff' and 'calldata'", args[0])
+}
+
+// readPubKey reads a base64-encoded BLS public key, from a file if one
+// exists at that path.
+func readPubKey(pubKey string) (*blsSignatures.PublicKey, error) {
+	if _, err := os.Stat(pubKey); err == nil {
+		return das.ReadPubKeyFromFile(pubKey)
+	}
+	return das.DecodeBase64BLSPublicKey([]byte(pubKey))
+}
+
+// readKeyset reads a hex-encoded serialized keyset, from a file unless it's
+// prefixed with 0x.
+func readKeyset(keyset string) (*arbstate.DataAvailabilityKeyset, []byte, error) {
+	if !strings.HasPrefix(keyset, "0x") {
+		contents, err := os.ReadFile(keyset)
+		if err != nil {
+			return nil, nil, err
+		}
+		keyset = strings.TrimSpace(string(contents))
+	}
+	keysetBytes, err := hexutil.Decode(keyset)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decoding keyset: %w", err)
+	}
+	ks, err := arbstate.DeserializeKeyset(bytes.NewReader(keysetBytes), false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("deserializing keyset: %w", err)
+	}
+	return ks, keysetBytes, nil
+}
+
+func printKeyset(out io.Writer, keysetBytes []byte, keysetHash common.Hash) {
+	fmt.Fprintf(out, "Keyset: %s\n", hexutil.Encode(keysetBytes))
+	fmt.Fprintf(out, "KeysetHash: %s\n", hexutil.Encode(keysetHash[:]))
+}
+
+// datool keyset build
+
+type KeysetBuildConfig struct {
+	AssumedHonest uint64   `koanf:"assumed-honest"`
+	PubKeys       []string `koanf:"pubkeys"`
+}
+
+func parseKeysetBuildConfig(args []string) (*KeysetBuildConfig, error) {
+	f := flag.NewFlagSet("datool keyset build", flag.ContinueOnError)
+	f.Uint64("assumed-honest", 0, "number of committee members assumed to be honest")
+	f.StringSlice("pubkeys", []string{}, "BLS public keys of the committee members in signer order, each either base64-encoded or the path of a file containing it")
+	genericconf.ConfConfigAddOptions("conf", f)
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var config KeysetBuildConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	if len(config.PubKeys) == 0 {
+		return nil, errors.New("--pubkeys must be set")
+	}
+	if config.AssumedHonest == 0 || config.AssumedHonest > uint64(len(config.PubKeys)) {
+		return nil, fmt.Errorf("--assumed-honest must be between 1 and the number of public keys (%d)", len(config.PubKeys))
+	}
+	return &config, nil
+}
+
+func keysetBuild(args []string, out io.Writer) error {
+	config, err := parseKeysetBuildConfig(args)
+	if err != nil {
+		return err
+	}
+	keyset := &arbstate.DataAvailabilityKeyset{AssumedHonest: config.AssumedHonest}
+	seen := make(map[string]bool)
+	for i, pubKeyArg := range config.PubKeys {
+		pubKey, err := readPubKey(pubKeyArg)
+		if err != nil {
+			return fmt.Errorf("reading public key %d: %w", i, err)
+		}
+		encoded := string(blsSignatures.PublicKeyToBytes(*pubKey))
+		if seen[encoded] {
+			return fmt.Errorf("public key %d is a duplicate", i)
+		}
+		seen[encoded] = true
+		keyset.PubKeys = append(keyset.PubKeys, *pubKey)
+	}
+
+	wr := bytes.NewBuffer([]byte{})
+	if err := keyset.Serialize(wr); err != nil {
+		return err
+	}
+	keysetHash, err := keyset.Hash()
+	if err != nil {
+		return err
+	}
+	printKeyset(out, wr.Bytes(), keysetHash)
+	return nil
+}
+
+// datool keyset diff
+
+type KeysetDiffConfig struct {
+	Current string `koanf:"current"`
+	New     string `koanf:"new"`
+}
+
+func parseKeysetDiffConfig(args []string) (*KeysetDiffConfig, error) {
+	f := flag.NewFlagSet("datool keyset diff", flag.ContinueOnError)
+	f.String("current", "", "the current keyset, hex-encoded if prefixed with 0x, otherwise a file containing it")
+	f.String("new", "", "the new keyset, hex-encoded if prefixed with 0x, otherwise a file containing it")
+	genericconf.ConfConfigAddOptions("conf", f)
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var config KeysetDiffConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	if config.Current == "" || config.New == "" {
+		return nil, errors.New("--current and --new must be set")
+	}
+	return &config, nil
+}
+
+func encodePubKey(pubKey blsSignatures.PublicKey) string {
+	return base64.StdEncoding.EncodeToString(blsSignatures.PublicKeyToBytes(pubKey))
+}
+
+func keysetDiff(args []string, out io.Writer) error {
+	config, err := parseKeysetDiffConfig(args)
+	if err != nil {
+		return err
+	}
+	current, _, err := readKeyset(config.Current)
+	if err != nil {
+		return fmt.Errorf("reading current keyset: %w", err)
+	}
+	newKeyset, _, err := readKeyset(config.New)
+	if err != nil {
+		return fmt.Errorf("reading new keyset: %w", err)
+	}
+	currentHash, err := current.Hash()
+	if err != nil {
+		return err
+	}
+	newHash, err := newKeyset.Hash()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Current KeysetHash: %s\n", hexutil.Encode(currentHash[:]))
+	fmt.Fprintf(out, "New KeysetHash: %s\n", hexutil.Encode(newHash[:]))
+	if currentHash == newHash {
+		fmt.Fprintln(out, "Keysets are identical")
+		return nil
+	}
+	if current.AssumedHonest != newKeyset.AssumedHonest {
+		fmt.Fprintf(out, "AssumedHonest: %d -> %d\n", current.AssumedHonest, newKeyset.AssumedHonest)
+	}
+
+	// Signers are identified by their index in the keyset, so report moved
+	// keys as well as added and removed ones.
+	currentIndex := make(map[string]int)
+	for i, pubKey := range current.PubKeys {
+		currentIndex[encodePubKey(pubKey)] = i
+	}
+	newIndex := make(map[string]int)
+	for i, pubKey := range newKeyset.PubKeys {
+		encoded := encodePubKey(pubKey)
+		newIndex[encoded] = i
+		oldI, ok := currentIndex[encoded]
+		if !ok {
+			fmt.Fprintf(out, "Added signer %d: %s\n", i, encoded)
+		} else if oldI != i {
+			fmt.Fprintf(out, "Moved signer %d -> %d: %s\n", oldI, i, encoded)
+		}
+	}
+	for i, pubKey := range current.PubKeys {
+		encoded := encodePubKey(pubKey)
+		if _, ok := newIndex[encoded]; !ok {
+			fmt.Fprintf(out, "Removed signer %d: %s\n", i, encoded)
+		}
+	}
+	return nil
+}
+
+// datool keyset calldata
+
+type KeysetCalldataConfig struct {
+	Keyset     string `koanf:"keyset"`
+	Invalidate string `koanf:"invalidate"`
+}
+
+func parseKeysetCalldataConfig(args []string) (*KeysetCalldataConfig, error) {
+	f := flag.NewFlagSet("datool keyset calldata", flag.ContinueOnError)
+	f.String("keyset", "", "the keyset to make valid, hex-encoded if prefixed with 0x, otherwise a file containing it")
+	f.String("invalidate", "", "hex-encoded hash of a keyset to invalidate")
+	genericconf.ConfConfigAddOptions("conf", f)
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var config KeysetCalldataConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	if config.Keyset == "" && config.Invalidate == "" {
+		return nil, errors.New("at least one of --keyset and --invalidate must be set")
+	}
+	return &config, nil
+}
+
+func keysetCalldata(args []string, out io.Writer) error {
+	config, err := parseKeysetCalldataConfig(args)
+	if err != nil {
+		return err
+	}
+	seqInboxABI, err := bridgegen.SequencerInboxMetaData.GetAbi()
+	if err != nil {
+		return err
+	}
+	if config.Keyset != "" {
+		keyset, keysetBytes, err := readKeyset(config.Keyset)
+		if err != nil {
+			return err
+		}
+		keysetHash, err := keyset.Hash()
+		if err != nil {
+			return err
+		}
+		calldata, err := seqInboxABI.Pack("setValidKeyset", keysetBytes)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "KeysetHash: %s\n", hexutil.Encode(keysetHash[:]))
+		fmt.Fprintf(out, "setValidKeyset calldata: %s\n", hexutil.Encode(calldata))
+	}
+	if config.Invalidate != "" {
+		hashBytes, err := hexutil.Decode(config.Invalidate)
+		if err != nil || len(hashBytes) != common.HashLength {
+			return errors.New("--invalidate must be a hex-encoded 32 byte keyset hash")
+		}
+		calldata, err := seqInboxABI.Pack("invalidateKeysetHash", common.BytesToHash(hashBytes))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "invalidateKeysetHash calldata: %s\n", hexutil.Encode(calldata))
+	}
+	return nil
+}
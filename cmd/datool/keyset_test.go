@@ -0,0 +1,178 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/offchainlabs/nitro/blsSignatures"
+	"github.com/offchainlabs/nitro/das"
+	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func runKeysetCommand(t *testing.T, command func([]string, io.Writer) error, args ...string) string {
+	t.Helper()
+	var out bytes.Buffer
+	Require(t, command(args, &out))
+	return out.String()
+}
+
+// outputField returns the value of the "<name>: <value>" line of a command's output.
+func outputField(t *testing.T, output string, name string) string {
+	t.Helper()
+	for _, line := range strings.Split(output, "\n") {
+		if value, found := strings.CutPrefix(line, name+": "); found {
+			return value
+		}
+	}
+	Fail(t, "no", name, "in output", output)
+	return ""
+}
+
+func generatePubKeys(t *testing.T, count int) []string {
+	t.Helper()
+	var pubKeys []string
+	for i := 0; i < count; i++ {
+		pubKey, _, err := blsSignatures.GenerateKeys()
+		Require(t, err)
+		pubKeys = append(pubKeys, encodePubKey(pubKey))
+	}
+	return pubKeys
+}
+
+func buildKeyset(t *testing.T, assumedHonest int, pubKeys ...string) (string, string) {
+	t.Helper()
+	output := runKeysetCommand(t, keysetBuild, "--assumed-honest", fmt.Sprint(assumedHonest), "--pubkeys", strings.Join(pubKeys, ","))
+	return outputField(t, output, "Keyset"), outputField(t, output, "KeysetHash")
+}
+
+func TestKeysetBuild(t *testing.T) {
+	keyDir := t.TempDir()
+	filePubKey, _, err := das.GenerateAndStoreKeys(keyDir)
+	Require(t, err)
+	pubKeys := append([]string{filepath.Join(keyDir, das.DefaultPubKeyFilename)}, generatePubKeys(t, 2)...)
+
+	keysetHex, keysetHash := buildKeyset(t, 2, pubKeys...)
+	keyset, _, err := readKeyset(keysetHex)
+	Require(t, err)
+	if keyset.AssumedHonest != 2 {
+		Fail(t, "unexpected assumed honest", keyset.AssumedHonest)
+	}
+	expectedPubKeys := append([]string{encodePubKey(*filePubKey)}, pubKeys[1:]...)
+	if len(keyset.PubKeys) != len(expectedPubKeys) {
+		Fail(t, "unexpected number of public keys", len(keyset.PubKeys))
+	}
+	for i, pubKey := range keyset.PubKeys {
+		if encodePubKey(pubKey) != expectedPubKeys[i] {
+			Fail(t, "public key", i, "didn't round trip")
+		}
+	}
+	hash, err := keyset.Hash()
+	Require(t, err)
+	if hexutil.Encode(hash[:]) != keysetHash {
+		Fail(t, "keyset hash", keysetHash, "doesn't match the keyset's", hash)
+	}
+
+	for _, args := range [][]string{
+		{"--assumed-honest", "2", "--pubkeys", strings.Join(append(pubKeys, pubKeys[1]), ",")},
+		{"--assumed-honest", "0", "--pubkeys", strings.Join(pubKeys, ",")},
+		{"--assumed-honest", "4", "--pubkeys", strings.Join(pubKeys, ",")},
+	} {
+		if err := keysetBuild(args, io.Discard); err == nil {
+			Fail(t, "built keyset with", args)
+		}
+	}
+}
+
+func TestKeysetDiff(t *testing.T) {
+	pubKeys := generatePubKeys(t, 4)
+	currentHex, currentHash := buildKeyset(t, 2, pubKeys[0], pubKeys[1], pubKeys[2])
+	newHex, newHash := buildKeyset(t, 1, pubKeys[1], pubKeys[0], pubKeys[3])
+	newFile := filepath.Join(t.TempDir(), "keyset")
+	Require(t, os.WriteFile(newFile, []byte(newHex+"\n"), 0600))
+
+	output := runKeysetCommand(t, keysetDiff, "--current", currentHex, "--new", newFile)
+	expected := "Current KeysetHash: " + currentHash + "\n" +
+		"New KeysetHash: " + newHash + "\n" +
+		"AssumedHonest: 2 -> 1\n" +
+		"Moved signer 1 -> 0: " + pubKeys[1] + "\n" +
+		"Moved signer 0 -> 1: " + pubKeys[0] + "\n" +
+		"Added signer 2: " + pubKeys[3] + "\n" +
+		"Removed signer 2: " + pubKeys[2] + "\n"
+	if output != expected {
+		Fail(t, "unexpected diff", output, "expected", expected)
+	}
+
+	output = runKeysetCommand(t, keysetDiff, "--current", newFile, "--new", newHex)
+	if !strings.HasSuffix(output, "Keysets are identical\n") {
+		Fail(t, "identical keysets reported as different", output)
+	}
+}
+
+func TestKeysetCalldata(t *testing.T) {
+	keysetHex, keysetHash := buildKeyset(t, 1, generatePubKeys(t, 2)...)
+	output := runKeysetCommand(t, keysetCalldata, "--keyset", keysetHex, "--invalidate", keysetHash)
+	if outputField(t, output, "KeysetHash") != keysetHash {
+		Fail(t, "unexpected keyset hash", output)
+	}
+	seqInboxABI, err := bridgegen.SequencerInboxMetaData.GetAbi()
+	Require(t, err)
+
+	unpack := func(field string, signature string) interface{} {
+		t.Helper()
+		calldata, err := hexutil.Decode(outputField(t, output, field))
+		Require(t, err)
+		if !bytes.Equal(calldata[:4], crypto.Keccak256([]byte(signature))[:4]) {
+			Fail(t, field, "doesn't call", signature)
+		}
+		method, err := seqInboxABI.MethodById(calldata[:4])
+		Require(t, err)
+		args, err := method.Inputs.Unpack(calldata[4:])
+		Require(t, err)
+		if len(args) != 1 {
+			Fail(t, field, "has", len(args), "arguments")
+		}
+		return args[0]
+	}
+
+	keysetBytes, ok := unpack("setValidKeyset calldata", "setValidKeyset(bytes)").([]byte)
+	if !ok || hexutil.Encode(keysetBytes) != keysetHex {
+		Fail(t, "setValidKeyset calldata doesn't contain the keyset")
+	}
+	hash, ok := unpack("invalidateKeysetHash calldata", "invalidateKeysetHash(bytes32)").([32]byte)
+	if !ok || common.Hash(hash).Hex() != keysetHash {
+		Fail(t, "invalidateKeysetHash calldata doesn't contain the keyset hash")
+	}
+
+	for _, args := range [][]string{
+		{},
+		{"--invalidate", "0x1234"},
+		{"--keyset", "0x1234"},
+	} {
+		if err := keysetCalldata(args, io.Discard); err == nil {
+			Fail(t, "made calldata with", args)
+		}
+	}
+}
+
+func Require(t *testing.T, err error, printables ...interface{}) {
+	t.Helper()
+	testhelpers.RequireImpl(t, err, printables...)
+}
+
+func Fail(t *testing.T, printables ...interface{}) {
+	t.Helper()
+	testhelpers.FailImpl(t, printables...)
+}
@@ -0,0 +1,265 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/broadcastclient"
+	"github.com/offchainlabs/nitro/broadcaster"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+	"github.com/offchainlabs/nitro/feedrecorder"
+	"github.com/offchainlabs/nitro/wsbroadcastserver"
+)
+
+func main() {
+	args := os.Args
+	if len(args) < 2 {
+		panic("Usage: feedrecorder [record|replay] ...")
+	}
+
+	var err error
+	switch strings.ToLower(args[1]) {
+	case "record":
+		err = startRecord(args[2:])
+	case "replay":
+		err = startReplay(args[2:])
+	default:
+		panic(fmt.Sprintf("Unknown tool '%s' specified, valid tools are 'record', 'replay'", args[1]))
+	}
+	if err != nil {
+		panic(err)
+	}
+}
+
+func setupLogging(logLevel int) {
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(logLevel))
+	log.Root().SetHandler(glogger)
+}
+
+// feedrecorder record
+
+type RecordConfig struct {
+	Conf     genericconf.ConfConfig `koanf:"conf"`
+	LogLevel int                    `koanf:"log-level"`
+	ChainId  uint64                 `koanf:"chain-id"`
+	Output   string                 `koanf:"output"`
+	Feed     broadcastclient.Config `koanf:"feed"`
+}
+
+func parseRecordConfig(args []string) (*RecordConfig, error) {
+	f := flag.NewFlagSet("feedrecorder record", flag.ContinueOnError)
+	f.Int("log-level", int(log.LvlInfo), "log level")
+	f.Uint64("chain-id", 0, "L2 chain ID of the feed")
+	f.String("output", "", "file to record the feed to, which is appended to if it's an existing recording")
+	broadcastclient.ConfigAddOptions("feed", f)
+	genericconf.ConfConfigAddOptions("conf", f)
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var config RecordConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	if config.ChainId == 0 {
+		return nil, errors.New("--chain-id must be set")
+	}
+	if config.Output == "" {
+		return nil, errors.New("--output must be set")
+	}
+	if !config.Feed.Enable() || len(config.Feed.URL) != 1 {
+		return nil, errors.New("exactly one --feed.url must be set")
+	}
+	return &config, nil
+}
+
+func startRecord(args []string) error {
+	config, err := parseRecordConfig(args)
+	if err != nil {
+		return err
+	}
+	setupLogging(config.LogLevel)
+
+	var nextSeqNum arbutil.MessageIndex
+	writeHeader := true
+	if info, err := os.Stat(config.Output); err == nil && info.Size() > 0 {
+		last, found, err := feedrecorder.RepairRecording(config.Output)
+		if err != nil {
+			return err
+		}
+		if found {
+			nextSeqNum = last + 1
+		}
+		writeHeader = false
+		log.Info("appending to existing recording", "file", config.Output, "nextSequenceNumber", nextSeqNum)
+	}
+	file, err := os.OpenFile(config.Output, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer, err := feedrecorder.NewRecordingWriter(file, writeHeader)
+	if err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	recorder := feedrecorder.NewRecorder(writer)
+
+	// Messages are recorded with their signatures but not verified
+	feedConfig := config.Feed
+	feedConfig.Verify.Dangerous.AcceptMissing = true
+	fatalErrChan := make(chan error, 10)
+	client, err := broadcastclient.NewBroadcastClient(
+		func() *broadcastclient.Config { return &feedConfig },
+		feedConfig.URL[0],
+		config.ChainId,
+		nextSeqNum,
+		recorder,
+		nil,
+		fatalErrChan,
+		nil,
+		func(int32) {},
+	)
+	if err != nil {
+		return err
+	}
+
+	sigint := make(chan os.Signal, 1)
+	signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
+	client.Start(context.Background())
+	select {
+	case <-sigint:
+		log.Info("shutting down because of sigint")
+	case err = <-fatalErrChan:
+		log.Error("feed error, exiting", "err", err)
+	}
+	client.StopAndWait()
+	log.Info("finished recording", "file", config.Output, "recorded", recorder.Count())
+	return err
+}
+
+// feedrecorder replay
+
+type ReplayConfig struct {
+	Conf     genericconf.ConfConfig              `koanf:"conf"`
+	LogLevel int                                 `koanf:"log-level"`
+	ChainId  uint64                              `koanf:"chain-id"`
+	Input    string                              `koanf:"input"`
+	Speed    float64                             `koanf:"speed"`
+	Exit     bool                                `koanf:"exit"`
+	Output   wsbroadcastserver.BroadcasterConfig `koanf:"output"`
+}
+
+func parseReplayConfig(args []string) (*ReplayConfig, error) {
+	f := flag.NewFlagSet("feedrecorder replay", flag.ContinueOnError)
+	f.Int("log-level", int(log.LvlInfo), "log level")
+	f.Uint64("chain-id", 0, "L2 chain ID to serve the feed for")
+	f.String("input", "", "recording to replay")
+	f.Float64("speed", 1, "speed to replay at relative to the original feed, or 0 to replay as fast as possible")
+	f.Bool("exit", false, "exit once the recording has been replayed, instead of continuing to serve it to new clients")
+	wsbroadcastserver.BroadcasterConfigAddOptions("output", f)
+	genericconf.ConfConfigAddOptions("conf", f)
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var config ReplayConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	if config.ChainId == 0 {
+		return nil, errors.New("--chain-id must be set")
+	}
+	if config.Input == "" {
+		return nil, errors.New("--input must be set")
+	}
+	if config.Speed < 0 {
+		return nil, errors.New("--speed must not be negative")
+	}
+	if err := config.Output.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func startReplay(args []string) error {
+	config, err := parseReplayConfig(args)
+	if err != nil {
+		return err
+	}
+	setupLogging(config.LogLevel)
+
+	file, err := os.Open(config.Input)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader, err := feedrecorder.NewRecordingReader(file)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fatalErrChan := make(chan error, 10)
+	// Messages are replayed with their recorded signatures, not re-signed
+	b := broadcaster.NewBroadcaster(func() *wsbroadcastserver.BroadcasterConfig { return &config.Output }, config.ChainId, fatalErrChan, nil)
+	if err := b.Initialize(); err != nil {
+		return err
+	}
+	if err := b.Start(ctx); err != nil {
+		return err
+	}
+	defer b.StopAndWait()
+	log.Info("serving recording", "file", config.Input, "address", b.ListenerAddr(), "speed", config.Speed)
+
+	replayDone := make(chan error, 1)
+	go func() {
+		count, err := feedrecorder.Replay(ctx, reader, b, config.Speed)
+		log.Info("finished replaying recording", "replayed", count)
+		replayDone <- err
+	}()
+
+	sigint := make(chan os.Signal, 1)
+	signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
+	for {
+		select {
+		case <-sigint:
+			log.Info("shutting down because of sigint")
+			return nil
+		case err := <-fatalErrChan:
+			return err
+		case err := <-replayDone:
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				// The recorder was stopped while writing a record
+				log.Warn("recording ends with an incomplete record")
+				err = nil
+			}
+			if err != nil || config.Exit {
+				return err
+			}
+		}
+	}
+}
@@ -4,9 +4,13 @@
 package genericconf
 
 import (
+	"errors"
+	"fmt"
 	"path"
 	"path/filepath"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	flag "github.com/spf13/pflag"
 )
 
@@ -18,6 +22,9 @@ type WalletConfig struct {
 	PrivateKey    string `koanf:"private-key"`
 	Account       string `koanf:"account"`
 	OnlyCreateKey bool   `koanf:"only-create-key"`
+	// If the external signer is enabled, it signs the wallet's parent chain
+	// transactions in place of its key.
+	ExternalSigner ExternalSignerConfig `koanf:"external-signer"`
 }
 
 func (w *WalletConfig) Pwd() *string {
@@ -28,11 +35,12 @@ func (w *WalletConfig) Pwd() *string {
 }
 
 var WalletConfigDefault = WalletConfig{
-	Pathname:      "",
-	Password:      PASSWORD_NOT_SET,
-	PrivateKey:    "",
-	Account:       "",
-	OnlyCreateKey: false,
+	Pathname:       "",
+	Password:       PASSWORD_NOT_SET,
+	PrivateKey:     "",
+	Account:        "",
+	OnlyCreateKey:  false,
+	ExternalSigner: DefaultExternalSignerConfig,
 }
 
 func WalletConfigAddOptions(prefix string, f *flag.FlagSet, defaultPathname string) {
@@ -41,6 +49,76 @@ func WalletConfigAddOptions(prefix string, f *flag.FlagSet, defaultPathname stri
 	f.String(prefix+".private-key", WalletConfigDefault.PrivateKey, "private key for wallet")
 	f.String(prefix+".account", WalletConfigDefault.Account, "account to use (default is first account in keystore)")
 	f.Bool(prefix+".only-create-key", WalletConfigDefault.OnlyCreateKey, "if true, creates new key then exits")
+	ExternalSignerConfigAddOptions(prefix+".external-signer", f)
+}
+
+type ExternalSignerConfig struct {
+	// URL of the external signer rpc server. If set, transactions are signed
+	// by the external signer instead of with the wallet's key.
+	URL string `koanf:"url"`
+	// Hex encoded ethereum address of the external signer.
+	Address string `koanf:"address"`
+	// API method name (e.g. eth_signTransaction).
+	Method string `koanf:"method"`
+	// (Optional) Path to the external signer root CA certificate.
+	// This allows us to use self-signed certificates on the external signer.
+	RootCA string `koanf:"root-ca"`
+	// (Optional) Client certificate for mtls.
+	ClientCert string `koanf:"client-cert"`
+	// (Optional) Client certificate key for mtls.
+	ClientPrivateKey string `koanf:"client-private-key"`
+	// How long to wait for the external signer to respond.
+	Timeout time.Duration `koanf:"timeout"`
+}
+
+var DefaultExternalSignerConfig = ExternalSignerConfig{
+	URL:              "",
+	Address:          "",
+	Method:           "eth_signTransaction",
+	RootCA:           "",
+	ClientCert:       "",
+	ClientPrivateKey: "",
+	Timeout:          time.Second * 30,
+}
+
+func ExternalSignerConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".url", DefaultExternalSignerConfig.URL, "external signer url")
+	f.String(prefix+".address", DefaultExternalSignerConfig.Address, "external signer address")
+	f.String(prefix+".method", DefaultExternalSignerConfig.Method, "external signer method")
+	f.String(prefix+".root-ca", DefaultExternalSignerConfig.RootCA, "external signer root CA")
+	f.String(prefix+".client-cert", DefaultExternalSignerConfig.ClientCert, "rpc client cert")
+	f.String(prefix+".client-private-key", DefaultExternalSignerConfig.ClientPrivateKey, "rpc client private key")
+	f.Duration(prefix+".timeout", DefaultExternalSignerConfig.Timeout, "how long to wait for the external signer to sign a transaction")
+}
+
+func (c *ExternalSignerConfig) Enabled() bool {
+	return c.URL != ""
+}
+
+func (c *ExternalSignerConfig) Validate() error {
+	if !c.Enabled() {
+		if c.Address != "" || c.RootCA != "" || c.ClientCert != "" || c.ClientPrivateKey != "" {
+			return errors.New("external signer url must be set to use the external signer")
+		}
+		return nil
+	}
+	if c.Method == "" {
+		return errors.New("external signer method must be set")
+	}
+	if !common.IsHexAddress(c.Address) {
+		return fmt.Errorf("invalid external signer address \"%v\"", c.Address)
+	}
+	if c.Timeout <= 0 {
+		return errors.New("external signer timeout must be positive")
+	}
+	if (c.ClientCert == "") != (c.ClientPrivateKey == "") {
+		return errors.New("external signer client-cert and client-private-key must be set together")
+	}
+	return nil
+}
+
+func (w *WalletConfig) Validate() error {
+	return w.ExternalSigner.Validate()
 }
 
 func (w *WalletConfig) ResolveDirectoryNames(chain string) {
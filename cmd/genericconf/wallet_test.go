@@ -0,0 +1,41 @@
+package genericconf
+
+import (
+	"testing"
+
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestExternalSignerConfigValidate(t *testing.T) {
+	valid := DefaultExternalSignerConfig
+	valid.URL = "https://localhost:1234"
+	valid.Address = "0x1111111111111111111111111111111111111111"
+	testhelpers.RequireImpl(t, valid.Validate())
+	disabled := DefaultExternalSignerConfig
+	testhelpers.RequireImpl(t, disabled.Validate())
+	withClientCert := valid
+	withClientCert.ClientCert = "client.crt"
+	withClientCert.ClientPrivateKey = "client.key"
+	testhelpers.RequireImpl(t, withClientCert.Validate())
+
+	for name, modify := range map[string]func(*ExternalSignerConfig){
+		"no url":              func(c *ExternalSignerConfig) { c.URL = "" },
+		"no method":           func(c *ExternalSignerConfig) { c.Method = "" },
+		"invalid address":     func(c *ExternalSignerConfig) { c.Address = "0x1234" },
+		"no timeout":          func(c *ExternalSignerConfig) { c.Timeout = 0 },
+		"client cert only":    func(c *ExternalSignerConfig) { c.ClientCert = "client.crt" },
+		"client key only":     func(c *ExternalSignerConfig) { c.ClientPrivateKey = "client.key" },
+		"root ca without url": func(c *ExternalSignerConfig) { c.URL, c.Address, c.RootCA = "", "", "ca.crt" },
+	} {
+		config := valid
+		modify(&config)
+		if err := config.Validate(); err == nil {
+			testhelpers.FailImpl(t, "external signer config with", name, "passed validation")
+		}
+		wallet := WalletConfigDefault
+		wallet.ExternalSigner = config
+		if err := wallet.Validate(); err == nil {
+			testhelpers.FailImpl(t, "wallet config with an external signer with", name, "passed validation")
+		}
+	}
+}
@@ -41,6 +41,7 @@ import (
 	"github.com/ethereum/go-ethereum/node"
 
 	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/arbnode/dataposter"
 	"github.com/offchainlabs/nitro/arbnode/execution"
 	"github.com/offchainlabs/nitro/arbnode/resourcemanager"
 	"github.com/offchainlabs/nitro/arbutil"
@@ -238,8 +239,6 @@ func mainImpl() int {
 	var dataSigner signature.DataSignerFunc
 	var l1TransactionOptsValidator *bind.TransactOpts
 	var l1TransactionOptsBatchPoster *bind.TransactOpts
-	sequencerNeedsKey := (nodeConfig.Node.Sequencer.Enable && !nodeConfig.Node.Feed.Output.DisableSigning) || nodeConfig.Node.BatchPoster.Enable
-	validatorNeedsKey := nodeConfig.Node.Staker.OnlyCreateWalletContract || nodeConfig.Node.Staker.Enable && !strings.EqualFold(nodeConfig.Node.Staker.Strategy, "watchtower")
 
 	l1Wallet.ResolveDirectoryNames(nodeConfig.Persistent.Chain)
 	defaultL1WalletConfig := conf.DefaultL1WalletConfig
@@ -253,7 +252,21 @@ func mainImpl() int {
 	defaultBatchPosterL1WalletConfig := arbnode.DefaultBatchPosterL1WalletConfig
 	defaultBatchPosterL1WalletConfig.ResolveDirectoryNames(nodeConfig.Persistent.Chain)
 
-	if nodeConfig.Node.Staker.ParentChainWallet == defaultValidatorL1WalletConfig && nodeConfig.Node.BatchPoster.ParentChainWallet == defaultBatchPosterL1WalletConfig {
+	// The batch poster and validator share the parent chain wallet unless either has its own
+	sharedL1Wallet := nodeConfig.Node.Staker.ParentChainWallet == defaultValidatorL1WalletConfig && nodeConfig.Node.BatchPoster.ParentChainWallet == defaultBatchPosterL1WalletConfig
+	batchPosterSigner := &nodeConfig.Node.BatchPoster.ParentChainWallet.ExternalSigner
+	validatorSigner := &nodeConfig.Node.Staker.ParentChainWallet.ExternalSigner
+	if sharedL1Wallet {
+		batchPosterSigner = &l1Wallet.ExternalSigner
+		validatorSigner = &l1Wallet.ExternalSigner
+	}
+	batchPosterExternalSigner := nodeConfig.Node.BatchPoster.Enable && batchPosterSigner.Enabled()
+	// The validator's wallet is also used to create its wallet contract, without the staker enabled
+	validatorExternalSigner := (nodeConfig.Node.Staker.Enable || nodeConfig.Node.Staker.OnlyCreateWalletContract) && validatorSigner.Enabled()
+	sequencerNeedsKey := (nodeConfig.Node.Sequencer.Enable && !nodeConfig.Node.Feed.Output.DisableSigning) || (nodeConfig.Node.BatchPoster.Enable && !batchPosterExternalSigner)
+	validatorNeedsKey := !validatorExternalSigner && (nodeConfig.Node.Staker.OnlyCreateWalletContract || nodeConfig.Node.Staker.Enable && !strings.EqualFold(nodeConfig.Node.Staker.Strategy, "watchtower"))
+
+	if sharedL1Wallet {
 		if sequencerNeedsKey || validatorNeedsKey || l1Wallet.OnlyCreateKey {
 			l1TransactionOpts, dataSigner, err = util.OpenWallet("l1", l1Wallet, new(big.Int).SetUint64(nodeConfig.ParentChain.ID))
 			if err != nil {
@@ -292,6 +305,20 @@ func mainImpl() int {
 		}
 	}
 
+	// An external signer takes the place of the parent chain wallet's key for the batch poster or the validator.
+	if batchPosterExternalSigner {
+		l1TransactionOptsBatchPoster, err = dataposter.ExternalSignerTxOpts(ctx, batchPosterSigner, new(big.Int).SetUint64(nodeConfig.ParentChain.ID))
+		if err != nil {
+			log.Crit("error creating Batch poster external signer", "url", batchPosterSigner.URL, "err", err)
+		}
+	}
+	if validatorExternalSigner {
+		l1TransactionOptsValidator, err = dataposter.ExternalSignerTxOpts(ctx, validatorSigner, new(big.Int).SetUint64(nodeConfig.ParentChain.ID))
+		if err != nil {
+			log.Crit("error creating Validator external signer", "url", validatorSigner.URL, "err", err)
+		}
+	}
+
 	combinedL2ChainInfoFile := nodeConfig.Chain.InfoFiles
 	if nodeConfig.Chain.InfoIpfsUrl != "" {
 		l2ChainInfoIpfsFile, err := util.GetL2ChainInfoIpfsFile(ctx, nodeConfig.Chain.InfoIpfsUrl, nodeConfig.Chain.InfoIpfsDownloadPath)
@@ -382,7 +409,7 @@ func mainImpl() int {
 		nodeConfig.Node.TxLookupLimit = 0
 	}
 
-	if err := resourcemanager.Init(&nodeConfig.Node.ResourceMgmt); err != nil {
+	if err := resourcemanager.Init(func() *resourcemanager.Config { return &liveNodeConfig.Get().Node.ResourceMgmt }); err != nil {
 		flag.Usage()
 		log.Crit("Failed to start resource management module", "err", err)
 	}
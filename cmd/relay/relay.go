@@ -62,7 +62,8 @@ func startMetrics(cfg *relay.Config) error {
 func startup() error {
 	ctx := context.Background()
 
-	relayConfig, err := relay.ParseRelay(ctx, os.Args[1:])
+	args := os.Args[1:]
+	relayConfig, err := relay.ParseRelay(ctx, args)
 	if err != nil || len(relayConfig.Node.Feed.Input.URL) == 0 || relayConfig.Node.Feed.Input.URL[0] == "" || relayConfig.Chain.ID == 0 {
 		confighelpers.PrintErrorAndExit(err, printSampleUsage)
 	}
@@ -86,7 +87,9 @@ func startup() error {
 
 	// Start up an arbitrum sequencer relay
 	feedErrChan := make(chan error, 10)
-	newRelay, err := relay.NewRelay(relayConfig, feedErrChan)
+	// API keys and other hot options are reloaded on SIGUSR1 or every conf.reload-interval
+	liveConfig := genericconf.NewLiveConfig[*relay.Config](args, relayConfig, relay.ParseRelay)
+	newRelay, err := relay.NewRelay(liveConfig.Get, feedErrChan)
 	if err != nil {
 		return err
 	}
@@ -98,6 +101,8 @@ func startup() error {
 	if err := newRelay.Start(ctx); err != nil {
 		return err
 	}
+	liveConfig.Start(ctx)
+	defer liveConfig.StopAndWait()
 
 	select {
 	case <-sigint:
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/enescakir/emoji"
 	"github.com/ethereum/go-ethereum/log"
@@ -27,6 +29,12 @@ var addSeqForm = tview.NewForm()
 var priorityForm = tview.NewForm()
 var nonPriorityForm = tview.NewForm()
 
+// Text views
+var historyView = tview.NewTextView().SetDynamicColors(true)
+
+// Number of the latest coordinator events shown in the history view
+const historyLength = 200
+
 // Sequencer coordinator managment UI data store
 type manager struct {
 	redisCoordinator *rediscoordinator.RedisCoordinator
@@ -155,7 +163,7 @@ func main() {
 		SetText("-----Not in priority list but online-----")
 	instructions := tview.NewTextView().
 		SetTextColor(tcell.ColorYellow).
-		SetText("(r) to refresh\n(s) to save all changes\n(c) to switch between lists\n(a) to add sequencer\n(q) to quit\n(tab) to navigate")
+		SetText("(r) to refresh\n(s) to save all changes\n(c) to switch between lists\n(a) to add sequencer\n(h) to view coordinator history\n(q) to quit\n(tab) to navigate")
 
 	flex.SetDirection(tview.FlexRow).
 		AddItem(priorityHeading, 0, 1, false).
@@ -195,6 +203,34 @@ func main() {
 				nonPriorityForm.Clear(true)
 				app.SetFocus(prioritySeqList)
 			}
+		} else if event.Rune() == 104 {
+			seqManager.populateHistory(ctx)
+			pages.SwitchToPage("History")
+			app.SetFocus(historyView)
+		} else if event.Rune() == 113 {
+			app.Stop()
+		}
+		return event
+	})
+
+	historyHeading := tview.NewTextView().
+		SetTextColor(tcell.ColorYellow).
+		SetText("-----Coordinator history (newest first)-----")
+	historyInstructions := tview.NewTextView().
+		SetTextColor(tcell.ColorYellow).
+		SetText("(r) to refresh\n(m) to return to the menu\n(q) to quit")
+	historyFlex := tview.NewFlex()
+	historyFlex.SetDirection(tview.FlexRow).
+		AddItem(historyHeading, 1, 0, false).
+		AddItem(historyView, 0, 1, true).
+		AddItem(historyInstructions, 3, 0, false).SetBorder(true)
+
+	historyFlex.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Rune() == 114 {
+			seqManager.populateHistory(ctx)
+		} else if event.Rune() == 109 {
+			pages.SwitchToPage("Menu")
+			app.SetFocus(prioritySeqList)
 		} else if event.Rune() == 113 {
 			app.Stop()
 		}
@@ -203,6 +239,7 @@ func main() {
 
 	pages.AddPage("Menu", flex, true, true)
 	pages.AddPage("Add Sequencer", addSeqForm, true, false)
+	pages.AddPage("History", historyFlex, true, false)
 
 	if err := app.SetRoot(pages, true).EnableMouse(true).Run(); err != nil {
 		panic(err)
@@ -253,6 +290,33 @@ func (sm *manager) populateLists(ctx context.Context) {
 	}
 }
 
+// populateHistory shows the latest coordinator events recorded by the sequencers
+func (sm *manager) populateHistory(ctx context.Context) {
+	history, err := sm.redisCoordinator.GetHistory(ctx, historyLength)
+	if err != nil {
+		historyView.SetText("[red]failed to read the coordinator history: " + tview.Escape(err.Error()))
+		return
+	}
+	if len(history) == 0 {
+		historyView.SetText("no coordinator events recorded")
+		return
+	}
+	var text strings.Builder
+	for _, event := range history {
+		line := fmt.Sprintf("%s [green]%-15s[white] %s", event.Time.Format(time.RFC3339), tview.Escape(event.Event), tview.Escape(event.Sequencer))
+		if event.Chosen != "" {
+			line += fmt.Sprintf(" %v %s", emoji.RightArrow, tview.Escape(event.Chosen))
+		}
+		line += fmt.Sprintf(" msgCount=%d", event.MsgCount)
+		if event.Reason != "" {
+			line += fmt.Sprintf(" (%s)", tview.Escape(event.Reason))
+		}
+		text.WriteString(line + "\n")
+	}
+	historyView.SetText(text.String())
+	historyView.ScrollToBeginning()
+}
+
 // addSeqPriorityForm returns a form with fields to add a new sequencer to priority list
 func (sm *manager) addSeqPriorityForm(ctx context.Context) *tview.Form {
 	URL := ""
@@ -4,8 +4,10 @@
 package das
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/allegro/bigcache"
@@ -82,6 +84,15 @@ func (bcs *BigCacheStorageService) GetByHash(ctx context.Context, key common.Has
 	return ret, err
 }
 
+// OpenByHash returns the cached data if present, and otherwise opens the data
+// from the base storage service without caching it.
+func (bcs *BigCacheStorageService) OpenByHash(ctx context.Context, key common.Hash) (io.ReadSeekCloser, error) {
+	if data, err := bcs.bigCache.Get(string(key.Bytes())); err == nil {
+		return readSeekNopCloser{bytes.NewReader(data)}, nil
+	}
+	return openByHash(ctx, bcs.baseStorageService, key)
+}
+
 func (bcs *BigCacheStorageService) Put(ctx context.Context, value []byte, timeout uint64) error {
 	logPut("das.BigCacheStorageService.Put", value, timeout, bcs)
 	err := bcs.baseStorageService.Put(ctx, value, timeout)
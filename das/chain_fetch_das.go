@@ -4,8 +4,10 @@
 package das
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"io"
 	"sync"
 
 	"github.com/offchainlabs/nitro/arbstate"
@@ -66,6 +68,23 @@ func (c *ChainFetchReader) GetByHash(ctx context.Context, hash common.Hash) ([]b
 	log.Trace("das.ChainFetchReader.GetByHash", "hash", pretty.PrettyHash(hash))
 	return chainFetchGetByHash(ctx, c.DataAvailabilityReader, &c.keysetCache, c.seqInboxCaller, c.seqInboxFilterer, hash)
 }
+
+// OpenByHash opens the data from the inner reader, falling back to GetByHash
+// for keysets which are only available from the chain.
+func (c *ChainFetchReader) OpenByHash(ctx context.Context, hash common.Hash) (io.ReadSeekCloser, error) {
+	if res, ok := c.keysetCache.get(hash); ok {
+		return readSeekNopCloser{bytes.NewReader(res)}, nil
+	}
+	if content, err := openByHash(ctx, c.DataAvailabilityReader, hash); err == nil {
+		return content, nil
+	}
+	data, err := c.GetByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return readSeekNopCloser{bytes.NewReader(data)}, nil
+}
+
 func (c *ChainFetchReader) String() string {
 	return "ChainFetchReader"
 }
@@ -43,11 +43,12 @@ type DataAvailabilityConfig struct {
 	LocalCache BigCacheConfig `koanf:"local-cache"`
 	RedisCache RedisConfig    `koanf:"redis-cache"`
 
-	LocalDBStorage     LocalDBStorageConfig     `koanf:"local-db-storage"`
-	LocalFileStorage   LocalFileStorageConfig   `koanf:"local-file-storage"`
-	S3Storage          S3StorageServiceConfig   `koanf:"s3-storage"`
-	IpfsStorage        IpfsStorageServiceConfig `koanf:"ipfs-storage"`
-	RegularSyncStorage RegularSyncStorageConfig `koanf:"regular-sync-storage"`
+	LocalDBStorage      LocalDBStorageConfig             `koanf:"local-db-storage"`
+	LocalFileStorage    LocalFileStorageConfig           `koanf:"local-file-storage"`
+	S3Storage           S3StorageServiceConfig           `koanf:"s3-storage"`
+	S3CompatibleStorage S3CompatibleStorageServiceConfig `koanf:"s3-compatible-storage"`
+	IpfsStorage         IpfsStorageServiceConfig         `koanf:"ipfs-storage"`
+	RegularSyncStorage  RegularSyncStorageConfig         `koanf:"regular-sync-storage"`
 
 	Key KeyConfig `koanf:"key"`
 
@@ -115,6 +116,7 @@ func dataAvailabilityConfigAddOptions(prefix string, f *flag.FlagSet, r role) {
 		LocalDBStorageConfigAddOptions(prefix+".local-db-storage", f)
 		LocalFileStorageConfigAddOptions(prefix+".local-file-storage", f)
 		S3ConfigAddOptions(prefix+".s3-storage", f)
+		S3CompatibleConfigAddOptions(prefix+".s3-compatible-storage", f)
 		RegularSyncStorageConfigAddOptions(prefix+".regular-sync-storage", f)
 
 		// Key config for storage
@@ -45,7 +45,7 @@ func CreatePersistentStorageService(
 	}
 
 	if config.LocalFileStorage.Enable {
-		s, err := NewLocalFileStorageService(config.LocalFileStorage.DataDir)
+		s, err := NewLocalFileStorageService(ctx, config.LocalFileStorage)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -78,6 +78,23 @@ func CreatePersistentStorageService(
 		storageServices = append(storageServices, s)
 	}
 
+	if config.S3CompatibleStorage.Enable {
+		s, err := NewS3CompatibleStorageService(config.S3CompatibleStorage)
+		if err != nil {
+			return nil, nil, err
+		}
+		lifecycleManager.Register(s)
+		if config.S3CompatibleStorage.SyncFromStorageService {
+			iterableStorageService := NewIterableStorageService(ConvertStorageServiceToIterationCompatibleStorageService(s))
+			*syncFromStorageServices = append(*syncFromStorageServices, iterableStorageService)
+			s = iterableStorageService
+		}
+		if config.S3CompatibleStorage.SyncToStorageService {
+			*syncToStorageServices = append(*syncToStorageServices, s)
+		}
+		storageServices = append(storageServices, s)
+	}
+
 	if config.IpfsStorage.Enable {
 		s, err := NewIpfsStorageService(ctx, config.IpfsStorage)
 		if err != nil {
@@ -203,8 +220,9 @@ func CreateDAComponentsForDaserver(
 	if !config.LocalDBStorage.Enable &&
 		!config.LocalFileStorage.Enable &&
 		!config.S3Storage.Enable &&
+		!config.S3CompatibleStorage.Enable &&
 		!config.IpfsStorage.Enable {
-		return nil, nil, nil, nil, errors.New("At least one of --data-availability.(local-db-storage|local-file-storage|s3-storage|ipfs-storage) must be enabled.")
+		return nil, nil, nil, nil, errors.New("At least one of --data-availability.(local-db-storage|local-file-storage|s3-storage|s3-compatible-storage|ipfs-storage) must be enabled.")
 	}
 	// Done checking config requirements
 
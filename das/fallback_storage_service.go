@@ -4,7 +4,9 @@
 package das
 
 import (
+	"bytes"
 	"context"
+	"io"
 	"sync"
 	"time"
 
@@ -64,33 +66,64 @@ func (f *FallbackStorageService) GetByHash(ctx context.Context, key common.Hash)
 
 	data, err := f.StorageService.GetByHash(ctx, key)
 	if err != nil {
-		doDelete := false
-		if f.preventRecursiveGets {
-			f.currentlyFetchingMutex.Lock()
-			if !f.currentlyFetching[key] {
-				f.currentlyFetching[key] = true
-				doDelete = true
-			}
-			f.currentlyFetchingMutex.Unlock()
+		return f.getFromBackup(ctx, key)
+	}
+	return data, err
+}
+
+// OpenByHash opens the data from the primary storage service, and otherwise
+// gets it from the backup as GetByHash does.
+func (f *FallbackStorageService) OpenByHash(ctx context.Context, key common.Hash) (io.ReadSeekCloser, error) {
+	log.Trace("das.FallbackStorageService.OpenByHash", "key", pretty.PrettyHash(key), "this", f)
+	if f.preventRecursiveGets {
+		f.currentlyFetchingMutex.RLock()
+		if f.currentlyFetching[key] {
+			// This is a recursive call, so return not-found
+			f.currentlyFetchingMutex.RUnlock()
+			return nil, ErrNotFound
 		}
-		log.Trace("das.FallbackStorageService.GetByHash trying fallback")
-		data, err = f.backup.GetByHash(ctx, key)
-		if doDelete {
-			f.currentlyFetchingMutex.Lock()
-			delete(f.currentlyFetching, key)
-			f.currentlyFetchingMutex.Unlock()
+		f.currentlyFetchingMutex.RUnlock()
+	}
+
+	if content, err := openByHash(ctx, f.StorageService, key); err == nil {
+		return content, nil
+	}
+	data, err := f.getFromBackup(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return readSeekNopCloser{bytes.NewReader(data)}, nil
+}
+
+// getFromBackup gets data missing from the primary storage service from the
+// backup, and stores it in the primary for the retention period.
+func (f *FallbackStorageService) getFromBackup(ctx context.Context, key common.Hash) ([]byte, error) {
+	doDelete := false
+	if f.preventRecursiveGets {
+		f.currentlyFetchingMutex.Lock()
+		if !f.currentlyFetching[key] {
+			f.currentlyFetching[key] = true
+			doDelete = true
 		}
-		if err != nil {
+		f.currentlyFetchingMutex.Unlock()
+	}
+	log.Trace("das.FallbackStorageService.GetByHash trying fallback")
+	data, err := f.backup.GetByHash(ctx, key)
+	if doDelete {
+		f.currentlyFetchingMutex.Lock()
+		delete(f.currentlyFetching, key)
+		f.currentlyFetchingMutex.Unlock()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if dastree.ValidHash(key, data) {
+		putErr := f.StorageService.Put(
+			ctx, data, arbmath.SaturatingUAdd(uint64(time.Now().Unix()), f.backupRetentionSeconds),
+		)
+		if putErr != nil && !f.ignoreRetentionWriteErrors {
 			return nil, err
 		}
-		if dastree.ValidHash(key, data) {
-			putErr := f.StorageService.Put(
-				ctx, data, arbmath.SaturatingUAdd(uint64(time.Now().Unix()), f.backupRetentionSeconds),
-			)
-			if putErr != nil && !f.ignoreRetentionWriteErrors {
-				return nil, err
-			}
-		}
 	}
 	return data, err
 }
@@ -5,6 +5,7 @@ package das
 
 import (
 	"context"
+	"io"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -36,6 +37,10 @@ func (i *IterationCompatibleStorageServiceAdaptor) putKeyValue(ctx context.Conte
 	return nil
 }
 
+func (i *IterationCompatibleStorageServiceAdaptor) OpenByHash(ctx context.Context, key common.Hash) (io.ReadSeekCloser, error) {
+	return openByHash(ctx, i.StorageService, key)
+}
+
 func ConvertStorageServiceToIterationCompatibleStorageService(storageService StorageService) IterationCompatibleStorageService {
 	service, ok := storageService.(IterationCompatibleStorageService)
 	if ok {
@@ -60,6 +65,10 @@ func NewIterableStorageService(storageService IterationCompatibleStorageService)
 	return i
 }
 
+func (i *IterableStorageService) OpenByHash(ctx context.Context, key common.Hash) (io.ReadSeekCloser, error) {
+	return openByHash(ctx, i.IterationCompatibleStorageService, key)
+}
+
 func (i *IterableStorageService) Put(ctx context.Context, data []byte, expiration uint64) error {
 	dataHash := dastree.Hash(data)
 
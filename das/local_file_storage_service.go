@@ -9,7 +9,12 @@ import (
 	"encoding/base32"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -17,19 +22,24 @@ import (
 	"github.com/offchainlabs/nitro/arbstate"
 	"github.com/offchainlabs/nitro/das/dastree"
 	"github.com/offchainlabs/nitro/util/pretty"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
 	flag "github.com/spf13/pflag"
 	"golang.org/x/sys/unix"
 )
 
 type LocalFileStorageConfig struct {
-	Enable                 bool   `koanf:"enable"`
-	DataDir                string `koanf:"data-dir"`
-	SyncFromStorageService bool   `koanf:"sync-from-storage-service"`
-	SyncToStorageService   bool   `koanf:"sync-to-storage-service"`
+	Enable                 bool          `koanf:"enable"`
+	DataDir                string        `koanf:"data-dir"`
+	SyncFromStorageService bool          `koanf:"sync-from-storage-service"`
+	SyncToStorageService   bool          `koanf:"sync-to-storage-service"`
+	DiscardAfterTimeout    bool          `koanf:"discard-after-timeout"`
+	PruneInterval          time.Duration `koanf:"prune-interval"`
+	ShardedLayout          bool          `koanf:"sharded-layout"`
 }
 
 var DefaultLocalFileStorageConfig = LocalFileStorageConfig{
-	DataDir: "",
+	DataDir:       "",
+	PruneInterval: time.Hour,
 }
 
 func LocalFileStorageConfigAddOptions(prefix string, f *flag.FlagSet) {
@@ -37,45 +47,142 @@ func LocalFileStorageConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.String(prefix+".data-dir", DefaultLocalFileStorageConfig.DataDir, "local data directory")
 	f.Bool(prefix+".sync-from-storage-service", DefaultLocalFileStorageConfig.SyncFromStorageService, "enable local storage to be used as a source for regular sync storage")
 	f.Bool(prefix+".sync-to-storage-service", DefaultLocalFileStorageConfig.SyncToStorageService, "enable local storage to be used as a sink for regular sync storage")
+	f.Bool(prefix+".discard-after-timeout", DefaultLocalFileStorageConfig.DiscardAfterTimeout, "discard data after its expiry timeout")
+	f.Duration(prefix+".prune-interval", DefaultLocalFileStorageConfig.PruneInterval, "interval between deleting expired data, if discard-after-timeout is enabled")
+	f.Bool(prefix+".sharded-layout", DefaultLocalFileStorageConfig.ShardedLayout, "store files in subdirectories named after the first two bytes of their hash (data in the flat layout is still readable; datool migratelocalfiles moves it)")
 }
 
+// The expiry index is a directory of bucket files, each named after the unix
+// time its bucket starts at and listing the keys expiring in that bucket.
+// The authoritative expiry of a file is its modification time, so data stored
+// again with a later timeout survives the pruning of its earlier bucket.
+const (
+	expiryIndexDirName  = "expiry-index"
+	expiryBucketSeconds = 60 * 60
+)
+
+// Data which never expires has its modification time set to the unix epoch,
+// and isn't added to the expiry index.
+var neverExpires = time.Unix(0, 0)
+
 type LocalFileStorageService struct {
+	stopwaiter.StopWaiter
 	dataDir string
+	config  LocalFileStorageConfig
+
+	// Held while writing or pruning files, so that a file being stored again
+	// isn't deleted by the pruner based on its previous expiry.
+	mutex sync.Mutex
 }
 
-func NewLocalFileStorageService(dataDir string) (StorageService, error) {
+func NewLocalFileStorageService(ctx context.Context, config LocalFileStorageConfig) (StorageService, error) {
+	dataDir := config.DataDir
 	if unix.Access(dataDir, unix.W_OK|unix.R_OK) != nil {
 		return nil, fmt.Errorf("couldn't start LocalFileStorageService, directory '%s' must be readable and writeable", dataDir)
 	}
-	return &LocalFileStorageService{dataDir: dataDir}, nil
+	s := &LocalFileStorageService{dataDir: dataDir, config: config}
+	if config.DiscardAfterTimeout {
+		if err := os.MkdirAll(filepath.Join(dataDir, expiryIndexDirName), 0o700); err != nil {
+			return nil, err
+		}
+		if config.PruneInterval <= 0 {
+			return nil, errors.New("local file storage prune-interval must be positive when discarding data after timeout")
+		}
+		s.StopWaiter.Start(ctx, s)
+		s.CallIteratively(s.pruneExpired)
+	}
+	return s, nil
+}
+
+// shardedPath is where a key is stored in the sharded layout.
+func shardedPath(dataDir string, key common.Hash) string {
+	return filepath.Join(dataDir, fmt.Sprintf("%02x", key[0]), fmt.Sprintf("%02x", key[1]), EncodeStorageServiceKey(key))
+}
+
+// flatPath is where a key is stored in the flat layout.
+func flatPath(dataDir string, key common.Hash) string {
+	return filepath.Join(dataDir, EncodeStorageServiceKey(key))
+}
+
+func (s *LocalFileStorageService) writePath(key common.Hash) string {
+	if s.config.ShardedLayout {
+		return shardedPath(s.dataDir, key)
+	}
+	return flatPath(s.dataDir, key)
+}
+
+// readPaths lists every location a key may be stored at, in either layout.
+func (s *LocalFileStorageService) readPaths(key common.Hash) []string {
+	paths := []string{flatPath(s.dataDir, key), shardedPath(s.dataDir, key)}
+	if s.config.ShardedLayout {
+		paths[0], paths[1] = paths[1], paths[0]
+	}
+	// Just for backward compatability.
+	return append(paths, filepath.Join(s.dataDir, base32.StdEncoding.EncodeToString(key.Bytes())))
 }
 
 func (s *LocalFileStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
 	log.Trace("das.LocalFileStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", s)
-	pathname := s.dataDir + "/" + EncodeStorageServiceKey(key)
-	data, err := os.ReadFile(pathname)
-	if err != nil {
-		// Just for backward compatability.
-		pathname = s.dataDir + "/" + base32.StdEncoding.EncodeToString(key.Bytes())
-		data, err = os.ReadFile(pathname)
-		if err != nil {
-			if errors.Is(err, os.ErrNotExist) {
-				return nil, ErrNotFound
-			}
+	for _, pathname := range s.readPaths(key) {
+		data, err := os.ReadFile(pathname)
+		if err == nil {
+			return data, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
 			return nil, err
 		}
-		return data, nil
 	}
-	return data, nil
+	return nil, ErrNotFound
+}
+
+// OpenByHash opens the data with the given hash for reading, so that it can
+// be streamed instead of read into memory.
+func (s *LocalFileStorageService) OpenByHash(ctx context.Context, key common.Hash) (io.ReadSeekCloser, error) {
+	for _, pathname := range s.readPaths(key) {
+		file, err := os.Open(pathname)
+		if err == nil {
+			return file, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, ErrNotFound
 }
 
 func (s *LocalFileStorageService) Put(ctx context.Context, data []byte, timeout uint64) error {
 	logPut("das.LocalFileStorageService.Store", data, timeout, s)
-	fileName := EncodeStorageServiceKey(dastree.Hash(data))
-	finalPath := s.dataDir + "/" + fileName
+	var expiry time.Time
+	if s.config.DiscardAfterTimeout {
+		if timeout > math.MaxInt64 {
+			// Timeouts beyond any unix time, such as math.MaxUint64, mean forever.
+			expiry = neverExpires
+		} else {
+			expiry = time.Unix(int64(timeout), 0)
+		}
+	}
+	return s.writeFile(dastree.Hash(data), data, expiry)
+}
+
+// putKeyValue stores values which aren't data, such as the iterable storage's
+// links, so they're kept forever.
+func (s *LocalFileStorageService) putKeyValue(ctx context.Context, key common.Hash, value []byte) error {
+	return s.writeFile(key, value, neverExpires)
+}
+
+// writeFile atomically stores value under key. If expiry is set, it's
+// recorded in the file's modification time and in the expiry index.
+func (s *LocalFileStorageService) writeFile(key common.Hash, value []byte, expiry time.Time) error {
+	finalPath := s.writePath(key)
+	dir := filepath.Dir(finalPath)
+	if s.config.ShardedLayout {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return err
+		}
+	}
 
 	// Use a temp file and rename to achieve atomic writes.
-	f, err := os.CreateTemp(s.dataDir, fileName)
+	f, err := os.CreateTemp(dir, filepath.Base(finalPath))
 	if err != nil {
 		return err
 	}
@@ -83,7 +190,7 @@ func (s *LocalFileStorageService) Put(ctx context.Context, data []byte, timeout
 	if err != nil {
 		return err
 	}
-	_, err = f.Write(data)
+	_, err = f.Write(value)
 	if err != nil {
 		return err
 	}
@@ -92,34 +199,113 @@ func (s *LocalFileStorageService) Put(ctx context.Context, data []byte, timeout
 		return err
 	}
 
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !expiry.IsZero() {
+		// Never shorten the expiry of data which was already stored, in either layout.
+		for _, pathname := range s.readPaths(key) {
+			if expiry.Equal(neverExpires) {
+				break
+			}
+			if info, err := os.Stat(pathname); err == nil && (info.ModTime().Equal(neverExpires) || info.ModTime().After(expiry)) {
+				expiry = info.ModTime()
+			}
+		}
+		if err := os.Chtimes(f.Name(), time.Now(), expiry); err != nil {
+			return err
+		}
+		if !expiry.Equal(neverExpires) {
+			if err := s.addToExpiryIndex(key, expiry); err != nil {
+				return err
+			}
+		}
+	}
 	return os.Rename(f.Name(), finalPath)
-
 }
 
-func (s *LocalFileStorageService) putKeyValue(ctx context.Context, key common.Hash, value []byte) error {
-	fileName := EncodeStorageServiceKey(key)
-	finalPath := s.dataDir + "/" + fileName
+func expiryBucketPath(dataDir string, expiry time.Time) string {
+	bucket := expiry.Unix() - expiry.Unix()%expiryBucketSeconds
+	return filepath.Join(dataDir, expiryIndexDirName, strconv.FormatInt(bucket, 10))
+}
 
-	// Use a temp file and rename to achieve atomic writes.
-	f, err := os.CreateTemp(s.dataDir, fileName)
+// addToExpiryIndex must be called with the mutex held.
+func (s *LocalFileStorageService) addToExpiryIndex(key common.Hash, expiry time.Time) error {
+	f, err := os.OpenFile(expiryBucketPath(s.dataDir, expiry), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
 	if err != nil {
 		return err
 	}
-	err = f.Chmod(0o600)
-	if err != nil {
+	if _, err := f.Write(key.Bytes()); err != nil {
+		f.Close()
 		return err
 	}
-	_, err = f.Write(value)
-	if err != nil {
-		return err
+	return f.Close()
+}
+
+func (s *LocalFileStorageService) pruneExpired(ctx context.Context) time.Duration {
+	if err := s.prune(ctx, time.Now()); err != nil {
+		log.Error("error pruning expired local file storage data", "err", err)
 	}
-	err = f.Close()
+	return s.config.PruneInterval
+}
+
+// prune deletes the data which expired before now, going through the expiry
+// index buckets which ended before now.
+func (s *LocalFileStorageService) prune(ctx context.Context, now time.Time) error {
+	entries, err := os.ReadDir(filepath.Join(s.dataDir, expiryIndexDirName))
 	if err != nil {
 		return err
 	}
+	pruned := 0
+	for _, entry := range entries {
+		bucket, err := strconv.ParseInt(entry.Name(), 10, 64)
+		if err != nil {
+			continue
+		}
+		if bucket+expiryBucketSeconds > now.Unix() {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		n, err := s.pruneBucket(filepath.Join(s.dataDir, expiryIndexDirName, entry.Name()), now)
+		if err != nil {
+			return err
+		}
+		pruned += n
+	}
+	if pruned > 0 {
+		log.Info("pruned expired local file storage data", "files", pruned)
+	}
+	return nil
+}
 
-	return os.Rename(f.Name(), finalPath)
-
+func (s *LocalFileStorageService) pruneBucket(bucketPath string, now time.Time) (int, error) {
+	keys, err := os.ReadFile(bucketPath)
+	if err != nil {
+		return 0, err
+	}
+	pruned := 0
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for len(keys) >= common.HashLength {
+		key := common.BytesToHash(keys[:common.HashLength])
+		keys = keys[common.HashLength:]
+		for _, pathname := range s.readPaths(key) {
+			info, err := os.Stat(pathname)
+			if err != nil {
+				continue
+			}
+			if info.ModTime().Equal(neverExpires) || info.ModTime().After(now) {
+				// Stored again since, with a later expiry or to be kept forever.
+				continue
+			}
+			if err := os.Remove(pathname); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return pruned, err
+			}
+			pruned++
+		}
+	}
+	return pruned, os.Remove(bucketPath)
 }
 
 func (s *LocalFileStorageService) Sync(ctx context.Context) error {
@@ -127,10 +313,16 @@ func (s *LocalFileStorageService) Sync(ctx context.Context) error {
 }
 
 func (s *LocalFileStorageService) Close(ctx context.Context) error {
+	if s.Started() {
+		s.StopAndWait()
+	}
 	return nil
 }
 
 func (s *LocalFileStorageService) ExpirationPolicy(ctx context.Context) (arbstate.ExpirationPolicy, error) {
+	if s.config.DiscardAfterTimeout {
+		return arbstate.DiscardAfterDataTimeout, nil
+	}
 	return arbstate.KeepForever, nil
 }
 
@@ -153,3 +345,80 @@ func (s *LocalFileStorageService) HealthCheck(ctx context.Context) error {
 	}
 	return nil
 }
+
+// MigrateLocalFileStorageToShardedLayout moves the files of a local file
+// storage data directory from the flat layout to the sharded layout, and
+// returns how many were moved. The directory is read in chunks, so this
+// works for directories too large to list at once.
+func MigrateLocalFileStorageToShardedLayout(ctx context.Context, dataDir string) (uint64, error) {
+	var total uint64
+	for {
+		moved, err := migrateLocalFileStoragePass(ctx, dataDir)
+		total += moved
+		if err != nil {
+			return total, err
+		}
+		// Directory listings aren't guaranteed to be stable while entries
+		// are being moved out, so repeat until nothing is left to move.
+		if moved == 0 {
+			return total, nil
+		}
+	}
+}
+
+func migrateLocalFileStoragePass(ctx context.Context, dataDir string) (uint64, error) {
+	dir, err := os.Open(dataDir)
+	if err != nil {
+		return 0, err
+	}
+	defer dir.Close()
+	var moved uint64
+	for {
+		if ctx.Err() != nil {
+			return moved, ctx.Err()
+		}
+		entries, err := dir.ReadDir(1024)
+		if errors.Is(err, io.EOF) {
+			return moved, nil
+		}
+		if err != nil {
+			return moved, err
+		}
+		for _, entry := range entries {
+			if !entry.Type().IsRegular() {
+				continue
+			}
+			key, ok := flatLayoutKey(entry.Name())
+			if !ok {
+				// Not a stored file, e.g. a leftover temp file.
+				continue
+			}
+			newPath := shardedPath(dataDir, key)
+			if err := os.MkdirAll(filepath.Dir(newPath), 0o700); err != nil {
+				return moved, err
+			}
+			if err := os.Rename(filepath.Join(dataDir, entry.Name()), newPath); err != nil {
+				return moved, err
+			}
+			moved++
+			if moved%100000 == 0 {
+				log.Info("migrating local file storage to sharded layout", "moved", moved)
+			}
+		}
+	}
+}
+
+// flatLayoutKey returns the key a file in the flat layout is stored under.
+func flatLayoutKey(name string) (common.Hash, bool) {
+	if len(name) == 2*common.HashLength {
+		key, err := DecodeStorageServiceKey(name)
+		return key, err == nil
+	}
+	if len(name) == base32.StdEncoding.EncodedLen(common.HashLength) {
+		decoded, err := base32.StdEncoding.DecodeString(name)
+		if err == nil && len(decoded) == common.HashLength {
+			return common.BytesToHash(decoded), true
+		}
+	}
+	return common.Hash{}, false
+}
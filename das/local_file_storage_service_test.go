@@ -0,0 +1,148 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+func TestLocalFileStoragePruning(t *testing.T) {
+	ctx := context.Background()
+	config := DefaultLocalFileStorageConfig
+	config.DataDir = t.TempDir()
+	config.DiscardAfterTimeout = true
+	config.ShardedLayout = true
+	config.PruneInterval = time.Hour
+	s, err := NewLocalFileStorageService(ctx, config)
+	Require(t, err)
+	defer s.Close(ctx)
+	storage := s.(*LocalFileStorageService)
+
+	now := time.Now()
+	shortLived := []byte("short lived")
+	longLived := []byte("long lived")
+	storedAgain := []byte("stored again")
+	forever := []byte("forever")
+	storedForever := []byte("stored forever")
+	Require(t, storage.Put(ctx, shortLived, uint64(now.Add(time.Hour).Unix())))
+	Require(t, storage.Put(ctx, forever, math.MaxUint64))
+	Require(t, storage.Put(ctx, storedForever, uint64(now.Add(time.Hour).Unix())))
+	Require(t, storage.Put(ctx, storedForever, math.MaxUint64))
+	Require(t, storage.Put(ctx, storedForever, uint64(now.Add(2*time.Hour).Unix())))
+	Require(t, storage.Put(ctx, longLived, uint64(now.Add(10*time.Hour).Unix())))
+	Require(t, storage.Put(ctx, storedAgain, uint64(now.Add(time.Hour).Unix())))
+	Require(t, storage.Put(ctx, storedAgain, uint64(now.Add(10*time.Hour).Unix())))
+
+	Require(t, storage.prune(ctx, now.Add(3*time.Hour)))
+
+	if _, err := storage.GetByHash(ctx, dastree.Hash(shortLived)); !errors.Is(err, ErrNotFound) {
+		Fail(t, "expired data wasn't pruned, err:", err)
+	}
+	for _, data := range [][]byte{longLived, storedAgain} {
+		res, err := storage.GetByHash(ctx, dastree.Hash(data))
+		Require(t, err)
+		if !bytes.Equal(res, data) {
+			Fail(t, "unexpected data", res, "expected", data)
+		}
+	}
+
+	Require(t, storage.prune(ctx, now.Add(12*time.Hour)))
+	for _, data := range [][]byte{longLived, storedAgain} {
+		if _, err := storage.GetByHash(ctx, dastree.Hash(data)); !errors.Is(err, ErrNotFound) {
+			Fail(t, "expired data wasn't pruned, err:", err)
+		}
+	}
+	for _, data := range [][]byte{forever, storedForever} {
+		res, err := storage.GetByHash(ctx, dastree.Hash(data))
+		Require(t, err)
+		if !bytes.Equal(res, data) {
+			Fail(t, "unexpected data", res, "expected", data)
+		}
+	}
+}
+
+func TestLocalFileStorageKeepsExpiry(t *testing.T) {
+	ctx := context.Background()
+	flatConfig := DefaultLocalFileStorageConfig
+	flatConfig.DataDir = t.TempDir()
+	flatConfig.DiscardAfterTimeout = true
+	flatConfig.PruneInterval = time.Hour
+	flat, err := NewLocalFileStorageService(ctx, flatConfig)
+	Require(t, err)
+	defer flat.Close(ctx)
+	shardedConfig := flatConfig
+	shardedConfig.ShardedLayout = true
+	s, err := NewLocalFileStorageService(ctx, shardedConfig)
+	Require(t, err)
+	defer s.Close(ctx)
+	storage := s.(*LocalFileStorageService)
+
+	// Data stored forever in the flat layout is kept forever when stored again in the sharded one
+	now := time.Now()
+	data := []byte("stored in both layouts")
+	Require(t, flat.Put(ctx, data, math.MaxUint64))
+	Require(t, storage.Put(ctx, data, uint64(now.Add(time.Hour).Unix())))
+	info, err := os.Stat(shardedPath(shardedConfig.DataDir, dastree.Hash(data)))
+	Require(t, err)
+	if !info.ModTime().Equal(neverExpires) {
+		Fail(t, "data stored forever in the flat layout expires at", info.ModTime(), "in the sharded layout")
+	}
+
+	// A key value replacing data which expires is kept forever
+	key := dastree.Hash([]byte("expiring data"))
+	value := []byte("key value")
+	Require(t, storage.Put(ctx, []byte("expiring data"), uint64(now.Add(time.Hour).Unix())))
+	Require(t, storage.putKeyValue(ctx, key, value))
+	Require(t, storage.prune(ctx, now.Add(3*time.Hour)))
+	res, err := storage.GetByHash(ctx, key)
+	Require(t, err)
+	if !bytes.Equal(res, value) {
+		Fail(t, "unexpected value", res, "expected", value)
+	}
+}
+
+func TestLocalFileStorageMigration(t *testing.T) {
+	ctx := context.Background()
+	config := DefaultLocalFileStorageConfig
+	config.DataDir = t.TempDir()
+	flat, err := NewLocalFileStorageService(ctx, config)
+	Require(t, err)
+
+	var values [][]byte
+	for i := 0; i < 10; i++ {
+		data := []byte{byte(i), 1, 2, 3}
+		Require(t, flat.Put(ctx, data, 0))
+		values = append(values, data)
+	}
+	Require(t, os.WriteFile(config.DataDir+"/not-a-key", []byte{}, 0o600))
+
+	moved, err := MigrateLocalFileStorageToShardedLayout(ctx, config.DataDir)
+	Require(t, err)
+	if moved != uint64(len(values)) {
+		Fail(t, "moved", moved, "files, expected", len(values))
+	}
+
+	config.ShardedLayout = true
+	sharded, err := NewLocalFileStorageService(ctx, config)
+	Require(t, err)
+	for _, data := range values {
+		key := dastree.Hash(data)
+		if _, err := os.Stat(shardedPath(config.DataDir, key)); err != nil {
+			Fail(t, "file wasn't moved to the sharded layout:", err)
+		}
+		res, err := sharded.GetByHash(ctx, key)
+		Require(t, err)
+		if !bytes.Equal(res, data) {
+			Fail(t, "unexpected data", res, "expected", data)
+		}
+	}
+}
@@ -4,10 +4,12 @@
 package das
 
 import (
+	"bytes"
 	"context"
 	"crypto/hmac"
 	"errors"
 	"fmt"
+	"io"
 	"time"
 
 	"golang.org/x/crypto/sha3"
@@ -124,6 +126,15 @@ func (rs *RedisStorageService) GetByHash(ctx context.Context, key common.Hash) (
 	return ret, err
 }
 
+// OpenByHash returns the cached data if present, and otherwise opens the data
+// from the base storage service without caching it.
+func (rs *RedisStorageService) OpenByHash(ctx context.Context, key common.Hash) (io.ReadSeekCloser, error) {
+	if data, err := rs.getVerifiedData(ctx, key); err == nil {
+		return readSeekNopCloser{bytes.NewReader(data)}, nil
+	}
+	return openByHash(ctx, rs.baseStorageService, key)
+}
+
 func (rs *RedisStorageService) Put(ctx context.Context, value []byte, timeout uint64) error {
 	logPut("das.RedisStorageService.Store", value, timeout, rs)
 	err := rs.baseStorageService.Put(ctx, value, timeout)
@@ -6,6 +6,7 @@ package das
 import (
 	"context"
 	"errors"
+	"io"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -32,6 +33,20 @@ type readResponse struct {
 	err  error
 }
 
+// OpenByHash opens the data from the first inner service which has it.
+func (r *RedundantStorageService) OpenByHash(ctx context.Context, key common.Hash) (io.ReadSeekCloser, error) {
+	log.Trace("das.RedundantStorageService.OpenByHash", "key", pretty.PrettyHash(key), "this", r)
+	var anyError error
+	for _, serv := range r.innerServices {
+		content, err := openByHash(ctx, serv, key)
+		if err == nil {
+			return content, nil
+		}
+		anyError = err
+	}
+	return nil, anyError
+}
+
 func (r *RedundantStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
 	log.Trace("das.RedundantStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", r)
 	subCtx, cancel := context.WithCancel(ctx)
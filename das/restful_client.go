@@ -8,6 +8,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -18,9 +19,16 @@ import (
 	"github.com/offchainlabs/nitro/das/dastree"
 )
 
+// maxRestfulBinaryDataSize bounds how much data the client reads from the
+// binary endpoint, so a misbehaving server can't exhaust its memory.
+const maxRestfulBinaryDataSize int64 = int64(arbstate.MaxDecompressedLen)
+
 // RestfulDasClient implements DataAvailabilityReader
 type RestfulDasClient struct {
 	url string
+	// Whether the server is trusted to serve partial ranges of data, which
+	// can't be checked against the data hash
+	trustRanges bool
 }
 
 func NewRestfulDasClient(protocol string, host string, port int) *RestfulDasClient {
@@ -39,6 +47,18 @@ func NewRestfulDasClientFromURL(url string) (*RestfulDasClient, error) {
 	}, nil
 }
 
+// NewTrustedRestfulDasClientFromURL creates a client for a server which is
+// trusted to serve the right data, so that GetRangeByHash retrieves only the
+// requested range from it rather than all of the data.
+func NewTrustedRestfulDasClientFromURL(url string) (*RestfulDasClient, error) {
+	c, err := NewRestfulDasClientFromURL(url)
+	if err != nil {
+		return nil, err
+	}
+	c.trustRanges = true
+	return c, nil
+}
+
 func (c *RestfulDasClient) GetByHash(ctx context.Context, hash common.Hash) ([]byte, error) {
 	res, err := http.Get(c.url + getByHashRequestPath + EncodeStorageServiceKey(hash))
 	if err != nil {
@@ -71,6 +91,114 @@ func (c *RestfulDasClient) GetByHash(ctx context.Context, hash common.Hash) ([]b
 	return decodedBytes, nil
 }
 
+// GetByHashBinary retrieves the data through the binary endpoint, which
+// avoids the JSON and base64 encoding of GetByHash.
+func (c *RestfulDasClient) GetByHashBinary(ctx context.Context, hash common.Hash) ([]byte, error) {
+	res, err := c.getBinary(ctx, hash, "")
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error with status %d returned by server: %s", res.StatusCode, http.StatusText(res.StatusCode))
+	}
+	data, err := readBinaryBody(res, maxRestfulBinaryDataSize)
+	if err != nil {
+		return nil, err
+	}
+	if !dastree.ValidHash(hash, data) {
+		return nil, arbstate.ErrHashMismatch
+	}
+	return data, nil
+}
+
+// GetRangeByHash retrieves length bytes of the data starting at offset.
+// A partial range can't be checked against the data hash, so only a trusted
+// server is asked for just the range, and must identify the data by its hash
+// in the ETag. Otherwise all of the data is retrieved and checked.
+func (c *RestfulDasClient) GetRangeByHash(ctx context.Context, hash common.Hash, offset, length uint64) ([]byte, error) {
+	if length == 0 {
+		return nil, errors.New("range to retrieve must not be empty")
+	}
+	if length > uint64(maxRestfulBinaryDataSize) {
+		return nil, fmt.Errorf("range to retrieve must be at most %d bytes", maxRestfulBinaryDataSize)
+	}
+	if !c.trustRanges {
+		data, err := c.GetByHashBinary(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		return dataRange(data, offset, length)
+	}
+	res, err := c.getBinary(ctx, hash, fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		// The server ignored the range and returned all of the data.
+		data, err := readBinaryBody(res, maxRestfulBinaryDataSize)
+		if err != nil {
+			return nil, err
+		}
+		if !dastree.ValidHash(hash, data) {
+			return nil, arbstate.ErrHashMismatch
+		}
+		return dataRange(data, offset, length)
+	default:
+		return nil, fmt.Errorf("HTTP error with status %d returned by server: %s", res.StatusCode, http.StatusText(res.StatusCode))
+	}
+	if etag := res.Header.Get("ETag"); etag != dataHashETag(hash) {
+		return nil, fmt.Errorf("server returned ETag %s for data with hash %v", etag, hash)
+	}
+	return readBinaryBody(res, int64(length))
+}
+
+// dataRange returns up to length bytes of data starting at offset.
+func dataRange(data []byte, offset, length uint64) ([]byte, error) {
+	if offset >= uint64(len(data)) {
+		return nil, fmt.Errorf("range starting at %d is past the end of the %d bytes of data", offset, len(data))
+	}
+	end := offset + length
+	if end > uint64(len(data)) {
+		end = uint64(len(data))
+	}
+	return data[offset:end], nil
+}
+
+// readBinaryBody copies the response body into a buffer sized from its
+// Content-Length, failing if it's longer than maxSize.
+func readBinaryBody(res *http.Response, maxSize int64) ([]byte, error) {
+	if res.ContentLength > maxSize {
+		return nil, fmt.Errorf("server returned %d bytes, more than the maximum of %d", res.ContentLength, maxSize)
+	}
+	var buf bytes.Buffer
+	if res.ContentLength > 0 {
+		buf.Grow(int(res.ContentLength))
+	}
+	n, err := io.Copy(&buf, io.LimitReader(res.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if n > maxSize {
+		return nil, fmt.Errorf("server returned more than the maximum of %d bytes", maxSize)
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *RestfulDasClient) getBinary(ctx context.Context, hash common.Hash, byteRange string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+getByHashBinaryRequestPath+EncodeStorageServiceKey(hash), nil)
+	if err != nil {
+		return nil, err
+	}
+	if byteRange != "" {
+		req.Header.Set("Range", byteRange)
+	}
+	return http.DefaultClient.Do(req)
+}
+
 func (c *RestfulDasClient) HealthCheck(ctx context.Context) error {
 	res, err := http.Get(c.url + healthRequestPath)
 	if err != nil {
@@ -4,10 +4,13 @@
 package das
 
 import (
+	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"path"
@@ -28,6 +31,11 @@ var (
 	restGetByHashFailureGauge       = metrics.NewRegisteredGauge("arb/das/rest/getbyhash/failure", nil)
 	restGetByHashReturnedBytesGauge = metrics.NewRegisteredGauge("arb/das/rest/getbyhash/bytes", nil)
 	restGetByHashDurationHistogram  = metrics.NewRegisteredHistogram("arb/das/rest/getbyhash/duration", nil, metrics.NewBoundedHistogramSample())
+
+	restGetByHashBinaryRequestGauge      = metrics.NewRegisteredGauge("arb/das/rest/getbyhashbinary/requests", nil)
+	restGetByHashBinarySuccessGauge      = metrics.NewRegisteredGauge("arb/das/rest/getbyhashbinary/success", nil)
+	restGetByHashBinaryFailureGauge      = metrics.NewRegisteredGauge("arb/das/rest/getbyhashbinary/failure", nil)
+	restGetByHashBinaryDurationHistogram = metrics.NewRegisteredHistogram("arb/das/rest/getbyhashbinary/duration", nil, metrics.NewBoundedHistogramSample())
 )
 
 type RestfulDasServer struct {
@@ -85,6 +93,7 @@ const cacheControlValueForSuccessfulGetByHash = "public, max-age=2419200, immuta
 const healthRequestPath = "/health"
 const expirationPolicyRequestPath = "/expiration-policy/"
 const getByHashRequestPath = "/get-by-hash/"
+const getByHashBinaryRequestPath = "/get-by-hash-binary/"
 
 func (rds *RestfulDasServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header()[cacheControlKey] = []string{cacheControlValueDefault}
@@ -97,6 +106,8 @@ func (rds *RestfulDasServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		rds.ExpirationPolicyHandler(w, r, requestPath)
 	case strings.HasPrefix(requestPath, getByHashRequestPath):
 		rds.GetByHashHandler(w, r, requestPath)
+	case strings.HasPrefix(requestPath, getByHashBinaryRequestPath):
+		rds.GetByHashBinaryHandler(w, r, requestPath)
 	default:
 		log.Warn("Unknown requestPath", "requestPath", requestPath)
 		w.WriteHeader(http.StatusBadRequest)
@@ -187,6 +198,82 @@ func (rds *RestfulDasServer) GetByHashHandler(w http.ResponseWriter, r *http.Req
 	success = true
 }
 
+// dataHashETag is the ETag of the data with the given hash.
+func dataHashETag(hash common.Hash) string {
+	return `"` + hash.Hex() + `"`
+}
+
+// GetByHashBinaryHandler serves the raw bytes of the data with the requested
+// hash, supporting range and conditional requests. The ETag is the data hash.
+func (rds *RestfulDasServer) GetByHashBinaryHandler(w http.ResponseWriter, r *http.Request, requestPath string) {
+	restGetByHashBinaryRequestGauge.Inc(1)
+	start := time.Now()
+	success := false
+	defer func() {
+		if success {
+			restGetByHashBinarySuccessGauge.Inc(1)
+		} else {
+			restGetByHashBinaryFailureGauge.Inc(1)
+		}
+		restGetByHashBinaryDurationHistogram.Update(time.Since(start).Nanoseconds())
+	}()
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	hash, err := DecodeStorageServiceKey(strings.TrimPrefix(requestPath, getByHashBinaryRequestPath))
+	if err != nil {
+		log.Warn("Failed to decode hex-encoded hash", "path", requestPath, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	content, err := openByHash(r.Context(), rds.daReader, hash)
+	if err != nil {
+		log.Warn("Unable to find data", "path", requestPath, "err", err, "remoteAddr", r.RemoteAddr)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	defer content.Close()
+
+	w.Header()[cacheControlKey] = []string{cacheControlValueForSuccessfulGetByHash}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("ETag", dataHashETag(hash))
+	// ServeContent handles Range, If-Range and If-None-Match, and copies only
+	// the requested part of the content to the response.
+	http.ServeContent(w, r, "", time.Time{}, content)
+	success = true
+}
+
+// dataOpener is implemented by storage which can open data for reading, so
+// that the binary endpoint can stream it instead of reading it into memory.
+// Wrappers implement it by forwarding to what they wrap.
+type dataOpener interface {
+	OpenByHash(ctx context.Context, hash common.Hash) (io.ReadSeekCloser, error)
+}
+
+// openByHash opens the data with the given hash for reading, streaming it if
+// the reader is a dataOpener, and otherwise reading it into memory.
+func openByHash(ctx context.Context, reader arbstate.DataAvailabilityReader, hash common.Hash) (io.ReadSeekCloser, error) {
+	if opener, ok := reader.(dataOpener); ok {
+		return opener.OpenByHash(ctx, hash)
+	}
+	data, err := reader.GetByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return readSeekNopCloser{bytes.NewReader(data)}, nil
+}
+
+type readSeekNopCloser struct {
+	io.ReadSeeker
+}
+
+func (readSeekNopCloser) Close() error {
+	return nil
+}
+
 func (rds *RestfulDasServer) GetServerExitedChan() <-chan interface{} { // channel will close when server terminates
 	return rds.httpServerExitedChan
 }
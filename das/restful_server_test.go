@@ -9,12 +9,16 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"os"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+
 	"github.com/offchainlabs/nitro/cmd/genericconf"
 	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
 )
 
 const LocalServerAddressForTest = "localhost"
@@ -68,3 +72,126 @@ func TestRestfulClientServer(t *testing.T) {
 	err = server.Shutdown()
 	Require(t, err)
 }
+
+func TestRestfulClientServerBinary(t *testing.T) {
+	initTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	testRestfulClientServerBinary(t, NewMemoryBackedStorageService(ctx))
+
+	// Local files are streamed rather than read into memory
+	config := DefaultLocalFileStorageConfig
+	config.DataDir = t.TempDir()
+	storage, err := NewLocalFileStorageService(ctx, config)
+	Require(t, err)
+	defer storage.Close(ctx)
+	testRestfulClientServerBinary(t, storage)
+}
+
+func TestRestfulServerBinaryStreamsFromDaserverStack(t *testing.T) {
+	initTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	data := []byte("Testing that the daserver's readers stream local files.")
+	dataHash := dastree.Hash(data)
+
+	config := DefaultDataAvailabilityConfig
+	config.Enable = true
+	config.LocalCache = TestBigCacheConfig
+	config.LocalDBStorage.Enable = true
+	config.LocalDBStorage.DataDir = t.TempDir()
+	config.LocalFileStorage = DefaultLocalFileStorageConfig
+	config.LocalFileStorage.Enable = true
+	config.LocalFileStorage.DataDir = t.TempDir()
+	config.LocalFileStorage.SyncFromStorageService = true
+
+	// The data is only in the local files, behind the database in the redundant storage
+	fileStorage, err := NewLocalFileStorageService(ctx, config.LocalFileStorage)
+	Require(t, err)
+	Require(t, fileStorage.Put(ctx, data, uint64(time.Now().Add(time.Hour).Unix())))
+	Require(t, fileStorage.Close(ctx))
+
+	daReader, _, daHealthChecker, lifecycleManager, err := CreateDAComponentsForDaserver(ctx, &config, nil, nil)
+	Require(t, err)
+	defer lifecycleManager.StopAndWaitUntil(time.Second)
+	seqInbox, err := bridgegen.NewSequencerInbox(common.Address{}, nil)
+	Require(t, err)
+	daReader, err = NewChainFetchReaderWithSeqInbox(daReader, seqInbox)
+	Require(t, err)
+
+	content, err := openByHash(ctx, daReader, dataHash)
+	Require(t, err)
+	if _, ok := content.(*os.File); !ok {
+		Fail(t, fmt.Sprintf("Data was read into memory as %T instead of opened", content))
+	}
+	Require(t, content.Close())
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:0", LocalServerAddressForTest))
+	Require(t, err)
+	server, err := NewRestfulDasServerOnListener(listener, genericconf.HTTPServerTimeoutConfigDefault, daReader, daHealthChecker)
+	Require(t, err)
+	defer func() {
+		Require(t, server.Shutdown())
+	}()
+	tcpAddr, ok := listener.Addr().(*net.TCPAddr)
+	if !ok {
+		Fail(t, "attempt to listen on TCP returned non-TCP address")
+	}
+	client, err := NewTrustedRestfulDasClientFromURL(fmt.Sprintf("http://%s:%d", LocalServerAddressForTest, tcpAddr.Port))
+	Require(t, err)
+	returnedRange, err := client.GetRangeByHash(ctx, dataHash, 8, 3)
+	Require(t, err)
+	if !bytes.Equal(data[8:11], returnedRange) {
+		Fail(t, fmt.Sprintf("Returned range '%s' does not match expected '%s'", returnedRange, data[8:11]))
+	}
+}
+
+func testRestfulClientServerBinary(t *testing.T, storage StorageService) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	data := []byte("Testing the binary endpoint of a restful server now.")
+	dataHash := dastree.Hash(data)
+
+	server, port, err := NewRestfulDasServerOnRandomPort(LocalServerAddressForTest, storage)
+	Require(t, err)
+
+	err = storage.Put(ctx, data, uint64(time.Now().Add(time.Hour).Unix()))
+	Require(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+
+	client := NewRestfulDasClient("http", LocalServerAddressForTest, port)
+	returnedData, err := client.GetByHashBinary(ctx, dataHash)
+	Require(t, err)
+	if !bytes.Equal(data, returnedData) {
+		Fail(t, fmt.Sprintf("Returned data '%s' does not match expected '%s'", returnedData, data))
+	}
+
+	// Only a trusted server is asked for just the range, otherwise all of
+	// the data is retrieved to check it against the hash
+	trustedClient, err := NewTrustedRestfulDasClientFromURL(fmt.Sprintf("http://%s:%d", LocalServerAddressForTest, port))
+	Require(t, err)
+	for _, c := range []*RestfulDasClient{client, trustedClient} {
+		returnedRange, err := c.GetRangeByHash(ctx, dataHash, 8, 3)
+		Require(t, err)
+		if !bytes.Equal(data[8:11], returnedRange) {
+			Fail(t, fmt.Sprintf("Returned range '%s' does not match expected '%s'", returnedRange, data[8:11]))
+		}
+	}
+	if _, err := client.GetRangeByHash(ctx, dataHash, uint64(len(data)), 1); err == nil {
+		Fail(t, "Expected an error for a range past the end of the data")
+	}
+
+	_, err = client.GetByHashBinary(ctx, dastree.Hash([]byte("absent data")))
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		Fail(t, "Expected a 404 error")
+	}
+
+	err = server.Shutdown()
+	Require(t, err)
+}
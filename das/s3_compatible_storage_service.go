@@ -0,0 +1,201 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das/dastree"
+	"github.com/offchainlabs/nitro/util/pretty"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	flag "github.com/spf13/pflag"
+)
+
+// S3CompatibleStorageServiceConfig configures storage in an S3-compatible
+// object store such as MinIO. Unlike S3StorageServiceConfig, nothing is taken
+// from the AWS environment, shared config files or instance metadata.
+type S3CompatibleStorageServiceConfig struct {
+	Enable                 bool   `koanf:"enable"`
+	Endpoint               string `koanf:"endpoint"`
+	Bucket                 string `koanf:"bucket"`
+	ObjectPrefix           string `koanf:"object-prefix"`
+	Region                 string `koanf:"region"`
+	AccessKey              string `koanf:"access-key"`
+	SecretKey              string `koanf:"secret-key"`
+	UsePathStyle           bool   `koanf:"use-path-style"`
+	DiscardAfterTimeout    bool   `koanf:"discard-after-timeout"`
+	ExpiryTagKey           string `koanf:"expiry-tag-key"`
+	SyncFromStorageService bool   `koanf:"sync-from-storage-service"`
+	SyncToStorageService   bool   `koanf:"sync-to-storage-service"`
+}
+
+var DefaultS3CompatibleStorageServiceConfig = S3CompatibleStorageServiceConfig{
+	Region:       "us-east-1",
+	UsePathStyle: true,
+	ExpiryTagKey: "das-retention-days",
+}
+
+func S3CompatibleConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultS3CompatibleStorageServiceConfig.Enable, "enable storage/retrieval of sequencer batch data from an S3-compatible object store")
+	f.String(prefix+".endpoint", DefaultS3CompatibleStorageServiceConfig.Endpoint, "URL of the S3-compatible object store")
+	f.String(prefix+".bucket", DefaultS3CompatibleStorageServiceConfig.Bucket, "bucket to store objects in")
+	f.String(prefix+".object-prefix", DefaultS3CompatibleStorageServiceConfig.ObjectPrefix, "prefix to add to objects")
+	f.String(prefix+".region", DefaultS3CompatibleStorageServiceConfig.Region, "region to sign requests for")
+	f.String(prefix+".access-key", DefaultS3CompatibleStorageServiceConfig.AccessKey, "access key (requests are unsigned if no access key and secret key are set)")
+	f.String(prefix+".secret-key", DefaultS3CompatibleStorageServiceConfig.SecretKey, "secret key")
+	f.Bool(prefix+".use-path-style", DefaultS3CompatibleStorageServiceConfig.UsePathStyle, "address buckets as a path of the endpoint rather than as a subdomain")
+	f.Bool(prefix+".discard-after-timeout", DefaultS3CompatibleStorageServiceConfig.DiscardAfterTimeout, "tag objects with the number of days to retain them for, to be expired by bucket lifecycle rules on that tag (objects to be kept forever aren't tagged)")
+	f.String(prefix+".expiry-tag-key", DefaultS3CompatibleStorageServiceConfig.ExpiryTagKey, "key of the object tag holding the number of days to retain the object for")
+	f.Bool(prefix+".sync-from-storage-service", DefaultS3CompatibleStorageServiceConfig.SyncFromStorageService, "enable the object store to be used as a source for regular sync storage")
+	f.Bool(prefix+".sync-to-storage-service", DefaultS3CompatibleStorageServiceConfig.SyncToStorageService, "enable the object store to be used as a sink for regular sync storage")
+}
+
+func (c *S3CompatibleStorageServiceConfig) Validate() error {
+	if c.Endpoint == "" {
+		return errors.New("s3-compatible-storage endpoint must be set")
+	}
+	if c.Bucket == "" {
+		return errors.New("s3-compatible-storage bucket must be set")
+	}
+	if (c.AccessKey == "") != (c.SecretKey == "") {
+		return errors.New("s3-compatible-storage access-key and secret-key must be set together")
+	}
+	if c.DiscardAfterTimeout && c.ExpiryTagKey == "" {
+		return errors.New("s3-compatible-storage expiry-tag-key must be set to discard data after timeout")
+	}
+	return nil
+}
+
+type S3CompatibleStorageService struct {
+	client *s3.Client
+	config S3CompatibleStorageServiceConfig
+}
+
+func NewS3CompatibleStorageService(config S3CompatibleStorageServiceConfig) (StorageService, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	options := s3.Options{
+		Region:           config.Region,
+		EndpointResolver: s3.EndpointResolverFromURL(config.Endpoint),
+		UsePathStyle:     config.UsePathStyle,
+		Credentials:      aws.AnonymousCredentials{},
+	}
+	if config.AccessKey != "" {
+		options.Credentials = credentials.NewStaticCredentialsProvider(config.AccessKey, config.SecretKey, "")
+	}
+	return &S3CompatibleStorageService{
+		client: s3.New(options),
+		config: config,
+	}, nil
+}
+
+func (s *S3CompatibleStorageService) objectKey(key common.Hash) *string {
+	return aws.String(s.config.ObjectPrefix + EncodeStorageServiceKey(key))
+}
+
+func (s *S3CompatibleStorageService) GetByHash(ctx context.Context, key common.Hash) ([]byte, error) {
+	log.Trace("das.S3CompatibleStorageService.GetByHash", "key", pretty.PrettyHash(key), "this", s)
+
+	output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    s.objectKey(key),
+	})
+	if err != nil {
+		var responseErr *awshttp.ResponseError
+		if errors.As(err, &responseErr) && responseErr.HTTPStatusCode() == http.StatusNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	defer output.Body.Close()
+	return io.ReadAll(output.Body)
+}
+
+// retentionDays is the number of whole days until the timeout, rounded up so
+// lifecycle rules never expire data early.
+func retentionDays(timeout uint64, now time.Time) uint64 {
+	if timeout <= uint64(now.Unix()) {
+		return 1
+	}
+	secondsPerDay := uint64(24 * time.Hour / time.Second)
+	remaining := timeout - uint64(now.Unix())
+	return (remaining + secondsPerDay - 1) / secondsPerDay
+}
+
+func (s *S3CompatibleStorageService) Put(ctx context.Context, value []byte, timeout uint64) error {
+	logPut("das.S3CompatibleStorageService.Store", value, timeout, s)
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(s.config.Bucket),
+		Key:           s.objectKey(dastree.Hash(value)),
+		Body:          bytes.NewReader(value),
+		ContentLength: int64(len(value)),
+	}
+	// Timeouts beyond any unix time, such as math.MaxUint64, mean forever and
+	// get no expiry tag.
+	if s.config.DiscardAfterTimeout && timeout <= math.MaxInt64 {
+		tags := url.Values{}
+		tags.Set(s.config.ExpiryTagKey, strconv.FormatUint(retentionDays(timeout, time.Now()), 10))
+		input.Tagging = aws.String(tags.Encode())
+	}
+	_, err := s.client.PutObject(ctx, input)
+	if err != nil {
+		log.Error("das.S3CompatibleStorageService.Store", "err", err)
+	}
+	return err
+}
+
+func (s *S3CompatibleStorageService) putKeyValue(ctx context.Context, key common.Hash, value []byte) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.config.Bucket),
+		Key:           s.objectKey(key),
+		Body:          bytes.NewReader(value),
+		ContentLength: int64(len(value)),
+	})
+	if err != nil {
+		log.Error("das.S3CompatibleStorageService.Store", "err", err)
+	}
+	return err
+}
+
+func (s *S3CompatibleStorageService) Sync(ctx context.Context) error {
+	return nil
+}
+
+func (s *S3CompatibleStorageService) Close(ctx context.Context) error {
+	return nil
+}
+
+func (s *S3CompatibleStorageService) ExpirationPolicy(ctx context.Context) (arbstate.ExpirationPolicy, error) {
+	if s.config.DiscardAfterTimeout {
+		return arbstate.DiscardAfterDataTimeout, nil
+	}
+	return arbstate.KeepForever, nil
+}
+
+func (s *S3CompatibleStorageService) String() string {
+	return fmt.Sprintf("S3CompatibleStorageService(%s:%s)", s.config.Endpoint, s.config.Bucket)
+}
+
+func (s *S3CompatibleStorageService) HealthCheck(ctx context.Context) error {
+	_, err := s.client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(s.config.Bucket)})
+	return err
+}
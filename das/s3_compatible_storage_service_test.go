@@ -0,0 +1,150 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package das
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das/dastree"
+)
+
+// fakeS3Server implements just enough of the S3 API, with path-style
+// addressing, to test S3CompatibleStorageService.
+type fakeS3Server struct {
+	bucket  string
+	mutex   sync.Mutex
+	objects map[string][]byte
+	tags    map[string]string
+}
+
+func newFakeS3Server(bucket string) *fakeS3Server {
+	return &fakeS3Server{
+		bucket:  bucket,
+		objects: make(map[string][]byte),
+		tags:    make(map[string]string),
+	}
+}
+
+func (f *fakeS3Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	bucket, key, _ := strings.Cut(path, "/")
+	if bucket != f.bucket {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<Error><Code>NoSuchBucket</Code></Error>`))
+		return
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	switch {
+	case r.Method == http.MethodHead && key == "":
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.objects[key] = data
+		f.tags[key] = r.Header.Get("X-Amz-Tagging")
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`<Error><Code>NoSuchKey</Code></Error>`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(data)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestS3CompatibleStorageService(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeS3Server("das")
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	config := DefaultS3CompatibleStorageServiceConfig
+	config.Enable = true
+	config.Endpoint = server.URL
+	config.Bucket = "das"
+	config.ObjectPrefix = "batches/"
+	config.AccessKey = "minio"
+	config.SecretKey = "minio123"
+	config.DiscardAfterTimeout = true
+	s, err := NewS3CompatibleStorageService(config)
+	Require(t, err)
+	Require(t, s.HealthCheck(ctx))
+
+	policy, err := s.ExpirationPolicy(ctx)
+	Require(t, err)
+	if policy != arbstate.DiscardAfterDataTimeout {
+		Fail(t, "unexpected expiration policy", policy)
+	}
+
+	val := []byte("The first value")
+	_, err = s.GetByHash(ctx, dastree.Hash(val))
+	if !errors.Is(err, ErrNotFound) {
+		Fail(t, "expected ErrNotFound, got", err)
+	}
+
+	timeout := uint64(time.Now().Add(36 * time.Hour).Unix())
+	Require(t, s.Put(ctx, val, timeout))
+	res, err := s.GetByHash(ctx, dastree.Hash(val))
+	Require(t, err)
+	if !bytes.Equal(res, val) {
+		Fail(t, "unexpected data", res, "expected", val)
+	}
+
+	objectKey := "batches/" + EncodeStorageServiceKey(dastree.Hash(val))
+	if tag := fake.tags[objectKey]; tag != "das-retention-days=2" {
+		Fail(t, "unexpected object tagging", tag)
+	}
+
+	// Data kept forever isn't tagged to be expired
+	forever := []byte("The forever value")
+	Require(t, s.Put(ctx, forever, math.MaxUint64))
+	res, err = s.GetByHash(ctx, dastree.Hash(forever))
+	Require(t, err)
+	if !bytes.Equal(res, forever) {
+		Fail(t, "unexpected data", res, "expected", forever)
+	}
+	foreverKey := "batches/" + EncodeStorageServiceKey(dastree.Hash(forever))
+	if tag := fake.tags[foreverKey]; tag != "" {
+		Fail(t, "data kept forever was tagged", tag)
+	}
+}
+
+func TestRetentionDays(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+	for _, tc := range []struct {
+		timeout time.Time
+		days    uint64
+	}{
+		{now.Add(-time.Hour), 1},
+		{now.Add(time.Second), 1},
+		{now.Add(24 * time.Hour), 1},
+		{now.Add(24*time.Hour + time.Second), 2},
+		{now.Add(15 * 24 * time.Hour), 15},
+	} {
+		if days := retentionDays(uint64(tc.timeout.Unix()), now); days != tc.days {
+			t.Errorf("retention until %v is %v days, expected %v", tc.timeout, days, tc.days)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package feedrecorder
+
+import (
+	"sync"
+	"time"
+
+	"github.com/offchainlabs/nitro/broadcaster"
+)
+
+// Recorder records the messages given to it by a broadcastclient.BroadcastClient,
+// in place of a transaction streamer.
+type Recorder struct {
+	mutex  sync.Mutex
+	writer *RecordingWriter
+	count  uint64
+}
+
+func NewRecorder(writer *RecordingWriter) *Recorder {
+	return &Recorder{writer: writer}
+}
+
+func (r *Recorder) AddBroadcastMessages(feedMessages []*broadcaster.BroadcastFeedMessage) error {
+	receivedAt := uint64(time.Now().UnixNano())
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, message := range feedMessages {
+		err := r.writer.Write(&RecordedMessage{
+			ReceivedAt: receivedAt,
+			Message:    message,
+		})
+		if err != nil {
+			return err
+		}
+		r.count++
+	}
+	return r.writer.Flush()
+}
+
+// Count is the number of messages recorded.
+func (r *Recorder) Count() uint64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.count
+}
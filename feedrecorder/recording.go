@@ -0,0 +1,172 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package feedrecorder records the sequencer feed to a file and replays
+// recordings through a feed server, to reproduce feed issues locally.
+package feedrecorder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/broadcaster"
+)
+
+// A recording is the magic bytes followed by records, each of which is its
+// length as a uvarint followed by the RLP encoding of a RecordedMessage.
+var recordingMagic = []byte("ARBFEEDREC\x01")
+
+// maxRecordSize bounds the allocation for a single record, so a corrupt
+// length can't exhaust memory.
+const maxRecordSize = 64 * 1024 * 1024
+
+var ErrBadRecording = errors.New("not a feed recording")
+
+// RecordedMessage is a feed message along with when it was received.
+type RecordedMessage struct {
+	ReceivedAt uint64 // unix nanoseconds
+	Message    *broadcaster.BroadcastFeedMessage
+}
+
+func (m *RecordedMessage) ReceivedTime() time.Time {
+	return time.Unix(0, int64(m.ReceivedAt))
+}
+
+// RecordingWriter appends messages to a recording.
+type RecordingWriter struct {
+	writer *bufio.Writer
+	buf    bytes.Buffer
+}
+
+// NewRecordingWriter starts a recording. If the writer is appending to an
+// existing recording, writeHeader must be false.
+func NewRecordingWriter(w io.Writer, writeHeader bool) (*RecordingWriter, error) {
+	rw := &RecordingWriter{writer: bufio.NewWriter(w)}
+	if writeHeader {
+		if _, err := rw.writer.Write(recordingMagic); err != nil {
+			return nil, err
+		}
+	}
+	return rw, nil
+}
+
+func (w *RecordingWriter) Write(message *RecordedMessage) error {
+	w.buf.Reset()
+	if err := rlp.Encode(&w.buf, message); err != nil {
+		return fmt.Errorf("encoding message %v: %w", message.Message.SequenceNumber, err)
+	}
+	var length [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(length[:], uint64(w.buf.Len()))
+	if _, err := w.writer.Write(length[:n]); err != nil {
+		return err
+	}
+	_, err := w.writer.Write(w.buf.Bytes())
+	return err
+}
+
+// Flush writes any buffered messages to the underlying writer.
+func (w *RecordingWriter) Flush() error {
+	return w.writer.Flush()
+}
+
+// RecordingReader reads the messages of a recording in order.
+type RecordingReader struct {
+	reader *bufio.Reader
+	buf    []byte
+	// The size of the header and the records read so far
+	offset int64
+}
+
+func NewRecordingReader(r io.Reader) (*RecordingReader, error) {
+	reader := bufio.NewReader(r)
+	magic := make([]byte, len(recordingMagic))
+	if _, err := io.ReadFull(reader, magic); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadRecording, err)
+	}
+	if !bytes.Equal(magic, recordingMagic) {
+		return nil, ErrBadRecording
+	}
+	return &RecordingReader{reader: reader, offset: int64(len(recordingMagic))}, nil
+}
+
+// Next returns the next message of the recording, or io.EOF at the end. A
+// record cut short, for instance by the recorder being killed, is reported
+// as io.ErrUnexpectedEOF.
+func (r *RecordingReader) Next() (*RecordedMessage, error) {
+	length, err := binary.ReadUvarint(r.reader)
+	if err != nil {
+		return nil, err
+	}
+	if length > maxRecordSize {
+		return nil, fmt.Errorf("record of %d bytes is larger than the maximum of %d", length, maxRecordSize)
+	}
+	if uint64(cap(r.buf)) < length {
+		r.buf = make([]byte, length)
+	}
+	r.buf = r.buf[:length]
+	if _, err := io.ReadFull(r.reader, r.buf); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	var message RecordedMessage
+	if err := rlp.DecodeBytes(r.buf, &message); err != nil {
+		return nil, fmt.Errorf("decoding record: %w", err)
+	}
+	var lengthBytes [binary.MaxVarintLen64]byte
+	r.offset += int64(binary.PutUvarint(lengthBytes[:], length)) + int64(length)
+	return &message, nil
+}
+
+// Offset returns the size of the recording up to the end of the last record
+// returned by Next.
+func (r *RecordingReader) Offset() int64 {
+	return r.offset
+}
+
+// RepairRecording reads through the recording at path to find its last
+// message, so that recording can resume after it. If the last record was cut
+// short, for instance by the recorder being killed, it's truncated off the
+// file so that new records can be appended.
+func RepairRecording(path string) (arbutil.MessageIndex, bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, false, err
+	}
+	defer file.Close()
+	reader, err := NewRecordingReader(file)
+	if err != nil {
+		return 0, false, err
+	}
+	var last arbutil.MessageIndex
+	found := false
+	for {
+		message, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return last, found, nil
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			log.Warn("truncating incomplete last record of recording", "file", path, "size", reader.Offset())
+			if err := os.Truncate(path, reader.Offset()); err != nil {
+				return 0, false, err
+			}
+			return last, found, nil
+		}
+		if err != nil {
+			return 0, false, fmt.Errorf("reading existing recording: %w", err)
+		}
+		last = message.Message.SequenceNumber
+		found = true
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package feedrecorder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/broadcaster"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func testFeedMessage(seqNum arbutil.MessageIndex) *broadcaster.BroadcastFeedMessage {
+	return &broadcaster.BroadcastFeedMessage{
+		SequenceNumber: seqNum,
+		Message:        arbostypes.TestMessageWithMetadataAndRequestId,
+		Signature:      []byte{byte(seqNum), 1, 2, 3},
+	}
+}
+
+type testBroadcaster struct {
+	batches [][]arbutil.MessageIndex
+}
+
+func (b *testBroadcaster) BroadcastFeedMessages(messages []*broadcaster.BroadcastFeedMessage) {
+	var batch []arbutil.MessageIndex
+	for _, message := range messages {
+		batch = append(batch, message.SequenceNumber)
+	}
+	b.batches = append(b.batches, batch)
+}
+
+func TestRecordingRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewRecordingWriter(&buf, true)
+	Require(t, err)
+	recorder := NewRecorder(writer)
+	Require(t, recorder.AddBroadcastMessages([]*broadcaster.BroadcastFeedMessage{testFeedMessage(5), testFeedMessage(6)}))
+
+	// Appending to the recording doesn't repeat the header
+	writer, err = NewRecordingWriter(&buf, false)
+	Require(t, err)
+	recorder = NewRecorder(writer)
+	Require(t, recorder.AddBroadcastMessages([]*broadcaster.BroadcastFeedMessage{testFeedMessage(7)}))
+	if recorder.Count() != 1 {
+		Fail(t, "recorded", recorder.Count(), "messages, expected 1")
+	}
+
+	reader, err := NewRecordingReader(bytes.NewReader(buf.Bytes()))
+	Require(t, err)
+	for _, seqNum := range []arbutil.MessageIndex{5, 6, 7} {
+		message, err := reader.Next()
+		Require(t, err)
+		if message.Message.SequenceNumber != seqNum {
+			Fail(t, "read message", message.Message.SequenceNumber, "expected", seqNum)
+		}
+		if !bytes.Equal(message.Message.Signature, testFeedMessage(seqNum).Signature) {
+			Fail(t, "signature of message", seqNum, "not recorded")
+		}
+		if time.Since(message.ReceivedTime()) > time.Minute {
+			Fail(t, "unexpected receive time", message.ReceivedTime())
+		}
+	}
+	if _, err := reader.Next(); !errors.Is(err, io.EOF) {
+		Fail(t, "expected end of recording, got", err)
+	}
+
+	// A record cut short is reported as such
+	reader, err = NewRecordingReader(bytes.NewReader(buf.Bytes()[:buf.Len()-1]))
+	Require(t, err)
+	for i := 0; i < 2; i++ {
+		_, err := reader.Next()
+		Require(t, err)
+	}
+	if _, err := reader.Next(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		Fail(t, "expected truncated record, got", err)
+	}
+
+	if _, err := NewRecordingReader(bytes.NewReader([]byte("not a recording"))); !errors.Is(err, ErrBadRecording) {
+		Fail(t, "expected bad recording, got", err)
+	}
+}
+
+func TestRepairRecording(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording")
+	file, err := os.Create(path)
+	Require(t, err)
+	writer, err := NewRecordingWriter(file, true)
+	Require(t, err)
+	Require(t, NewRecorder(writer).AddBroadcastMessages([]*broadcaster.BroadcastFeedMessage{testFeedMessage(5), testFeedMessage(6), testFeedMessage(7)}))
+	Require(t, file.Close())
+	info, err := os.Stat(path)
+	Require(t, err)
+	// Cut the last record short, as if the recorder was killed writing it
+	Require(t, os.Truncate(path, info.Size()-3))
+
+	last, found, err := RepairRecording(path)
+	Require(t, err)
+	if !found || last != 6 {
+		Fail(t, "found", found, "last message", last, "expected 6")
+	}
+
+	// Recording resumes after the last complete record
+	file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	Require(t, err)
+	writer, err = NewRecordingWriter(file, false)
+	Require(t, err)
+	Require(t, NewRecorder(writer).AddBroadcastMessages([]*broadcaster.BroadcastFeedMessage{testFeedMessage(7), testFeedMessage(8)}))
+	Require(t, file.Close())
+	file, err = os.Open(path)
+	Require(t, err)
+	defer file.Close()
+	reader, err := NewRecordingReader(file)
+	Require(t, err)
+	for _, seqNum := range []arbutil.MessageIndex{5, 6, 7, 8} {
+		message, err := reader.Next()
+		Require(t, err)
+		if message.Message.SequenceNumber != seqNum {
+			Fail(t, "read message", message.Message.SequenceNumber, "expected", seqNum)
+		}
+	}
+	if _, err := reader.Next(); !errors.Is(err, io.EOF) {
+		Fail(t, "expected end of recording, got", err)
+	}
+
+	// A recording with only a header has no messages
+	Require(t, os.WriteFile(path, recordingMagic, 0o644))
+	if _, found, err := RepairRecording(path); err != nil || found {
+		Fail(t, "unexpected message in empty recording", found, err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewRecordingWriter(&buf, true)
+	Require(t, err)
+	start := time.Now()
+	receivedAt := []time.Duration{0, 0, 100 * time.Millisecond, 200 * time.Millisecond, 200 * time.Millisecond}
+	for i, offset := range receivedAt {
+		Require(t, writer.Write(&RecordedMessage{
+			ReceivedAt: uint64(start.Add(offset).UnixNano()),
+			Message:    testFeedMessage(arbutil.MessageIndex(i)),
+		}))
+	}
+	Require(t, writer.Flush())
+
+	// Replaying at double speed takes about half the recorded time
+	reader, err := NewRecordingReader(bytes.NewReader(buf.Bytes()))
+	Require(t, err)
+	b := &testBroadcaster{}
+	replayStart := time.Now()
+	count, err := Replay(context.Background(), reader, b, 2)
+	Require(t, err)
+	if elapsed := time.Since(replayStart); elapsed < 100*time.Millisecond || elapsed > time.Second {
+		Fail(t, "replay took", elapsed)
+	}
+	if count != 5 {
+		Fail(t, "replayed", count, "messages, expected 5")
+	}
+	// Messages received together are broadcast together
+	if len(b.batches) != 3 || len(b.batches[0]) != 2 || len(b.batches[1]) != 1 || len(b.batches[2]) != 2 {
+		Fail(t, "unexpected batches", b.batches)
+	}
+
+	// Replaying is stopped by the context
+	reader, err = NewRecordingReader(bytes.NewReader(buf.Bytes()))
+	Require(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Replay(ctx, reader, &testBroadcaster{}, 0.001); !errors.Is(err, context.Canceled) {
+		Fail(t, "expected replay to be canceled, got", err)
+	}
+}
+
+func Require(t *testing.T, err error, printables ...interface{}) {
+	t.Helper()
+	testhelpers.RequireImpl(t, err, printables...)
+}
+
+func Fail(t *testing.T, printables ...interface{}) {
+	t.Helper()
+	testhelpers.FailImpl(t, printables...)
+}
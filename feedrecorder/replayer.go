@@ -0,0 +1,75 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package feedrecorder
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/offchainlabs/nitro/broadcaster"
+)
+
+// FeedBroadcaster is the part of broadcaster.Broadcaster used for replaying.
+type FeedBroadcaster interface {
+	BroadcastFeedMessages(messages []*broadcaster.BroadcastFeedMessage)
+}
+
+// Replay broadcasts the messages of a recording, with the time between them
+// divided by speed, or as fast as possible if speed isn't positive. Messages
+// received together are broadcast together. It returns the number of
+// messages broadcast.
+func Replay(ctx context.Context, reader *RecordingReader, b FeedBroadcaster, speed float64) (uint64, error) {
+	var count uint64
+	var pending []*broadcaster.BroadcastFeedMessage
+	var pendingReceivedAt, firstReceivedAt time.Time
+	var start time.Time
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		if speed > 0 {
+			offset := time.Duration(float64(pendingReceivedAt.Sub(firstReceivedAt)) / speed)
+			if wait := time.Until(start.Add(offset)); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+		b.BroadcastFeedMessages(pending)
+		count += uint64(len(pending))
+		pending = nil
+		return nil
+	}
+	for {
+		message, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if flushErr := flush(); flushErr != nil {
+				return count, flushErr
+			}
+			return count, err
+		}
+		receivedAt := message.ReceivedTime()
+		if start.IsZero() {
+			start = time.Now()
+			firstReceivedAt = receivedAt
+		}
+		if len(pending) > 0 && !receivedAt.Equal(pendingReceivedAt) {
+			if err := flush(); err != nil {
+				return count, err
+			}
+		}
+		pending = append(pending, message.Message)
+		pendingReceivedAt = receivedAt
+	}
+	return count, flush()
+}
@@ -6,12 +6,16 @@ package relay
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net"
+	"reflect"
+	"strings"
 	"time"
 
 	flag "github.com/spf13/pflag"
 
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 
 	"github.com/offchainlabs/nitro/arbutil"
 	"github.com/offchainlabs/nitro/broadcastclient"
@@ -19,11 +23,14 @@ import (
 	"github.com/offchainlabs/nitro/broadcaster"
 	"github.com/offchainlabs/nitro/cmd/genericconf"
 	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+	"github.com/offchainlabs/nitro/util/colors"
 	"github.com/offchainlabs/nitro/util/sharedmetrics"
 	"github.com/offchainlabs/nitro/util/stopwaiter"
 	"github.com/offchainlabs/nitro/wsbroadcastserver"
 )
 
+var upstreamsHealthyGauge = metrics.NewRegisteredGauge("arb/relay/upstreams/healthy", nil)
+
 type Relay struct {
 	stopwaiter.StopWaiter
 	broadcastClients            *broadcastclients.BroadcastClients
@@ -44,15 +51,15 @@ func (q *MessageQueue) AddBroadcastMessages(feedMessages []*broadcaster.Broadcas
 	return nil
 }
 
-func NewRelay(config *Config, feedErrChan chan error) (*Relay, error) {
+func NewRelay(config ConfigFetcher, feedErrChan chan error) (*Relay, error) {
 
-	q := MessageQueue{make(chan broadcaster.BroadcastFeedMessage, config.Queue)}
+	q := MessageQueue{make(chan broadcaster.BroadcastFeedMessage, config().Queue)}
 
-	confirmedSequenceNumberListener := make(chan arbutil.MessageIndex, config.Queue)
+	confirmedSequenceNumberListener := make(chan arbutil.MessageIndex, config().Queue)
 
 	clients, err := broadcastclients.NewBroadcastClients(
-		func() *broadcastclient.Config { return &config.Node.Feed.Input },
-		config.Chain.ID,
+		func() *broadcastclient.Config { return &config().Node.Feed.Input },
+		config().Chain.ID,
 		0,
 		&q,
 		confirmedSequenceNumberListener,
@@ -69,12 +76,35 @@ func NewRelay(config *Config, feedErrChan chan error) (*Relay, error) {
 	dataSignerErr := func([]byte) ([]byte, error) {
 		return nil, errors.New("relay attempted to sign feed message")
 	}
-	return &Relay{
-		broadcaster:                 broadcaster.NewBroadcaster(func() *wsbroadcastserver.BroadcasterConfig { return &config.Node.Feed.Output }, config.Chain.ID, feedErrChan, dataSignerErr),
+	r := &Relay{
+		broadcaster:                 broadcaster.NewBroadcaster(func() *wsbroadcastserver.BroadcasterConfig { return &config().Node.Feed.Output }, config().Chain.ID, feedErrChan, dataSignerErr),
 		broadcastClients:            clients,
 		confirmedSequenceNumberChan: confirmedSequenceNumberListener,
 		messageChan:                 q.queue,
-	}, nil
+	}
+	r.broadcaster.SetReadinessCheck(r.checkUpstreams)
+	return r, nil
+}
+
+// checkUpstreams returns an error if none of the feeds relayed from is
+// healthy, describing the health of each. The error is only logged, as the
+// readiness probe is unauthenticated and mustn't reveal the upstream URLs.
+func (r *Relay) checkUpstreams() error {
+	health := r.broadcastClients.Health()
+	healthy := 0
+	var unhealthy []string
+	for _, feed := range health {
+		if feed.Healthy {
+			healthy++
+		} else {
+			unhealthy = append(unhealthy, fmt.Sprintf("%s (connected %v, lag %d, last message %v)", feed.URL, feed.Connected, feed.Lag, feed.LastMessage))
+		}
+	}
+	upstreamsHealthyGauge.Update(int64(healthy))
+	if healthy == 0 {
+		return fmt.Errorf("no healthy upstream feed: %s", strings.Join(unhealthy, ", "))
+	}
+	return nil
 }
 
 const RECENT_FEED_ITEM_TTL = time.Second * 10
@@ -119,6 +149,9 @@ func (r *Relay) Start(ctx context.Context) error {
 				}
 				r.broadcaster.Confirm(cs)
 			case <-recentFeedItemsCleanup.C:
+				if err := r.checkUpstreams(); err != nil {
+					log.Warn("relay has no healthy upstream", "err", err)
+				}
 				// Cycle buckets to get rid of old entries
 				recentFeedItemsOld = recentFeedItemsNew
 				recentFeedItemsNew = make(map[arbutil.MessageIndex]time.Time, RECENT_FEED_INITIAL_MAP_SIZE)
@@ -148,7 +181,7 @@ type Config struct {
 	MetricsServer genericconf.MetricsServerConfig `koanf:"metrics-server"`
 	PProf         bool                            `koanf:"pprof"`
 	PprofCfg      genericconf.PProf               `koanf:"pprof-cfg"`
-	Node          NodeConfig                      `koanf:"node"`
+	Node          NodeConfig                      `koanf:"node" reload:"hot"`
 	Queue         int                             `koanf:"queue"`
 }
 
@@ -165,6 +198,53 @@ var ConfigDefault = Config{
 	Queue:         1024,
 }
 
+type ConfigFetcher func() *Config
+
+func (c *Config) CanReload(new *Config) error {
+	var check func(node, other reflect.Value, path string)
+	var err error
+
+	check = func(node, value reflect.Value, path string) {
+		if node.Kind() != reflect.Struct {
+			return
+		}
+
+		for i := 0; i < node.NumField(); i++ {
+			fieldTy := node.Type().Field(i)
+			if !fieldTy.IsExported() {
+				continue
+			}
+			hot := fieldTy.Tag.Get("reload") == "hot"
+			dot := path + "." + fieldTy.Name
+
+			first := node.Field(i).Interface()
+			other := value.Field(i).Interface()
+
+			if !hot && !reflect.DeepEqual(first, other) {
+				err = fmt.Errorf("illegal change to %v%v%v", colors.Red, dot, colors.Clear)
+			} else {
+				check(node.Field(i), value.Field(i), dot)
+			}
+		}
+	}
+
+	check(reflect.ValueOf(c).Elem(), reflect.ValueOf(new).Elem(), "config")
+	return err
+}
+
+func (c *Config) GetReloadInterval() time.Duration {
+	return c.Conf.ReloadInterval
+}
+
+func (c *Config) Validate() error {
+	// Without the arbiter every upstream's copy of a message would be relayed,
+	// and upstream health would only be based on connectivity
+	if len(c.Node.Feed.Input.URL) > 1 && !c.Node.Feed.Input.Arbiter.Enable {
+		return errors.New("relaying from multiple feeds requires --node.feed.input.arbiter.enable")
+	}
+	return c.Node.Feed.Validate()
+}
+
 func ConfigAddOptions(f *flag.FlagSet) {
 	genericconf.ConfConfigAddOptions("conf", f)
 	L2ConfigAddOptions("chain", f)
@@ -179,7 +259,7 @@ func ConfigAddOptions(f *flag.FlagSet) {
 }
 
 type NodeConfig struct {
-	Feed broadcastclient.FeedConfig `koanf:"feed"`
+	Feed broadcastclient.FeedConfig `koanf:"feed" reload:"hot"`
 }
 
 var NodeConfigDefault = NodeConfig{
@@ -217,6 +297,10 @@ func ParseRelay(_ context.Context, args []string) (*Config, error) {
 		return nil, err
 	}
 
+	if err := relayConfig.Validate(); err != nil {
+		return nil, err
+	}
+
 	if relayConfig.Conf.Dump {
 		err = confighelpers.DumpConfig(k, map[string]interface{}{})
 		if err != nil {
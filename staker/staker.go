@@ -135,6 +135,9 @@ func (c *L1ValidatorConfig) Validate() error {
 		return errors.New("invalid validator gas refunder address")
 	}
 	c.gasRefunder = common.HexToAddress(c.GasRefunderAddress)
+	if err := c.ParentChainWallet.Validate(); err != nil {
+		return fmt.Errorf("invalid validator parent chain wallet: %w", err)
+	}
 	return nil
 }
 
@@ -181,11 +184,12 @@ var TestL1ValidatorConfig = L1ValidatorConfig{
 }
 
 var DefaultValidatorL1WalletConfig = genericconf.WalletConfig{
-	Pathname:      "validator-wallet",
-	Password:      genericconf.WalletConfigDefault.Password,
-	PrivateKey:    genericconf.WalletConfigDefault.PrivateKey,
-	Account:       genericconf.WalletConfigDefault.Account,
-	OnlyCreateKey: genericconf.WalletConfigDefault.OnlyCreateKey,
+	Pathname:       "validator-wallet",
+	Password:       genericconf.WalletConfigDefault.Password,
+	PrivateKey:     genericconf.WalletConfigDefault.PrivateKey,
+	Account:        genericconf.WalletConfigDefault.Account,
+	OnlyCreateKey:  genericconf.WalletConfigDefault.OnlyCreateKey,
+	ExternalSigner: genericconf.WalletConfigDefault.ExternalSigner,
 }
 
 func L1ValidatorConfigAddOptions(prefix string, f *flag.FlagSet) {
@@ -0,0 +1,96 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/arbnode/execution"
+)
+
+func sendBundle(ctx context.Context, rpcClient *rpc.Client, deadline time.Time, txes ...*types.Transaction) (*execution.BundleReceipt, error) {
+	args := execution.SendBundleArgs{Deadline: hexutil.Uint64(deadline.Unix())}
+	for _, tx := range txes {
+		encoded, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		args.Txs = append(args.Txs, encoded)
+	}
+	var receipt execution.BundleReceipt
+	err := rpcClient.CallContext(ctx, &receipt, execution.BundleAPINamespace+"_sendBundle", args)
+	if err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+func TestSequencerBundle(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := arbnode.ConfigDefaultL2Test()
+	config.Sequencer.Bundles.Enable = true
+	l2info, l2node, client := CreateTestL2WithConfig(t, ctx, nil, config, true)
+	defer l2node.StopAndWait()
+	rpcClient, err := l2node.Stack.Attach()
+	Require(t, err)
+
+	l2info.GenerateAccount("User")
+	l2info.GenerateAccount("Unfunded")
+	deadline := time.Now().Add(30 * time.Second)
+
+	// The bundle's txs are sequenced in order in one block
+	fund := l2info.PrepareTx("Owner", "User", l2info.TransferGas, big.NewInt(params.Ether), nil)
+	spend := l2info.PrepareTx("User", "Owner", l2info.TransferGas, big.NewInt(params.Ether/10), nil)
+	receipt, err := sendBundle(ctx, rpcClient, deadline, fund, spend)
+	Require(t, err)
+	if receipt.BundleHash != execution.BundleHash(types.Transactions{fund, spend}) {
+		Fatal(t, "unexpected bundle hash", receipt.BundleHash)
+	}
+	block, err := client.BlockByHash(ctx, receipt.BlockHash)
+	Require(t, err)
+	// The first tx of a block is the internal start block tx
+	txes := block.Transactions()
+	if len(txes) != 3 || txes[1].Hash() != fund.Hash() || txes[2].Hash() != spend.Hash() {
+		Fatal(t, "bundle block has unexpected transactions", len(txes))
+	}
+
+	// If any tx fails, none are sequenced
+	transfer := l2info.PrepareTx("Owner", "User", l2info.TransferGas, big.NewInt(params.Ether), nil)
+	unfunded := l2info.PrepareTx("Unfunded", "Owner", l2info.TransferGas, big.NewInt(params.Ether), nil)
+	_, err = sendBundle(ctx, rpcClient, deadline, transfer, unfunded)
+	if err == nil {
+		Fatal(t, "bundle with unfunded transaction was sequenced")
+	}
+	if _, err := client.TransactionReceipt(ctx, transfer.Hash()); !errors.Is(err, ethereum.NotFound) {
+		Fatal(t, "transaction from failed bundle was sequenced", err)
+	}
+	l2info.GetInfoWithPrivKey("Owner").Nonce--
+
+	// The bundle goes through once the unfunded account is funded
+	TransferBalance(t, "Owner", "Unfunded", big.NewInt(params.Ether*2), l2info, client, ctx)
+	transfer = l2info.PrepareTx("Owner", "User", l2info.TransferGas, big.NewInt(params.Ether), nil)
+	_, err = sendBundle(ctx, rpcClient, deadline, transfer, unfunded)
+	Require(t, err)
+	_, err = EnsureTxSucceeded(ctx, client, transfer)
+	Require(t, err)
+
+	// Bundles past their deadline are refused
+	late := l2info.PrepareTx("Owner", "User", l2info.TransferGas, big.NewInt(1), nil)
+	if _, err := sendBundle(ctx, rpcClient, time.Now().Add(-time.Second), late); err == nil {
+		Fatal(t, "bundle past its deadline was accepted")
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/arbnode/execution"
+)
+
+// sendTxsConcurrently submits the transactions in order, without waiting for
+// one to be sequenced before submitting the next, and returns their receipts.
+func sendTxsConcurrently(t *testing.T, ctx context.Context, client *ethclient.Client, txs []*types.Transaction, spacing time.Duration) []*types.Receipt {
+	t.Helper()
+	errs := make([]error, len(txs))
+	var wg sync.WaitGroup
+	for i, tx := range txs {
+		if i > 0 {
+			time.Sleep(spacing)
+		}
+		wg.Add(1)
+		go func(i int, tx *types.Transaction) {
+			defer wg.Done()
+			errs[i] = client.SendTransaction(ctx, tx)
+		}(i, tx)
+	}
+	wg.Wait()
+	receipts := make([]*types.Receipt, len(txs))
+	for i, tx := range txs {
+		Require(t, errs[i])
+		receipt, err := EnsureTxSucceeded(ctx, client, tx)
+		Require(t, err)
+		receipts[i] = receipt
+	}
+	return receipts
+}
+
+func sequencedBefore(a, b *types.Receipt) bool {
+	if cmp := a.BlockNumber.Cmp(b.BlockNumber); cmp != 0 {
+		return cmp < 0
+	}
+	return a.TransactionIndex < b.TransactionIndex
+}
+
+func TestSequencerPriorityFeeOrdering(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := arbnode.ConfigDefaultL2Test()
+	config.Sequencer.Ordering.Policy = execution.TxOrderingPriorityFee
+	config.Sequencer.Ordering.PriorityFeeWindow = time.Second
+	l2info := NewArbTestInfo(t, params.ArbitrumDevTestChainConfig().ChainID)
+	senders := []string{"Low", "High", "Mid"}
+	tips := []int64{1, 3, 2}
+	for _, name := range senders {
+		l2info.GenerateGenesisAccount(name, big.NewInt(params.Ether))
+	}
+	l2info, node, client := CreateTestL2WithConfig(t, ctx, l2info, config, false)
+	defer node.StopAndWait()
+
+	to := l2info.GetAddress("Owner")
+	feeCap := new(big.Int).Mul(l2info.GasPrice, big.NewInt(2))
+	var txs []*types.Transaction
+	for i, name := range senders {
+		txs = append(txs, l2info.SignTxAs(name, &types.DynamicFeeTx{
+			To:        &to,
+			Gas:       l2info.TransferGas,
+			GasFeeCap: feeCap,
+			GasTipCap: big.NewInt(tips[i] * params.GWei / 100),
+			Value:     big.NewInt(1),
+			Nonce:     0,
+		}))
+	}
+	receipts := sendTxsConcurrently(t, ctx, client, txs, 10*time.Millisecond)
+
+	low, high, mid := receipts[0], receipts[1], receipts[2]
+	if low.BlockNumber.Cmp(high.BlockNumber) != 0 || mid.BlockNumber.Cmp(high.BlockNumber) != 0 {
+		Fatal(t, "transactions received within the priority fee window were sequenced into blocks", low.BlockNumber, high.BlockNumber, mid.BlockNumber)
+	}
+	if !sequencedBefore(high, mid) || !sequencedBefore(mid, low) {
+		Fatal(t, "transactions weren't sequenced in priority fee order: got indices", high.TransactionIndex, mid.TransactionIndex, low.TransactionIndex)
+	}
+}
+
+func TestSequencerExpressLaneOrdering(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l2info := NewArbTestInfo(t, params.ArbitrumDevTestChainConfig().ChainID)
+	l2info.GenerateGenesisAccount("Express", big.NewInt(params.Ether))
+	l2info.GenerateGenesisAccount("Other", big.NewInt(params.Ether))
+
+	advantage := time.Second
+	config := arbnode.ConfigDefaultL2Test()
+	config.Sequencer.Ordering.Policy = execution.TxOrderingExpressLane
+	config.Sequencer.Ordering.ExpressLaneAddress = l2info.GetAddress("Express").Hex()
+	config.Sequencer.Ordering.ExpressLaneAdvantage = advantage
+	l2info, node, client := CreateTestL2WithConfig(t, ctx, l2info, config, false)
+	defer node.StopAndWait()
+
+	// The other sender submits first, but the express lane transaction
+	// should still be sequenced ahead of it.
+	txs := []*types.Transaction{
+		l2info.PrepareTx("Other", "Owner", l2info.TransferGas, big.NewInt(1), nil),
+		l2info.PrepareTx("Express", "Owner", l2info.TransferGas, big.NewInt(1), nil),
+	}
+	start := time.Now()
+	receipts := sendTxsConcurrently(t, ctx, client, txs, 50*time.Millisecond)
+	other, express := receipts[0], receipts[1]
+	if !sequencedBefore(express, other) {
+		Fatal(t, "express lane transaction was sequenced after an earlier transaction: express block", express.BlockNumber, "index", express.TransactionIndex, "other block", other.BlockNumber, "index", other.TransactionIndex)
+	}
+	if elapsed := time.Since(start); elapsed < advantage {
+		Fatal(t, "transaction from outside the express lane was sequenced after", elapsed, "which is less than the express lane advantage", advantage)
+	}
+}
@@ -92,7 +92,7 @@ func TestRelayedSequencerFeed(t *testing.T) {
 	config.Chain.ID = bigChainId.Uint64()
 
 	feedErrChan := make(chan error, 10)
-	currentRelay, err := relay.NewRelay(&config, feedErrChan)
+	currentRelay, err := relay.NewRelay(func() *relay.Config { return &config }, feedErrChan)
 	Require(t, err)
 	err = currentRelay.Start(ctx)
 	Require(t, err)
@@ -0,0 +1,123 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package raft
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	flag "github.com/spf13/pflag"
+)
+
+type Config struct {
+	Addr            string        `koanf:"addr"`
+	Peers           []string      `koanf:"peers"`
+	ElectionTimeout time.Duration `koanf:"election-timeout"`
+	RequestTimeout  time.Duration `koanf:"request-timeout"`
+	MaxLogEntries   int           `koanf:"max-log-entries"`
+	TLS             TLSConfig     `koanf:"tls"`
+}
+
+// TLSConfig is used to mutually authenticate the peers: every peer presents
+// a certificate signed by the CA, and only accepts peers which do the same.
+type TLSConfig struct {
+	Cert string `koanf:"cert"`
+	Key  string `koanf:"key"`
+	CA   string `koanf:"ca"`
+}
+
+var DefaultConfig = Config{
+	Addr:            "",
+	Peers:           []string{},
+	ElectionTimeout: time.Second,
+	RequestTimeout:  500 * time.Millisecond,
+	MaxLogEntries:   1024,
+	TLS:             DefaultTLSConfig,
+}
+
+var TestConfig = Config{
+	Addr:            "",
+	Peers:           []string{},
+	ElectionTimeout: 200 * time.Millisecond,
+	RequestTimeout:  time.Second,
+	MaxLogEntries:   16,
+	TLS:             DefaultTLSConfig,
+}
+
+var DefaultTLSConfig = TLSConfig{
+	Cert: "",
+	Key:  "",
+	CA:   "",
+}
+
+func ConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".addr", DefaultConfig.Addr, "address to serve the raft protocol on")
+	f.StringSlice(prefix+".peers", DefaultConfig.Peers, "all members of the raft cluster, including this node, as <id>=<host:port>")
+	f.Duration(prefix+".election-timeout", DefaultConfig.ElectionTimeout, "time without hearing from the leader before starting an election")
+	f.Duration(prefix+".request-timeout", DefaultConfig.RequestTimeout, "timeout for a proposal to be committed, including forwarding it to the leader")
+	f.Int(prefix+".max-log-entries", DefaultConfig.MaxLogEntries, "number of log entries kept before they're compacted into a snapshot")
+	TLSConfigAddOptions(prefix+".tls", f)
+}
+
+func TLSConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".cert", DefaultTLSConfig.Cert, "certificate this node presents to the other members, signed by the CA and valid for the host of its peer address")
+	f.String(prefix+".key", DefaultTLSConfig.Key, "private key of the certificate")
+	f.String(prefix+".ca", DefaultTLSConfig.CA, "CA certificate which members of the raft cluster must present a certificate signed by")
+}
+
+func (c *TLSConfig) Validate() error {
+	if c.Cert == "" || c.Key == "" || c.CA == "" {
+		return errors.New("raft tls cert, key and ca must be set, as members only talk to each other over mutually authenticated TLS")
+	}
+	return nil
+}
+
+type Peer struct {
+	ID   string
+	Addr string
+}
+
+func (c *Config) ParsePeers() ([]Peer, error) {
+	peers := make([]Peer, 0, len(c.Peers))
+	seen := make(map[string]bool)
+	for _, entry := range c.Peers {
+		// Ids may be urls themselves, so split at the last '='
+		sep := strings.LastIndex(entry, "=")
+		if sep <= 0 {
+			return nil, fmt.Errorf("raft peer %q isn't of the form <id>=<host:port>", entry)
+		}
+		peer := Peer{ID: entry[:sep], Addr: entry[sep+1:]}
+		if _, _, err := net.SplitHostPort(peer.Addr); err != nil {
+			return nil, fmt.Errorf("raft peer %v has invalid address: %w", peer.ID, err)
+		}
+		if seen[peer.ID] {
+			return nil, fmt.Errorf("duplicate raft peer %v", peer.ID)
+		}
+		seen[peer.ID] = true
+		peers = append(peers, peer)
+	}
+	return peers, nil
+}
+
+func (c *Config) Validate() error {
+	if c.Addr == "" {
+		return errors.New("raft addr must be set")
+	}
+	if _, err := c.ParsePeers(); err != nil {
+		return err
+	}
+	if c.ElectionTimeout < 10*time.Millisecond {
+		return errors.New("raft election-timeout must be at least 10ms")
+	}
+	if c.RequestTimeout <= 0 {
+		return errors.New("raft request-timeout must be positive")
+	}
+	if c.MaxLogEntries <= 0 {
+		return errors.New("raft max-log-entries must be positive")
+	}
+	return c.TLS.Validate()
+}
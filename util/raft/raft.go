@@ -0,0 +1,412 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package raft replicates a state machine between a fixed set of peers using
+// hashicorp/raft. It's meant for small, frequently updated state: the log is
+// compacted into a snapshot of the state machine every few entries. Peers only
+// talk to each other over mutually authenticated TLS, and followers forward
+// proposals to the leader over the same connections.
+package raft
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/hashicorp/go-hclog"
+	hraft "github.com/hashicorp/raft"
+
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+var (
+	ErrNotLeader  = errors.New("not the raft leader")
+	ErrNoLeader   = errors.New("raft leader unknown")
+	ErrNotStarted = errors.New("raft node not started")
+)
+
+// StateMachine is replicated by applying the same commands in the same order on every peer.
+type StateMachine interface {
+	// Apply applies a committed command, and returns the result given to its proposer.
+	// The time is when the leader appended the command to its log, and is the same on every peer.
+	Apply(command []byte, time time.Time) []byte
+	Snapshot() ([]byte, error)
+	Restore(snapshot []byte) error
+}
+
+type Node struct {
+	stopwaiter.StopWaiter
+
+	id        string
+	config    Config // warning: static
+	peers     []Peer
+	tlsConfig *tls.Config
+	fsm       *fsm
+	logs      *logStore
+	snapshots *snapshotStore
+
+	// Set by Start
+	layer     *streamLayer
+	transport *hraft.NetworkTransport
+	raft      *hraft.Raft
+}
+
+// NewNode creates a raft node with the given id, which must be one of the
+// configured peers. Its log is persisted in db, which shouldn't be shared.
+func NewNode(id string, config *Config, db ethdb.KeyValueStore, sm StateMachine) (*Node, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	peers, err := config.ParsePeers()
+	if err != nil {
+		return nil, err
+	}
+	isPeer := false
+	for _, peer := range peers {
+		if peer.ID == id {
+			isPeer = true
+		}
+	}
+	if !isPeer {
+		return nil, fmt.Errorf("raft node %v isn't one of the configured peers", id)
+	}
+	tlsConfig, err := loadTLSConfig(&config.TLS)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := newLogStore(db)
+	if err != nil {
+		return nil, err
+	}
+	return &Node{
+		id:        id,
+		config:    *config,
+		peers:     peers,
+		tlsConfig: tlsConfig,
+		fsm:       &fsm{sm: sm},
+		logs:      logs,
+		snapshots: &snapshotStore{db: db},
+	}, nil
+}
+
+func (n *Node) peerAddr(id string) string {
+	for _, peer := range n.peers {
+		if peer.ID == id {
+			return peer.Addr
+		}
+	}
+	return ""
+}
+
+func (n *Node) raftConfig() *hraft.Config {
+	logger := hclog.NewInterceptLogger(&hclog.LoggerOptions{
+		Name:   "raft",
+		Output: io.Discard,
+	})
+	logger.RegisterSink(logSink{})
+
+	config := hraft.DefaultConfig()
+	config.LocalID = hraft.ServerID(n.id)
+	config.HeartbeatTimeout = n.config.ElectionTimeout
+	config.ElectionTimeout = n.config.ElectionTimeout
+	config.LeaderLeaseTimeout = n.config.ElectionTimeout / 2
+	config.SnapshotInterval = n.config.ElectionTimeout
+	config.SnapshotThreshold = uint64(n.config.MaxLogEntries)
+	config.TrailingLogs = uint64(n.config.MaxLogEntries)
+	config.Logger = logger
+	return config
+}
+
+func (n *Node) Start(ctxIn context.Context) error {
+	config := n.raftConfig()
+	if err := hraft.ValidateConfig(config); err != nil {
+		return err
+	}
+	listener, err := tls.Listen("tcp", n.config.Addr, n.tlsConfig)
+	if err != nil {
+		return fmt.Errorf("error listening for raft peers: %w", err)
+	}
+	n.layer = newStreamLayer(listener, n.tlsConfig, n.peerAddr(n.id))
+	n.transport = hraft.NewNetworkTransportWithConfig(&hraft.NetworkTransportConfig{
+		Stream:  n.layer,
+		MaxPool: 3,
+		Timeout: n.config.RequestTimeout,
+		Logger:  config.Logger,
+	})
+	// Every peer bootstraps the same configuration, so it doesn't matter which does first
+	hasState, err := hraft.HasExistingState(n.logs, n.logs, n.snapshots)
+	if err == nil && !hasState {
+		var configuration hraft.Configuration
+		for _, peer := range n.peers {
+			configuration.Servers = append(configuration.Servers, hraft.Server{
+				Suffrage: hraft.Voter,
+				ID:       hraft.ServerID(peer.ID),
+				Address:  hraft.ServerAddress(peer.Addr),
+			})
+		}
+		err = hraft.BootstrapCluster(config, n.logs, n.logs, n.snapshots, n.transport, configuration)
+	}
+	if err == nil {
+		n.raft, err = hraft.NewRaft(config, n.fsm, n.logs, n.logs, n.snapshots, n.transport)
+	}
+	if err != nil {
+		_ = n.transport.Close()
+		return fmt.Errorf("error starting raft: %w", err)
+	}
+
+	n.StopWaiter.Start(ctxIn, n)
+	n.LaunchThread(n.acceptConns)
+	n.LaunchThread(func(ctx context.Context) {
+		<-ctx.Done()
+		if err := n.raft.Shutdown().Error(); err != nil {
+			log.Warn("error shutting down raft", "err", err)
+		}
+		if err := n.transport.Close(); err != nil {
+			log.Warn("error closing raft transport", "err", err)
+		}
+	})
+	return nil
+}
+
+func (n *Node) ID() string {
+	return n.id
+}
+
+// Leader returns the id of the current leader, or "" if it's unknown.
+func (n *Node) Leader() string {
+	if n.raft == nil {
+		return ""
+	}
+	_, id := n.raft.LeaderWithID()
+	return string(id)
+}
+
+func (n *Node) IsLeader() bool {
+	return n.raft != nil && n.raft.State() == hraft.Leader
+}
+
+// Propose replicates the command, forwarding it to the leader if this node
+// isn't the leader, and returns the state machine's result once it's committed.
+func (n *Node) Propose(ctx context.Context, command []byte) ([]byte, error) {
+	if n.raft == nil {
+		return nil, ErrNotStarted
+	}
+	if n.IsLeader() {
+		return n.proposeLocal(ctx, command)
+	}
+	leaderID := n.Leader()
+	if leaderID == "" {
+		return nil, ErrNoLeader
+	}
+	result, err := n.forward(ctx, leaderID, command)
+	if err != nil {
+		return nil, fmt.Errorf("forwarding proposal to raft leader %v: %w", leaderID, err)
+	}
+	return result, nil
+}
+
+func (n *Node) proposeLocal(ctx context.Context, command []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, n.config.RequestTimeout)
+	defer cancel()
+	future := n.raft.Apply(command, n.config.RequestTimeout)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- future.Error()
+	}()
+	select {
+	case err := <-errChan:
+		if errors.Is(err, hraft.ErrNotLeader) || errors.Is(err, hraft.ErrLeadershipLost) {
+			return nil, fmt.Errorf("%w: %v", ErrNotLeader, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result, ok := future.Response().([]byte)
+		if !ok {
+			return nil, fmt.Errorf("unexpected raft state machine result %T", future.Response())
+		}
+		return result, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// connDeadline bounds a forwarded proposal by the request timeout, and the context's deadline if it's earlier.
+func (n *Node) connDeadline(ctx context.Context) time.Time {
+	deadline := time.Now().Add(n.config.RequestTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	return deadline
+}
+
+func (n *Node) forward(ctx context.Context, leaderID string, command []byte) ([]byte, error) {
+	deadline := n.connDeadline(ctx)
+	conn, err := n.layer.dial(n.peerAddr(leaderID), proposeConnType, time.Until(deadline))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	if err := json.NewEncoder(conn).Encode(&proposeRequest{Command: command}); err != nil {
+		return nil, err
+	}
+	var res proposeResponse
+	if err := json.NewDecoder(conn).Decode(&res); err != nil {
+		return nil, err
+	}
+	if res.NotLeader {
+		return nil, fmt.Errorf("%w: %v", ErrNotLeader, res.Error)
+	}
+	if res.Error != "" {
+		return nil, errors.New(res.Error)
+	}
+	return res.Result, nil
+}
+
+// acceptConns reads the type of every connection from a peer, and hands it to raft or handles the forwarded proposal.
+func (n *Node) acceptConns(ctx context.Context) {
+	for {
+		conn, err := n.layer.listener.Accept()
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Error("error accepting raft connection", "err", err)
+			}
+			return
+		}
+		err = n.LaunchThreadSafe(func(ctx context.Context) {
+			n.handleConn(ctx, conn)
+		})
+		if err != nil {
+			conn.Close()
+			return
+		}
+	}
+}
+
+func (n *Node) handleConn(ctx context.Context, conn net.Conn) {
+	// Reading the type completes the TLS handshake, which rejects peers without a certificate signed by the CA
+	if err := conn.SetReadDeadline(time.Now().Add(n.config.RequestTimeout)); err != nil {
+		conn.Close()
+		return
+	}
+	connType := make([]byte, 1)
+	if _, err := io.ReadFull(conn, connType); err != nil {
+		log.Warn("rejected raft connection", "remote", conn.RemoteAddr(), "err", err)
+		conn.Close()
+		return
+	}
+	switch connType[0] {
+	case raftConnType:
+		if err := conn.SetReadDeadline(time.Time{}); err != nil || !n.layer.handOff(conn) {
+			conn.Close()
+		}
+	case proposeConnType:
+		defer conn.Close()
+		n.handleProposal(ctx, conn)
+	default:
+		log.Warn("rejected raft connection of unknown type", "remote", conn.RemoteAddr(), "type", connType[0])
+		conn.Close()
+	}
+}
+
+func (n *Node) handleProposal(ctx context.Context, conn net.Conn) {
+	if err := conn.SetDeadline(n.connDeadline(ctx)); err != nil {
+		return
+	}
+	var req proposeRequest
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		log.Warn("error reading forwarded raft proposal", "remote", conn.RemoteAddr(), "err", err)
+		return
+	}
+	var res proposeResponse
+	if n.IsLeader() {
+		result, err := n.proposeLocal(ctx, req.Command)
+		if err != nil {
+			res.Error = err.Error()
+			res.NotLeader = errors.Is(err, ErrNotLeader)
+		} else {
+			res.Result = result
+		}
+	} else {
+		res.Error = ErrNotLeader.Error()
+		res.NotLeader = true
+	}
+	if err := json.NewEncoder(conn).Encode(&res); err != nil {
+		log.Warn("error answering forwarded raft proposal", "remote", conn.RemoteAddr(), "err", err)
+	}
+}
+
+// fsm applies raft's committed log to the state machine.
+type fsm struct {
+	sm StateMachine
+}
+
+func (f *fsm) Apply(entry *hraft.Log) interface{} {
+	return f.sm.Apply(entry.Data, entry.AppendedAt)
+}
+
+func (f *fsm) Snapshot() (hraft.FSMSnapshot, error) {
+	snapshot, err := f.sm.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return fsmSnapshot(snapshot), nil
+}
+
+func (f *fsm) Restore(reader io.ReadCloser) error {
+	defer reader.Close()
+	snapshot, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	return f.sm.Restore(snapshot)
+}
+
+type fsmSnapshot []byte
+
+func (s fsmSnapshot) Persist(sink hraft.SnapshotSink) error {
+	if _, err := sink.Write(s); err != nil {
+		_ = sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+func (s fsmSnapshot) Release() {}
+
+// logSink writes hashicorp/raft's logs to the node's log.
+type logSink struct{}
+
+func (logSink) Accept(name string, level hclog.Level, msg string, args ...interface{}) {
+	ctx := []interface{}{"module", name}
+	for _, arg := range args {
+		if format, ok := arg.(hclog.Format); ok && len(format) > 0 {
+			if formatString, ok := format[0].(string); ok {
+				arg = fmt.Sprintf(formatString, format[1:]...)
+			}
+		}
+		ctx = append(ctx, arg)
+	}
+	switch level {
+	case hclog.Error:
+		log.Error(msg, ctx...)
+	case hclog.Warn:
+		log.Warn(msg, ctx...)
+	case hclog.Info:
+		log.Info(msg, ctx...)
+	case hclog.Debug:
+		log.Debug(msg, ctx...)
+	default:
+		log.Trace(msg, ctx...)
+	}
+}
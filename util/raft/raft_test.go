@@ -0,0 +1,282 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package raft
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+
+	"github.com/offchainlabs/nitro/util/raft/rafttest"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+// counter sums the commands it's given
+type counter struct {
+	mutex sync.Mutex
+	sum   uint64
+}
+
+func (c *counter) Apply(command []byte, _ time.Time) []byte {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.sum += binary.BigEndian.Uint64(command)
+	return binary.BigEndian.AppendUint64(nil, c.sum)
+}
+
+func (c *counter) Snapshot() ([]byte, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return json.Marshal(c.sum)
+}
+
+func (c *counter) Restore(snapshot []byte) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return json.Unmarshal(snapshot, &c.sum)
+}
+
+func (c *counter) get() uint64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.sum
+}
+
+type testCluster struct {
+	t        *testing.T
+	config   Config
+	addrs    []string
+	dbs      []ethdb.Database
+	nodes    []*Node
+	counters []*counter
+}
+
+func newTestCluster(t *testing.T, ctx context.Context, size int) *testCluster {
+	c := &testCluster{t: t, config: TestConfig}
+	c.config.TLS.Cert, c.config.TLS.Key, c.config.TLS.CA = rafttest.TLSFiles(t)
+	c.addrs = rafttest.Addrs(t, size)
+	for i, addr := range c.addrs {
+		c.config.Peers = append(c.config.Peers, fmt.Sprintf("node%d=%s", i, addr))
+		c.dbs = append(c.dbs, rawdb.NewMemoryDatabase())
+	}
+	c.nodes = make([]*Node, size)
+	c.counters = make([]*counter, size)
+	for i := 0; i < size; i++ {
+		c.start(ctx, i)
+	}
+	return c
+}
+
+func (c *testCluster) start(ctx context.Context, i int) {
+	c.counters[i] = &counter{}
+	config := c.config
+	config.Addr = c.addrs[i]
+	node, err := NewNode(fmt.Sprintf("node%d", i), &config, c.dbs[i], c.counters[i])
+	Require(c.t, err)
+	Require(c.t, node.Start(ctx))
+	c.nodes[i] = node
+}
+
+func (c *testCluster) stop(i int) {
+	c.nodes[i].StopAndWait()
+	c.nodes[i] = nil
+}
+
+func (c *testCluster) stopAll() {
+	for i, node := range c.nodes {
+		if node != nil {
+			c.stop(i)
+		}
+	}
+}
+
+func (c *testCluster) waitFor(what string, check func() bool) {
+	c.t.Helper()
+	for start := time.Now(); time.Since(start) < 20*c.config.ElectionTimeout; time.Sleep(c.config.ElectionTimeout / 10) {
+		if check() {
+			return
+		}
+	}
+	Fail(c.t, "timed out waiting for", what)
+}
+
+func (c *testCluster) waitForLeader() int {
+	c.t.Helper()
+	leader := -1
+	c.waitFor("raft leader", func() bool {
+		for i, node := range c.nodes {
+			if node != nil && node.IsLeader() {
+				leader = i
+				return true
+			}
+		}
+		return false
+	})
+	return leader
+}
+
+func (c *testCluster) waitForSum(sum uint64) {
+	c.t.Helper()
+	c.waitFor(fmt.Sprint("sum ", sum), func() bool {
+		for i, node := range c.nodes {
+			if node != nil && c.counters[i].get() != sum {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func propose(t *testing.T, ctx context.Context, node *Node, value uint64) uint64 {
+	t.Helper()
+	result, err := node.Propose(ctx, binary.BigEndian.AppendUint64(nil, value))
+	Require(t, err)
+	return binary.BigEndian.Uint64(result)
+}
+
+func TestRaftReplication(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster := newTestCluster(t, ctx, 3)
+	defer cluster.stopAll()
+	leader := cluster.waitForLeader()
+	follower := (leader + 1) % 3
+
+	// Proposals are forwarded to the leader, and applied in order on every node
+	if sum := propose(t, ctx, cluster.nodes[leader], 1); sum != 1 {
+		Fail(t, "unexpected sum", sum)
+	}
+	if sum := propose(t, ctx, cluster.nodes[follower], 2); sum != 3 {
+		Fail(t, "unexpected sum", sum)
+	}
+	cluster.waitForSum(3)
+
+	// The remaining nodes elect a new leader, and still have a quorum
+	cluster.stop(leader)
+	newLeader := cluster.waitForLeader()
+	expected := uint64(3)
+	for i := uint64(0); i < uint64(cluster.config.MaxLogEntries)*3; i++ {
+		expected += i
+		if sum := propose(t, ctx, cluster.nodes[newLeader], i); sum != expected {
+			Fail(t, "unexpected sum", sum, "expected", expected)
+		}
+	}
+	cluster.waitForSum(expected)
+	cluster.waitFor("log compaction", func() bool {
+		first, err := cluster.nodes[newLeader].logs.FirstIndex()
+		Require(t, err)
+		return first > 3
+	})
+
+	// The old leader catches up from the compacted log's snapshot after restarting
+	cluster.start(ctx, leader)
+	cluster.waitForSum(expected)
+}
+
+func TestRaftNoQuorum(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster := newTestCluster(t, ctx, 3)
+	defer cluster.stopAll()
+	leader := cluster.waitForLeader()
+	propose(t, ctx, cluster.nodes[leader], 1)
+	cluster.stop((leader + 1) % 3)
+	cluster.stop((leader + 2) % 3)
+
+	proposeCtx, cancelPropose := context.WithTimeout(ctx, cluster.config.ElectionTimeout)
+	defer cancelPropose()
+	if _, err := cluster.nodes[leader].Propose(proposeCtx, binary.BigEndian.AppendUint64(nil, 1)); err == nil {
+		Fail(t, "proposal committed without a quorum")
+	}
+	if sum := cluster.counters[leader].get(); sum != 1 {
+		Fail(t, "uncommitted proposal was applied, sum", sum)
+	}
+}
+
+func TestRaftRejectsUnauthenticatedPeers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster := newTestCluster(t, ctx, 1)
+	defer cluster.stopAll()
+	cluster.waitForLeader()
+	propose(t, ctx, cluster.nodes[0], 1)
+
+	// A certificate signed by another CA, or no certificate at all, isn't accepted
+	otherCert, otherKey, _ := rafttest.TLSFiles(t)
+	otherConfig := cluster.config.TLS
+	otherConfig.Cert = otherCert
+	otherConfig.Key = otherKey
+	otherTLSConfig, err := loadTLSConfig(&otherConfig)
+	Require(t, err)
+	noCertTLSConfig := otherTLSConfig.Clone()
+	noCertTLSConfig.Certificates = nil
+	for _, tlsConfig := range []*tls.Config{otherTLSConfig, noCertTLSConfig} {
+		layer := &streamLayer{tlsConfig: tlsConfig}
+		conn, err := layer.dial(cluster.addrs[0], proposeConnType, time.Second)
+		if err != nil {
+			continue
+		}
+		Require(t, conn.SetDeadline(time.Now().Add(time.Second)))
+		err = json.NewEncoder(conn).Encode(&proposeRequest{Command: binary.BigEndian.AppendUint64(nil, 2)})
+		if err == nil {
+			var res proposeResponse
+			err = json.NewDecoder(conn).Decode(&res)
+		}
+		conn.Close()
+		if err == nil {
+			Fail(t, "unauthenticated peer's proposal was answered")
+		}
+	}
+	if sum := cluster.counters[0].get(); sum != 1 {
+		Fail(t, "unauthenticated peer's proposal was applied, sum", sum)
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	cert, key, ca := rafttest.TLSFiles(t)
+	validConfig := TestConfig
+	validConfig.Addr = "localhost:1234"
+	validConfig.Peers = []string{"node0=localhost:1234"}
+	validConfig.TLS = TLSConfig{Cert: cert, Key: key, CA: ca}
+	Require(t, validConfig.Validate())
+
+	for _, peers := range [][]string{
+		{"node0"},
+		{"=localhost:1234"},
+		{"node0=http://localhost:1234"},
+		{"node0=localhost:1234", "node0=localhost:1235"},
+	} {
+		config := validConfig
+		config.Peers = peers
+		if err := config.Validate(); err == nil {
+			Fail(t, "invalid peers passed validation", peers)
+		}
+	}
+	config := validConfig
+	config.TLS.CA = ""
+	if err := config.Validate(); err == nil {
+		Fail(t, "config without tls passed validation")
+	}
+	if _, err := NewNode("node1", &validConfig, rawdb.NewMemoryDatabase(), &counter{}); err == nil {
+		Fail(t, "created node that isn't a peer")
+	}
+}
+
+func Require(t *testing.T, err error, printables ...interface{}) {
+	t.Helper()
+	testhelpers.RequireImpl(t, err, printables...)
+}
+
+func Fail(t *testing.T, printables ...interface{}) {
+	t.Helper()
+	testhelpers.FailImpl(t, printables...)
+}
@@ -0,0 +1,280 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package raft
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	hraft "github.com/hashicorp/raft"
+)
+
+var (
+	logBoundsKey    = []byte("logBounds")    // contains the first and last log index
+	snapshotMetaKey = []byte("snapshotMeta") // contains the metadata of the last snapshot
+	snapshotDataKey = []byte("snapshotData") // contains the last snapshot of the state machine
+	logPrefix       = []byte("l")            // maps a log index to a log entry
+	stablePrefix    = []byte("s")            // maps a key to raft's stable state, such as the current term
+)
+
+// hashicorp/raft tells missing keys apart from other errors by this message
+var errKeyNotFound = errors.New("not found")
+
+func logKey(index uint64) []byte {
+	key := make([]byte, len(logPrefix)+8)
+	copy(key, logPrefix)
+	binary.BigEndian.PutUint64(key[len(logPrefix):], index)
+	return key
+}
+
+func stableKey(key []byte) []byte {
+	return append(append([]byte{}, stablePrefix...), key...)
+}
+
+func readJSON(db ethdb.KeyValueReader, key []byte, value interface{}) (bool, error) {
+	has, err := db.Has(key)
+	if err != nil || !has {
+		return false, err
+	}
+	data, err := db.Get(key)
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(data, value)
+}
+
+func writeJSON(db ethdb.KeyValueWriter, key []byte, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return db.Put(key, data)
+}
+
+type logBounds struct {
+	First uint64 `json:"first"`
+	Last  uint64 `json:"last"`
+}
+
+// logStore persists raft's log and stable state in the node's database.
+type logStore struct {
+	db ethdb.KeyValueStore
+
+	mutex  sync.Mutex
+	bounds logBounds
+}
+
+func newLogStore(db ethdb.KeyValueStore) (*logStore, error) {
+	s := &logStore{db: db}
+	if _, err := readJSON(db, logBoundsKey, &s.bounds); err != nil {
+		return nil, fmt.Errorf("reading raft log bounds: %w", err)
+	}
+	return s, nil
+}
+
+func (s *logStore) FirstIndex() (uint64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.bounds.First, nil
+}
+
+func (s *logStore) LastIndex() (uint64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.bounds.Last, nil
+}
+
+func (s *logStore) GetLog(index uint64, entry *hraft.Log) error {
+	found, err := readJSON(s.db, logKey(index), entry)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return hraft.ErrLogNotFound
+	}
+	return nil
+}
+
+func (s *logStore) StoreLog(entry *hraft.Log) error {
+	return s.StoreLogs([]*hraft.Log{entry})
+}
+
+func (s *logStore) StoreLogs(entries []*hraft.Log) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	bounds := s.bounds
+	batch := s.db.NewBatch()
+	for _, entry := range entries {
+		if err := writeJSON(batch, logKey(entry.Index), entry); err != nil {
+			return err
+		}
+		if bounds.First == 0 || entry.Index < bounds.First {
+			bounds.First = entry.Index
+		}
+		if entry.Index > bounds.Last {
+			bounds.Last = entry.Index
+		}
+	}
+	if err := writeJSON(batch, logBoundsKey, &bounds); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	s.bounds = bounds
+	return nil
+}
+
+func (s *logStore) DeleteRange(min, max uint64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	bounds := s.bounds
+	batch := s.db.NewBatch()
+	for index := min; index <= max; index++ {
+		if err := batch.Delete(logKey(index)); err != nil {
+			return err
+		}
+	}
+	if min <= bounds.First {
+		bounds.First = max + 1
+	}
+	if max >= bounds.Last {
+		bounds.Last = min - 1
+	}
+	if bounds.First > bounds.Last {
+		bounds = logBounds{}
+	}
+	if err := writeJSON(batch, logBoundsKey, &bounds); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	s.bounds = bounds
+	return nil
+}
+
+func (s *logStore) Set(key []byte, value []byte) error {
+	return s.db.Put(stableKey(key), value)
+}
+
+func (s *logStore) Get(key []byte) ([]byte, error) {
+	has, err := s.db.Has(stableKey(key))
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, errKeyNotFound
+	}
+	return s.db.Get(stableKey(key))
+}
+
+func (s *logStore) SetUint64(key []byte, value uint64) error {
+	return s.Set(key, binary.BigEndian.AppendUint64(nil, value))
+}
+
+func (s *logStore) GetUint64(key []byte) (uint64, error) {
+	value, err := s.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	if len(value) != 8 {
+		return 0, fmt.Errorf("raft stable value %q has invalid length %v", key, len(value))
+	}
+	return binary.BigEndian.Uint64(value), nil
+}
+
+// snapshotStore keeps the last snapshot of the state machine in the node's database.
+// The state machine is small, so the snapshot is buffered in memory while it's written.
+type snapshotStore struct {
+	db ethdb.KeyValueStore
+
+	mutex sync.Mutex
+}
+
+func (s *snapshotStore) Create(version hraft.SnapshotVersion, index, term uint64, configuration hraft.Configuration, configurationIndex uint64, _ hraft.Transport) (hraft.SnapshotSink, error) {
+	return &snapshotSink{
+		store: s,
+		meta: hraft.SnapshotMeta{
+			Version:            version,
+			ID:                 fmt.Sprintf("%d-%d-%d", term, index, time.Now().UnixMilli()),
+			Index:              index,
+			Term:               term,
+			Configuration:      configuration,
+			ConfigurationIndex: configurationIndex,
+		},
+	}, nil
+}
+
+func (s *snapshotStore) List() ([]*hraft.SnapshotMeta, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var meta hraft.SnapshotMeta
+	found, err := readJSON(s.db, snapshotMetaKey, &meta)
+	if err != nil || !found {
+		return nil, err
+	}
+	return []*hraft.SnapshotMeta{&meta}, nil
+}
+
+func (s *snapshotStore) Open(id string) (*hraft.SnapshotMeta, io.ReadCloser, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var meta hraft.SnapshotMeta
+	found, err := readJSON(s.db, snapshotMetaKey, &meta)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !found || meta.ID != id {
+		return nil, nil, fmt.Errorf("raft snapshot %v not found", id)
+	}
+	data, err := s.db.Get(snapshotDataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &meta, io.NopCloser(bytes.NewReader(data)), nil
+}
+
+type snapshotSink struct {
+	store *snapshotStore
+	meta  hraft.SnapshotMeta
+	data  bytes.Buffer
+}
+
+func (s *snapshotSink) Write(p []byte) (int, error) {
+	return s.data.Write(p)
+}
+
+// Close replaces the stored snapshot with this one
+func (s *snapshotSink) Close() error {
+	s.meta.Size = int64(s.data.Len())
+	s.store.mutex.Lock()
+	defer s.store.mutex.Unlock()
+	batch := s.store.db.NewBatch()
+	if err := batch.Put(snapshotDataKey, s.data.Bytes()); err != nil {
+		return err
+	}
+	if err := writeJSON(batch, snapshotMetaKey, &s.meta); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+func (s *snapshotSink) ID() string {
+	return s.meta.ID
+}
+
+func (s *snapshotSink) Cancel() error {
+	return nil
+}
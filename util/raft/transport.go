@@ -0,0 +1,151 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package raft
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	hraft "github.com/hashicorp/raft"
+)
+
+// Every connection between peers starts with a byte saying what it's for,
+// so raft's own traffic and forwarded proposals share the listener.
+const (
+	raftConnType    byte = 1
+	proposeConnType byte = 2
+)
+
+var errTransportClosed = errors.New("raft transport closed")
+
+type proposeRequest struct {
+	Command []byte `json:"command"`
+}
+
+type proposeResponse struct {
+	Result    []byte `json:"result,omitempty"`
+	Error     string `json:"error,omitempty"`
+	NotLeader bool   `json:"notLeader,omitempty"`
+}
+
+func loadTLSConfig(config *TLSConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(config.Cert, config.Key)
+	if err != nil {
+		return nil, fmt.Errorf("error loading raft tls certificate and private key: %w", err)
+	}
+	caCert, err := os.ReadFile(config.CA)
+	if err != nil {
+		return nil, fmt.Errorf("error reading raft tls CA: %w", err)
+	}
+	caPool := x509.NewCertPool()
+	if !caPool.AppendCertsFromPEM(caCert) {
+		return nil, errors.New("error parsing raft tls CA")
+	}
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      caPool,
+		ClientCAs:    caPool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}, nil
+}
+
+// peerAddr is the address a peer is configured with, which the others dial.
+type peerAddr string
+
+func (a peerAddr) Network() string {
+	return "tcp"
+}
+
+func (a peerAddr) String() string {
+	return string(a)
+}
+
+// streamLayer carries raft's traffic over mutually authenticated TLS. Raft's
+// connections are handed to it through Accept, once the node read their type.
+type streamLayer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	addr      peerAddr
+
+	raftConns chan net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newStreamLayer(listener net.Listener, tlsConfig *tls.Config, addr string) *streamLayer {
+	return &streamLayer{
+		listener:  listener,
+		tlsConfig: tlsConfig,
+		addr:      peerAddr(addr),
+		raftConns: make(chan net.Conn),
+		closed:    make(chan struct{}),
+	}
+}
+
+func (l *streamLayer) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.raftConns:
+		return conn, nil
+	case <-l.closed:
+		return nil, errTransportClosed
+	}
+}
+
+// handOff passes an accepted raft connection to raft, and returns false if the transport is closed.
+func (l *streamLayer) handOff(conn net.Conn) bool {
+	select {
+	case l.raftConns <- conn:
+		return true
+	case <-l.closed:
+		return false
+	}
+}
+
+func (l *streamLayer) Close() error {
+	var err error
+	l.closeOnce.Do(func() {
+		close(l.closed)
+		err = l.listener.Close()
+	})
+	return err
+}
+
+func (l *streamLayer) Addr() net.Addr {
+	return l.addr
+}
+
+func (l *streamLayer) Dial(address hraft.ServerAddress, timeout time.Duration) (net.Conn, error) {
+	return l.dial(string(address), raftConnType, timeout)
+}
+
+func (l *streamLayer) dial(address string, connType byte, timeout time.Duration) (net.Conn, error) {
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: timeout},
+		Config:    l.tlsConfig,
+	}
+	conn, err := dialer.Dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if _, err := conn.Write([]byte{connType}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := conn.SetWriteDeadline(time.Time{}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
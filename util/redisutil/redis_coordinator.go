@@ -2,9 +2,11 @@ package redisutil
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 
@@ -19,10 +21,31 @@ const PRIORITIES_KEY string = "coordinator.priorities"            // Read only
 const WANTS_LOCKOUT_KEY_PREFIX string = "coordinator.liveliness." // Per server. Only written by self
 const MESSAGE_KEY_PREFIX string = "coordinator.msg."              // Per Message. Only written by sequencer holding CHOSEN
 const SIGNATURE_KEY_PREFIX string = "coordinator.msg.sig."        // Per Message. Only written by sequencer holding CHOSEN
+const HISTORY_KEY string = "coordinator.history"                  // List of coordinator events, newest first. Appended to by every sequencer
 const WANTS_LOCKOUT_VAL string = "OK"
 const INVALID_VAL string = "INVALID"
 const INVALID_URL string = "<?INVALID-URL?>"
 
+// Coordinator event types
+const (
+	EVENT_ACQUIRED        string = "acquired"
+	EVENT_RELEASED        string = "released"
+	EVENT_LOCKOUT_EXPIRED string = "lockout-expired"
+	EVENT_HANDOFF         string = "handoff"
+	EVENT_HANDOFF_FAILED  string = "handoff-failed"
+)
+
+// CoordinatorEvent records a change of the chosen sequencer, as seen by the sequencer reporting it
+type CoordinatorEvent struct {
+	Time      time.Time `json:"time"`
+	Sequencer string    `json:"sequencer"`
+	Event     string    `json:"event"`
+	// The sequencer expected to be chosen after the event, if known
+	Chosen   string               `json:"chosen,omitempty"`
+	MsgCount arbutil.MessageIndex `json:"msgCount"`
+	Reason   string               `json:"reason,omitempty"`
+}
+
 type RedisCoordinator struct {
 	Client redis.UniversalClient
 }
@@ -102,6 +125,36 @@ func (rc *RedisCoordinator) GetLiveliness(ctx context.Context) ([]string, error)
 	return livelinessList, nil
 }
 
+// RecordEvent prepends the event to the history, keeping at most maxLength events
+func (rc *RedisCoordinator) RecordEvent(ctx context.Context, event *CoordinatorEvent, maxLength int) error {
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	pipe := rc.Client.TxPipeline()
+	pipe.LPush(ctx, HISTORY_KEY, eventBytes)
+	pipe.LTrim(ctx, HISTORY_KEY, 0, int64(maxLength)-1)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// GetHistory returns up to count of the latest coordinator events, newest first. A count of 0 returns all of them.
+func (rc *RedisCoordinator) GetHistory(ctx context.Context, count int) ([]CoordinatorEvent, error) {
+	entries, err := rc.Client.LRange(ctx, HISTORY_KEY, 0, int64(count)-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	history := make([]CoordinatorEvent, 0, len(entries))
+	for _, entry := range entries {
+		var event CoordinatorEvent
+		if err := json.Unmarshal([]byte(entry), &event); err != nil {
+			return nil, fmt.Errorf("invalid coordinator history entry %q: %w", entry, err)
+		}
+		history = append(history, event)
+	}
+	return history, nil
+}
+
 func MessageKeyFor(pos arbutil.MessageIndex) string {
 	return fmt.Sprintf("%s%d", MESSAGE_KEY_PREFIX, pos)
 }
@@ -0,0 +1,326 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package wsbroadcastserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	flag "github.com/spf13/pflag"
+
+	"github.com/offchainlabs/nitro/util/metricsutil"
+)
+
+// APIKeyConfig configures access to the feed with API keys, passed in the
+// HTTPHeaderFeedAPIKey header or the APIKeyQueryParam query parameter.
+// Clients with a key are limited by the tier of the key instead of by IP.
+//
+// Tiers is a JSON list like
+// [{"name":"premium","max-connections":50,"max-bandwidth":10000000,"client-delay":"0s"}]
+// where max-bandwidth is in bytes per second shared by all of a key's
+// connections (messages over it are delayed), zero limits mean unlimited,
+// and an unset client-delay means the broadcaster's client-delay. Keys is a
+// JSON list like
+// [{"name":"acme","key":"<secret>","tier":"premium"}].
+type APIKeyConfig struct {
+	Enable  bool   `koanf:"enable" reload:"hot"`
+	Require bool   `koanf:"require" reload:"hot"`
+	Tiers   string `koanf:"tiers" reload:"hot"`
+	Keys    string `koanf:"keys" reload:"hot"`
+}
+
+func (c *APIKeyConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	_, err := parseAPIKeys(c.Tiers, c.Keys)
+	return err
+}
+
+var DefaultAPIKeyConfig = APIKeyConfig{
+	Enable:  false,
+	Require: false,
+	Tiers:   "",
+	Keys:    "",
+}
+
+func APIKeyConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultAPIKeyConfig.Enable, "enable API keys, which exempt clients from the per-IP connection limits and apply the limits of their tier instead")
+	f.Bool(prefix+".require", DefaultAPIKeyConfig.Require, "reject clients without a valid API key")
+	f.String(prefix+".tiers", DefaultAPIKeyConfig.Tiers, "JSON list of API key tiers, each with a name, max-connections, max-bandwidth in bytes per second and client-delay")
+	f.String(prefix+".keys", DefaultAPIKeyConfig.Keys, "JSON list of API keys, each with a name, key and tier")
+}
+
+type APIKeyConfigFetcher func() *APIKeyConfig
+
+type apiKeyTierConfig struct {
+	Name           string `json:"name"`
+	MaxConnections int    `json:"max-connections"`
+	MaxBandwidth   int64  `json:"max-bandwidth"`
+	ClientDelay    string `json:"client-delay"`
+}
+
+type apiKeyEntryConfig struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+	Tier string `json:"tier"`
+}
+
+type APIKeyTier struct {
+	Name           string
+	MaxConnections int
+	MaxBandwidth   int64
+	// Nil to use the broadcaster's client delay
+	ClientDelay *time.Duration
+}
+
+// APIKey is a client's key. Its name identifies it in logs and metrics, as
+// the key itself is secret.
+type APIKey struct {
+	Name string
+	Tier *APIKeyTier
+}
+
+type apiKeySet struct {
+	byKey  map[string]*APIKey
+	byName map[string]*APIKey
+}
+
+func parseAPIKeys(tiersJSON, keysJSON string) (*apiKeySet, error) {
+	var tierConfigs []apiKeyTierConfig
+	if tiersJSON != "" {
+		if err := json.Unmarshal([]byte(tiersJSON), &tierConfigs); err != nil {
+			return nil, fmt.Errorf("parsing API key tiers: %w", err)
+		}
+	}
+	tiers := make(map[string]*APIKeyTier, len(tierConfigs))
+	for _, tc := range tierConfigs {
+		if tc.Name == "" {
+			return nil, errors.New("API key tier has no name")
+		}
+		if _, exists := tiers[tc.Name]; exists {
+			return nil, fmt.Errorf("duplicate API key tier %v", tc.Name)
+		}
+		if tc.MaxConnections < 0 || tc.MaxBandwidth < 0 {
+			return nil, fmt.Errorf("API key tier %v has a negative limit", tc.Name)
+		}
+		tier := &APIKeyTier{
+			Name:           tc.Name,
+			MaxConnections: tc.MaxConnections,
+			MaxBandwidth:   tc.MaxBandwidth,
+		}
+		if tc.ClientDelay != "" {
+			delay, err := time.ParseDuration(tc.ClientDelay)
+			if err != nil {
+				return nil, fmt.Errorf("API key tier %v has invalid client-delay: %w", tc.Name, err)
+			}
+			tier.ClientDelay = &delay
+		}
+		tiers[tc.Name] = tier
+	}
+
+	var keyConfigs []apiKeyEntryConfig
+	if keysJSON != "" {
+		if err := json.Unmarshal([]byte(keysJSON), &keyConfigs); err != nil {
+			return nil, fmt.Errorf("parsing API keys: %w", err)
+		}
+	}
+	set := &apiKeySet{
+		byKey:  make(map[string]*APIKey, len(keyConfigs)),
+		byName: make(map[string]*APIKey, len(keyConfigs)),
+	}
+	for _, kc := range keyConfigs {
+		if kc.Name == "" || kc.Key == "" {
+			return nil, errors.New("API key must have a name and key")
+		}
+		if _, exists := set.byName[kc.Name]; exists {
+			return nil, fmt.Errorf("duplicate API key name %v", kc.Name)
+		}
+		if _, exists := set.byKey[kc.Key]; exists {
+			return nil, fmt.Errorf("API key %v duplicates another key", kc.Name)
+		}
+		tier, ok := tiers[kc.Tier]
+		if !ok {
+			return nil, fmt.Errorf("API key %v has unknown tier %q", kc.Name, kc.Tier)
+		}
+		apiKey := &APIKey{Name: kc.Name, Tier: tier}
+		set.byKey[kc.Key] = apiKey
+		set.byName[kc.Name] = apiKey
+	}
+	return set, nil
+}
+
+type apiKeyUsage struct {
+	connections int
+	// Bandwidth token bucket, in bytes, which may go negative to let
+	// messages larger than the bucket through
+	tokens     float64
+	lastRefill time.Time
+
+	connectionsGauge metrics.Gauge
+	bytesCounter     metrics.Counter
+	limitedCounter   metrics.Counter
+}
+
+// APIKeyLimiter looks up API keys and enforces the limits of their tiers.
+// Limits are tracked by key name, so they carry over when a key's tier or
+// secret is changed by reloading the configuration.
+type APIKeyLimiter struct {
+	mutex sync.Mutex
+
+	config APIKeyConfigFetcher
+	// The configuration keys was parsed from, to reparse when it changes
+	parsedTiers string
+	parsedKeys  string
+	keys        *apiKeySet
+	usage       map[string]*apiKeyUsage
+}
+
+func NewAPIKeyLimiter(configFetcher APIKeyConfigFetcher) *APIKeyLimiter {
+	return &APIKeyLimiter{
+		config: configFetcher,
+		keys:   &apiKeySet{},
+		usage:  make(map[string]*apiKeyUsage),
+	}
+}
+
+func (l *APIKeyLimiter) currentKeys() *apiKeySet {
+	config := l.config()
+	if l.keys.byKey != nil && config.Tiers == l.parsedTiers && config.Keys == l.parsedKeys {
+		return l.keys
+	}
+	keys, err := parseAPIKeys(config.Tiers, config.Keys)
+	if err != nil {
+		// Validation should have caught this, so keep using the last keys
+		log.Error("invalid API key configuration", "err", err)
+		if l.keys.byKey == nil {
+			l.keys = &apiKeySet{byKey: map[string]*APIKey{}, byName: map[string]*APIKey{}}
+		}
+		return l.keys
+	}
+	l.keys = keys
+	l.parsedTiers = config.Tiers
+	l.parsedKeys = config.Keys
+	return keys
+}
+
+// getUsage must be called with the mutex held.
+func (l *APIKeyLimiter) getUsage(name string) *apiKeyUsage {
+	usage, ok := l.usage[name]
+	if !ok {
+		metricName := metricsutil.CanonicalizeMetricName(name)
+		usage = &apiKeyUsage{
+			connectionsGauge: metrics.GetOrRegisterGauge("arb/feed/apikey/"+metricName+"/connections", nil),
+			bytesCounter:     metrics.GetOrRegisterCounter("arb/feed/apikey/"+metricName+"/bytes", nil),
+			limitedCounter:   metrics.GetOrRegisterCounter("arb/feed/apikey/"+metricName+"/limited", nil),
+		}
+		l.usage[name] = usage
+	}
+	return usage
+}
+
+// Lookup returns the API key with the given secret, or nil if there's none.
+func (l *APIKeyLimiter) Lookup(key string) *APIKey {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.currentKeys().byKey[key]
+}
+
+// Valid is whether the key is still configured, as it may have been removed
+// since the client connected.
+func (l *APIKeyLimiter) Valid(apiKey *APIKey) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	_, ok := l.currentKeys().byName[apiKey.Name]
+	return ok
+}
+
+// tier returns the current tier of the key, which may have changed since the
+// client connected. It must be called with the mutex held.
+func (l *APIKeyLimiter) tier(apiKey *APIKey) *APIKeyTier {
+	if current, ok := l.currentKeys().byName[apiKey.Name]; ok {
+		return current.Tier
+	}
+	return apiKey.Tier
+}
+
+func (l *APIKeyLimiter) isAllowedImpl(apiKey *APIKey) bool {
+	maxConnections := l.tier(apiKey).MaxConnections
+	usage := l.getUsage(apiKey.Name)
+	if maxConnections > 0 && usage.connections >= maxConnections {
+		usage.limitedCounter.Inc(1)
+		return false
+	}
+	return true
+}
+
+func (l *APIKeyLimiter) IsAllowed(apiKey *APIKey) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.isAllowedImpl(apiKey)
+}
+
+func (l *APIKeyLimiter) Register(apiKey *APIKey) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if !l.isAllowedImpl(apiKey) {
+		return false
+	}
+	usage := l.getUsage(apiKey.Name)
+	usage.connections++
+	usage.connectionsGauge.Update(int64(usage.connections))
+	return true
+}
+
+func (l *APIKeyLimiter) Release(apiKey *APIKey) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	usage := l.getUsage(apiKey.Name)
+	if usage.connections == 0 {
+		log.Error("BUG: Unbalanced APIKeyLimiter.Release calls", "apiKey", apiKey.Name)
+		return
+	}
+	usage.connections--
+	usage.connectionsGauge.Update(int64(usage.connections))
+}
+
+// refill adds the bandwidth accumulated since the last refill to the usage's
+// token bucket. It must be called with the mutex held.
+func (usage *apiKeyUsage) refill(rate float64, now time.Time) {
+	// The bucket holds up to a second of bandwidth
+	if usage.lastRefill.IsZero() {
+		usage.tokens = rate
+	} else {
+		usage.tokens += now.Sub(usage.lastRefill).Seconds() * rate
+		if usage.tokens > rate {
+			usage.tokens = rate
+		}
+	}
+	usage.lastRefill = now
+}
+
+// ReserveBandwidth accounts for sending size bytes to a client with the key,
+// and returns how long to wait before sending them to stay within the key's
+// bandwidth limit.
+func (l *APIKeyLimiter) ReserveBandwidth(apiKey *APIKey, size int, now time.Time) time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	usage := l.getUsage(apiKey.Name)
+	usage.bytesCounter.Inc(int64(size))
+	rate := float64(l.tier(apiKey).MaxBandwidth)
+	if rate == 0 {
+		return 0
+	}
+	usage.refill(rate, now)
+	usage.tokens -= float64(size)
+	if usage.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-usage.tokens / rate * float64(time.Second))
+}
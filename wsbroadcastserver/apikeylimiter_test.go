@@ -0,0 +1,91 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package wsbroadcastserver
+
+import (
+	"testing"
+	"time"
+)
+
+const testAPIKeyTiers = `[
+	{"name":"free","max-connections":1,"max-bandwidth":1000,"client-delay":"1s"},
+	{"name":"premium","max-connections":3}
+]`
+
+func TestAPIKeyConnectionLimiting(t *testing.T) {
+	config := APIKeyConfig{
+		Enable: true,
+		Tiers:  testAPIKeyTiers,
+		Keys:   `[{"name":"alice","key":"secret-a","tier":"free"},{"name":"bob","key":"secret-b","tier":"premium"}]`,
+	}
+	Require(t, config.Validate())
+	l := NewAPIKeyLimiter(func() *APIKeyConfig { return &config })
+
+	Expect(t, l.Lookup("unknown") == nil, "unknown key was accepted")
+	alice := l.Lookup("secret-a")
+	Expect(t, alice != nil && alice.Name == "alice", "key not found")
+	Expect(t, *alice.Tier.ClientDelay == time.Second, "unexpected client delay", alice.Tier.ClientDelay)
+	bob := l.Lookup("secret-b")
+	Expect(t, bob.Tier.ClientDelay == nil, "unexpected client delay", bob.Tier.ClientDelay)
+
+	Expect(t, l.Register(alice))
+	Expect(t, !l.IsAllowed(alice))
+	Expect(t, !l.Register(alice))
+	// Limits are per key
+	Expect(t, l.Register(bob))
+	Expect(t, l.Register(bob))
+	l.Release(alice)
+	Expect(t, l.Register(alice))
+
+	// Reloading the keys applies the new tier to existing connections, and
+	// revokes removed keys
+	config.Keys = `[{"name":"alice","key":"new-secret-a","tier":"premium"}]`
+	Expect(t, l.Lookup("secret-a") == nil, "old key still accepted")
+	Expect(t, l.Valid(alice))
+	Expect(t, !l.Valid(bob))
+	Expect(t, l.Register(alice))
+	Expect(t, l.Register(alice))
+	Expect(t, !l.Register(alice))
+}
+
+func TestAPIKeyBandwidthLimiting(t *testing.T) {
+	config := APIKeyConfig{
+		Enable: true,
+		Tiers:  testAPIKeyTiers,
+		Keys:   `[{"name":"alice","key":"secret-a","tier":"free"},{"name":"bob","key":"secret-b","tier":"premium"}]`,
+	}
+	l := NewAPIKeyLimiter(func() *APIKeyConfig { return &config })
+	alice := l.Lookup("secret-a")
+	now := time.Now()
+
+	// A second of bandwidth is allowed in a burst
+	Expect(t, l.ReserveBandwidth(alice, 600, now) == 0)
+	Expect(t, l.ReserveBandwidth(alice, 400, now) == 0)
+	if wait := l.ReserveBandwidth(alice, 500, now); wait != 500*time.Millisecond {
+		Fail(t, "expected to wait 500ms, got", wait)
+	}
+	now = now.Add(time.Second)
+	if wait := l.ReserveBandwidth(alice, 1000, now); wait != 500*time.Millisecond {
+		Fail(t, "expected to wait 500ms, got", wait)
+	}
+
+	// Tiers without a bandwidth limit are never throttled
+	bob := l.Lookup("secret-b")
+	Expect(t, l.ReserveBandwidth(bob, 1_000_000_000, now) == 0)
+}
+
+func TestInvalidAPIKeyConfig(t *testing.T) {
+	for _, config := range []APIKeyConfig{
+		{Enable: true, Tiers: `not json`},
+		{Enable: true, Tiers: `[{"name":"free"}]`, Keys: `[{"name":"alice","key":"a","tier":"paid"}]`},
+		{Enable: true, Tiers: `[{"name":"free"}]`, Keys: `[{"name":"alice","key":"a","tier":"free"},{"name":"alice","key":"b","tier":"free"}]`},
+		{Enable: true, Tiers: `[{"name":"free"}]`, Keys: `[{"name":"alice","key":"a","tier":"free"},{"name":"bob","key":"a","tier":"free"}]`},
+		{Enable: true, Tiers: `[{"name":"free","client-delay":"soon"}]`},
+		{Enable: true, Tiers: `[{"name":"free","max-connections":-1}]`},
+	} {
+		if err := config.Validate(); err == nil {
+			Fail(t, "invalid config passed validation", config)
+		}
+	}
+}
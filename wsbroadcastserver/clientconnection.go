@@ -4,6 +4,7 @@
 package wsbroadcastserver
 
 import (
+	"compress/flate"
 	"context"
 	"fmt"
 	"math/rand"
@@ -37,10 +38,17 @@ type ClientConnection struct {
 	lastHeardUnix int64
 	out           chan []byte
 
-	compression bool
-	flateReader *wsflate.Reader
+	compression    bool
+	flateReader    *wsflate.Reader
+	binaryEncoding bool
+	filter         MessageFilter
+	apiKey         *APIKey
 
 	delay time.Duration
+
+	// Run by the writer thread before it sends any queued data
+	catchup            func(ctx context.Context) error
+	catchupFlateWriter *flate.Writer
 }
 
 func NewClientConnection(
@@ -50,6 +58,9 @@ func NewClientConnection(
 	requestedSeqNum arbutil.MessageIndex,
 	connectingIP net.IP,
 	compression bool,
+	binaryEncoding bool,
+	filter MessageFilter,
+	apiKey *APIKey,
 	delay time.Duration,
 ) *ClientConnection {
 	return &ClientConnection{
@@ -64,6 +75,9 @@ func NewClientConnection(
 		out:             make(chan []byte, clientManager.config().MaxSendQueue),
 		compression:     compression,
 		flateReader:     NewFlateReader(),
+		binaryEncoding:  binaryEncoding,
+		filter:          filter,
+		apiKey:          apiKey,
 		delay:           delay,
 	}
 }
@@ -76,9 +90,90 @@ func (cc *ClientConnection) Compression() bool {
 	return cc.compression
 }
 
+// BinaryEncoding is whether the client negotiated the binary encoding of
+// messages rather than JSON.
+func (cc *ClientConnection) BinaryEncoding() bool {
+	return cc.binaryEncoding
+}
+
+// Filter is the filter the client subscribed with, or nil if it's sent
+// every message.
+func (cc *ClientConnection) Filter() MessageFilter {
+	return cc.filter
+}
+
+// APIKey is the API key the client connected with, or nil if it had none.
+func (cc *ClientConnection) APIKey() *APIKey {
+	return cc.apiKey
+}
+
+// throttle waits until data of the given size can be sent within the
+// bandwidth limit of the client's API key. It returns false if the client
+// is stopped while waiting. Waiting holds up the client's queue rather than
+// dropping messages, so a client that stays over its limit is disconnected
+// once its queue is full, like any other slow client.
+func (cc *ClientConnection) throttle(ctx context.Context, size int) bool {
+	if cc.apiKey == nil {
+		return true
+	}
+	wait := cc.clientManager.apiKeyLimiter.ReserveBandwidth(cc.apiKey, size, time.Now())
+	if wait <= 0 {
+		return true
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// SetCatchup sets a function for the client's writer thread to run before it
+// sends any queued data, so that the messages the client missed can be sent
+// to it without holding up the client manager's thread. Data queued in the
+// meantime is sent afterwards. It must be called before the client is started.
+func (cc *ClientConnection) SetCatchup(catchup func(ctx context.Context) error) {
+	cc.catchup = catchup
+}
+
+// WriteCatchup serializes x and sends it to the client right away, rather
+// than queuing it. It must only be called from the client's catchup function.
+func (cc *ClientConnection) WriteCatchup(ctx context.Context, x interface{}) error {
+	if cc.filter != nil {
+		x = cc.filter.Filter(x)
+		if x == nil {
+			return nil
+		}
+	}
+	notCompressed, compressed, err := serializeMessage(&cc.catchupFlateWriter, x, !cc.compression, cc.compression, cc.binaryEncoding)
+	if err != nil {
+		return err
+	}
+	data := notCompressed.Bytes()
+	if cc.compression {
+		data = compressed.Bytes()
+	}
+	if !cc.throttle(ctx, len(data)) {
+		return ctx.Err()
+	}
+	return cc.writeRaw(data)
+}
+
 func (cc *ClientConnection) Start(parentCtx context.Context) {
 	cc.StopWaiter.Start(parentCtx, cc)
 	cc.LaunchThread(func(ctx context.Context) {
+		if cc.catchup != nil {
+			if err := cc.catchup(ctx); err != nil {
+				if ctx.Err() == nil {
+					logWarn(err, "error sending catchup to client")
+					cc.clientManager.Remove(cc)
+				}
+				return
+			}
+		}
+
 		if cc.delay != 0 {
 			var delayQueue [][]byte
 			t := time.NewTimer(cc.delay)
@@ -91,6 +186,9 @@ func (cc *ClientConnection) Start(parentCtx context.Context) {
 					delayQueue = append(delayQueue, data)
 				case <-t.C:
 					for _, data := range delayQueue {
+						if !cc.throttle(ctx, len(data)) {
+							return
+						}
 						err := cc.writeRaw(data)
 						if err != nil {
 							logWarn(err, "error writing data to client")
@@ -108,6 +206,9 @@ func (cc *ClientConnection) Start(parentCtx context.Context) {
 			case <-ctx.Done():
 				return
 			case data := <-cc.out:
+				if !cc.throttle(ctx, len(data)) {
+					return
+				}
 				err := cc.writeRaw(data)
 				if err != nil {
 					logWarn(err, "error writing data to client")
@@ -162,10 +263,17 @@ func (cc *ClientConnection) readRequest(ctx context.Context, timeout time.Durati
 }
 
 func (cc *ClientConnection) Write(x interface{}) error {
+	if cc.filter != nil {
+		x = cc.filter.Filter(x)
+		if x == nil {
+			return nil
+		}
+	}
+
 	cc.ioMutex.Lock()
 	defer cc.ioMutex.Unlock()
 
-	notCompressed, compressed, err := serializeMessage(cc.clientManager, x, !cc.compression, cc.compression)
+	notCompressed, compressed, err := serializeMessage(&cc.clientManager.flateWriter, x, !cc.compression, cc.compression, cc.binaryEncoding)
 	if err != nil {
 		return err
 	}
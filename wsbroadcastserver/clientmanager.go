@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"compress/flate"
 	"context"
+	"encoding"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -46,6 +47,22 @@ type CatchupBuffer interface {
 	GetMessageCount() int
 }
 
+// MessageFilter restricts the messages sent to a client which requested a
+// filtered subscription. It's called from the client manager's thread, and
+// from the client's own thread while it's sent catchup messages.
+type MessageFilter interface {
+	// Filter returns what to send the client in place of a broadcast
+	// message, or nil to send it nothing.
+	Filter(bm interface{}) interface{}
+	// Key identifies what the filter matches, so that a broadcast is only
+	// filtered and serialized once for all clients with the same filter.
+	Key() string
+}
+
+// MessageFilterParser parses the filter a client requested with
+// HTTPHeaderFeedFilter. The value is only valid for the duration of the call.
+type MessageFilterParser func(value []byte) (MessageFilter, error)
+
 // ClientManager manages client connections
 type ClientManager struct {
 	stopwaiter.StopWaiter
@@ -61,6 +78,7 @@ type ClientManager struct {
 	flateWriter   *flate.Writer
 
 	connectionLimiter *ConnectionLimiter
+	apiKeyLimiter     *APIKeyLimiter
 }
 
 type ClientConnectionAction struct {
@@ -79,6 +97,7 @@ func NewClientManager(poller netpoll.Poller, configFetcher BroadcasterConfigFetc
 		config:            configFetcher,
 		catchupBuffer:     catchupBuffer,
 		connectionLimiter: NewConnectionLimiter(func() *ConnectionLimiterConfig { return &configFetcher().ConnectionLimits }),
+		apiKeyLimiter:     NewAPIKeyLimiter(func() *APIKeyConfig { return &configFetcher().APIKeys }),
 	}
 }
 
@@ -89,7 +108,11 @@ func (cm *ClientManager) registerClient(ctx context.Context, clientConnection *C
 		}
 	}()
 
-	if cm.config().ConnectionLimits.Enable && !cm.connectionLimiter.Register(clientConnection.clientIp) {
+	if apiKey := clientConnection.APIKey(); apiKey != nil {
+		if !cm.apiKeyLimiter.Register(apiKey) {
+			return fmt.Errorf("Connection limited for API key %s", apiKey.Name)
+		}
+	} else if cm.config().ConnectionLimits.Enable && !cm.connectionLimiter.Register(clientConnection.clientIp) {
 		return fmt.Errorf("Connection limited %s", clientConnection.clientIp)
 	}
 
@@ -100,9 +123,7 @@ func (cm *ClientManager) registerClient(ctx context.Context, clientConnection *C
 	err, sent, elapsed := cm.catchupBuffer.OnRegisterClient(clientConnection)
 	if err != nil {
 		clientsTotalFailedRegisterCounter.Inc(1)
-		if cm.config().ConnectionLimits.Enable {
-			cm.connectionLimiter.Release(clientConnection.clientIp)
-		}
+		cm.releaseLimits(clientConnection)
 		return err
 	}
 	if cm.config().LogConnect {
@@ -123,9 +144,16 @@ func (cm *ClientManager) Register(
 	requestedSeqNum arbutil.MessageIndex,
 	connectingIP net.IP,
 	compression bool,
+	binaryEncoding bool,
+	filter MessageFilter,
+	apiKey *APIKey,
 ) *ClientConnection {
+	delay := cm.config().ClientDelay
+	if apiKey != nil && apiKey.Tier.ClientDelay != nil {
+		delay = *apiKey.Tier.ClientDelay
+	}
 	createClient := ClientConnectionAction{
-		NewClientConnection(conn, desc, cm, requestedSeqNum, connectingIP, compression, cm.config().ClientDelay),
+		NewClientConnection(conn, desc, cm, requestedSeqNum, connectingIP, compression, binaryEncoding, filter, apiKey, delay),
 		true,
 	}
 	cm.clientAction <- createClient
@@ -170,13 +198,21 @@ func (cm *ClientManager) removeClient(clientConnection *ClientConnection) {
 	}
 
 	cm.removeClientImpl(clientConnection)
-	if cm.config().ConnectionLimits.Enable {
-		cm.connectionLimiter.Release(clientConnection.clientIp)
-	}
+	cm.releaseLimits(clientConnection)
 
 	delete(cm.clientPtrMap, clientConnection)
 }
 
+// releaseLimits releases the connection counted against the client's API key,
+// or if it has none its IP.
+func (cm *ClientManager) releaseLimits(clientConnection *ClientConnection) {
+	if apiKey := clientConnection.APIKey(); apiKey != nil {
+		cm.apiKeyLimiter.Release(apiKey)
+	} else if cm.config().ConnectionLimits.Enable {
+		cm.connectionLimiter.Release(clientConnection.clientIp)
+	}
+}
+
 func (cm *ClientManager) Remove(clientConnection *ClientConnection) {
 	cm.clientAction <- ClientConnectionAction{
 		clientConnection,
@@ -204,36 +240,55 @@ func (cm *ClientManager) doBroadcast(bm interface{}) ([]*ClientConnection, error
 		return nil, err
 	}
 	config := cm.config()
-	//                                        /-> wsutil.Writer -> not compressed msg buffer
-	// bm -> json.Encoder -> io.MultiWriter -|
-	//                                        \-> cm.flateWriter -> wsutil.Writer -> compressed msg buffer
-
-	notCompressed, compressed, err := serializeMessage(cm, bm, !config.RequireCompression, config.EnableCompression)
-	if err != nil {
-		return nil, err
-	}
+	// Each distinct output is filtered and serialized at most once, and only
+	// if a client needs it
+	filtered := make(map[string]interface{})
+	serialized := make(map[broadcastOutput][]byte)
 
 	sendQueueTooLargeCount := 0
 	clientDeleteList := make([]*ClientConnection, 0, len(cm.clientPtrMap))
 	for client := range cm.clientPtrMap {
-		var data []byte
-		if client.Compression() {
-			if config.EnableCompression {
-				data = compressed.Bytes()
-			} else {
-				log.Warn("disconnecting because client has enabled compression, but compression support is disabled", "client", client.Name)
-				clientDeleteList = append(clientDeleteList, client)
-				continue
+		if client.Compression() && !config.EnableCompression {
+			log.Warn("disconnecting because client has enabled compression, but compression support is disabled", "client", client.Name)
+			clientDeleteList = append(clientDeleteList, client)
+			continue
+		}
+		if !client.Compression() && config.RequireCompression {
+			log.Warn("disconnecting because client has disabled compression, but compression support is required", "client", client.Name)
+			clientDeleteList = append(clientDeleteList, client)
+			continue
+		}
+		output := broadcastOutput{
+			compression:    client.Compression(),
+			binaryEncoding: client.BinaryEncoding(),
+		}
+		msg := bm
+		if filter := client.Filter(); filter != nil {
+			output.filtered = true
+			output.filterKey = filter.Key()
+			var ok bool
+			msg, ok = filtered[output.filterKey]
+			if !ok {
+				msg = filter.Filter(bm)
+				filtered[output.filterKey] = msg
 			}
-		} else {
-			if !config.RequireCompression {
-				data = notCompressed.Bytes()
-			} else {
-				log.Warn("disconnecting because client has disabled compression, but compression support is required", "client", client.Name)
-				clientDeleteList = append(clientDeleteList, client)
+			if msg == nil {
+				// Nothing in the message matched the client's filter
 				continue
 			}
 		}
+		data, ok := serialized[output]
+		if !ok {
+			notCompressed, compressed, err := serializeMessage(&cm.flateWriter, msg, !output.compression, output.compression, output.binaryEncoding)
+			if err != nil {
+				return nil, err
+			}
+			data = notCompressed.Bytes()
+			if output.compression {
+				data = compressed.Bytes()
+			}
+			serialized[output] = data
+		}
 		select {
 		case client.out <- data:
 		default:
@@ -254,36 +309,68 @@ func (cm *ClientManager) doBroadcast(bm interface{}) ([]*ClientConnection, error
 	return clientDeleteList, nil
 }
 
-func serializeMessage(cm *ClientManager, bm interface{}, enableNonCompressedOutput, enableCompressedOutput bool) (bytes.Buffer, bytes.Buffer, error) {
+// broadcastOutput identifies what's sent to a client for a broadcast.
+type broadcastOutput struct {
+	filtered       bool
+	filterKey      string
+	compression    bool
+	binaryEncoding bool
+}
+
+// serializeMessage encodes bm as JSON, or if binaryEncoding is set with its
+// MarshalBinary method, which bm must then implement. The flate writer is
+// created if it's nil, and must only be used by one thread.
+func serializeMessage(flateWriter **flate.Writer, bm interface{}, enableNonCompressedOutput, enableCompressedOutput, binaryEncoding bool) (bytes.Buffer, bytes.Buffer, error) {
 	var notCompressed bytes.Buffer
 	var compressed bytes.Buffer
 	writers := []io.Writer{}
 	var notCompressedWriter *wsutil.Writer
 	var compressedWriter *wsutil.Writer
+	opCode := ws.OpText
+	if binaryEncoding {
+		opCode = ws.OpBinary
+	}
 	if enableNonCompressedOutput {
-		notCompressedWriter = wsutil.NewWriter(&notCompressed, ws.StateServerSide, ws.OpText)
+		notCompressedWriter = wsutil.NewWriter(&notCompressed, ws.StateServerSide, opCode)
 		writers = append(writers, notCompressedWriter)
 	}
 	if enableCompressedOutput {
-		if cm.flateWriter == nil {
+		if *flateWriter == nil {
 			var err error
-			cm.flateWriter, err = flate.NewWriterDict(nil, DeflateCompressionLevel, GetStaticCompressorDictionary())
+			*flateWriter, err = flate.NewWriterDict(nil, DeflateCompressionLevel, GetStaticCompressorDictionary())
 			if err != nil {
 				return bytes.Buffer{}, bytes.Buffer{}, fmt.Errorf("unable to create flate writer: %w", err)
 			}
 		}
-		compressedWriter = wsutil.NewWriter(&compressed, ws.StateServerSide|ws.StateExtended, ws.OpText)
+		compressedWriter = wsutil.NewWriter(&compressed, ws.StateServerSide|ws.StateExtended, opCode)
 		var msg wsflate.MessageState
 		msg.SetCompressed(true)
 		compressedWriter.SetExtensions(&msg)
-		cm.flateWriter.Reset(compressedWriter)
-		writers = append(writers, cm.flateWriter)
+		(*flateWriter).Reset(compressedWriter)
+		writers = append(writers, *flateWriter)
 	}
 
+	//                                        /-> wsutil.Writer -> not compressed msg buffer
+	// bm -> json.Encoder -> io.MultiWriter -|
+	//                                        \-> flateWriter -> wsutil.Writer -> compressed msg buffer
 	multiWriter := io.MultiWriter(writers...)
-	encoder := json.NewEncoder(multiWriter)
-	if err := encoder.Encode(bm); err != nil {
-		return bytes.Buffer{}, bytes.Buffer{}, fmt.Errorf("unable to encode message: %w", err)
+	if binaryEncoding {
+		marshaler, ok := bm.(encoding.BinaryMarshaler)
+		if !ok {
+			return bytes.Buffer{}, bytes.Buffer{}, fmt.Errorf("message of type %T has no binary encoding", bm)
+		}
+		data, err := marshaler.MarshalBinary()
+		if err != nil {
+			return bytes.Buffer{}, bytes.Buffer{}, fmt.Errorf("unable to encode message: %w", err)
+		}
+		if _, err := multiWriter.Write(data); err != nil {
+			return bytes.Buffer{}, bytes.Buffer{}, fmt.Errorf("unable to write message: %w", err)
+		}
+	} else {
+		encoder := json.NewEncoder(multiWriter)
+		if err := encoder.Encode(bm); err != nil {
+			return bytes.Buffer{}, bytes.Buffer{}, fmt.Errorf("unable to encode message: %w", err)
+		}
 	}
 	if notCompressedWriter != nil {
 		if err := notCompressedWriter.Flush(); err != nil {
@@ -291,7 +378,7 @@ func serializeMessage(cm *ClientManager, bm interface{}, enableNonCompressedOutp
 		}
 	}
 	if compressedWriter != nil {
-		if err := cm.flateWriter.Close(); err != nil {
+		if err := (*flateWriter).Close(); err != nil {
 			return bytes.Buffer{}, bytes.Buffer{}, fmt.Errorf("unable to close flate writer: %w", err)
 		}
 		if err := compressedWriter.Flush(); err != nil {
@@ -315,6 +402,9 @@ func (cm *ClientManager) verifyClients() []*ClientConnection {
 		if diff > cm.config().ClientTimeout {
 			log.Debug("disconnecting because connection timed out", "client", client.Name)
 			clientDeleteList = append(clientDeleteList, client)
+		} else if apiKey := client.APIKey(); apiKey != nil && cm.config().APIKeys.Enable && !cm.apiKeyLimiter.Valid(apiKey) {
+			log.Info("disconnecting because API key was removed", "client", client.Name, "apiKey", apiKey.Name)
+			clientDeleteList = append(clientDeleteList, client)
 		} else {
 			err := client.Ping()
 			if err != nil {
@@ -7,9 +7,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/textproto"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync"
@@ -32,12 +34,27 @@ var (
 	HTTPHeaderFeedClientVersion       = textproto.CanonicalMIMEHeaderKey("Arbitrum-Feed-Client-Version")
 	HTTPHeaderRequestedSequenceNumber = textproto.CanonicalMIMEHeaderKey("Arbitrum-Requested-Sequence-Number")
 	HTTPHeaderChainId                 = textproto.CanonicalMIMEHeaderKey("Arbitrum-Chain-Id")
+	HTTPHeaderFeedEncoding            = textproto.CanonicalMIMEHeaderKey("Arbitrum-Feed-Encoding")
+	HTTPHeaderFeedFilter              = textproto.CanonicalMIMEHeaderKey("Arbitrum-Feed-Filter")
+	HTTPHeaderFeedAPIKey              = textproto.CanonicalMIMEHeaderKey("Arbitrum-Feed-Api-Key")
 )
 
+// APIKeyQueryParam is the query parameter clients which can't set headers
+// can pass their API key in.
+const APIKeyQueryParam = "api-key"
+
 const (
 	FeedServerVersion = 2
 	FeedClientVersion = 2
 	LivenessProbeURI  = "livenessprobe"
+	ReadinessProbeURI = "readinessprobe"
+)
+
+// Feed encodings a client can request with HTTPHeaderFeedEncoding. Clients
+// which don't request an encoding are sent JSON.
+const (
+	FeedEncodingJSON   = "json"
+	FeedEncodingBinary = "binary"
 )
 
 type BroadcasterConfig struct {
@@ -63,15 +80,64 @@ type BroadcasterConfig struct {
 	MaxCatchup         int                     `koanf:"max-catchup" reload:"hot"`
 	ConnectionLimits   ConnectionLimiterConfig `koanf:"connection-limits" reload:"hot"`
 	ClientDelay        time.Duration           `koanf:"client-delay" reload:"hot"`
+	EnableBinary       bool                    `koanf:"enable-binary" reload:"hot"`  // reloading will affect only new connections
+	EnableFilters      bool                    `koanf:"enable-filters" reload:"hot"` // reloading will affect only new connections
+	PersistentCatchup  PersistentCatchupConfig `koanf:"persistent-catchup" reload:"hot"`
+	APIKeys            APIKeyConfig            `koanf:"api-keys" reload:"hot"`
 }
 
 func (bc *BroadcasterConfig) Validate() error {
 	if !bc.EnableCompression && bc.RequireCompression {
 		return errors.New("require-compression cannot be true while enable-compression is false")
 	}
+	if err := bc.PersistentCatchup.Validate(); err != nil {
+		return err
+	}
+	return bc.APIKeys.Validate()
+}
+
+// PersistentCatchupConfig configures keeping the catchup buffer in a database
+// rather than in memory, so that it survives restarts.
+type PersistentCatchupConfig struct {
+	Enable      bool          `koanf:"enable"`
+	Directory   string        `koanf:"directory"`
+	DBEngine    string        `koanf:"db-engine"`
+	MaxMessages uint64        `koanf:"max-messages" reload:"hot"`
+	MaxAge      time.Duration `koanf:"max-age" reload:"hot"`
+}
+
+func (c *PersistentCatchupConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.Directory == "" {
+		return errors.New("persistent-catchup directory must be set")
+	}
+	if c.DBEngine != "leveldb" && c.DBEngine != "pebble" {
+		return fmt.Errorf(`invalid persistent-catchup db-engine choice: %q, allowed "leveldb" or "pebble"`, c.DBEngine)
+	}
+	if c.MaxMessages == 0 {
+		return errors.New("persistent-catchup max-messages must be positive")
+	}
 	return nil
 }
 
+func PersistentCatchupConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultPersistentCatchupConfig.Enable, "keep the catchup buffer in a database so that it survives restarts (max-catchup then only applies to the in-memory buffer)")
+	f.String(prefix+".directory", DefaultPersistentCatchupConfig.Directory, "directory of the catchup database")
+	f.String(prefix+".db-engine", DefaultPersistentCatchupConfig.DBEngine, "backing database implementation to use ('leveldb' or 'pebble')")
+	f.Uint64(prefix+".max-messages", DefaultPersistentCatchupConfig.MaxMessages, "maximum number of messages to keep in the catchup database")
+	f.Duration(prefix+".max-age", DefaultPersistentCatchupConfig.MaxAge, "maximum age of messages to keep in the catchup database (0 means no limit)")
+}
+
+var DefaultPersistentCatchupConfig = PersistentCatchupConfig{
+	Enable:      false,
+	Directory:   "",
+	DBEngine:    "leveldb",
+	MaxMessages: 100_000,
+	MaxAge:      24 * time.Hour,
+}
+
 type BroadcasterConfigFetcher func() *BroadcasterConfig
 
 func BroadcasterConfigAddOptions(prefix string, f *flag.FlagSet) {
@@ -97,6 +163,10 @@ func BroadcasterConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Int(prefix+".max-catchup", DefaultBroadcasterConfig.MaxCatchup, "the maximum size of the catchup buffer (-1 means unlimited)")
 	ConnectionLimiterConfigAddOptions(prefix+".connection-limits", f)
 	f.Duration(prefix+".client-delay", DefaultBroadcasterConfig.ClientDelay, "delay the first messages sent to each client by this amount")
+	f.Bool(prefix+".enable-binary", DefaultBroadcasterConfig.EnableBinary, "send messages in the binary encoding to clients which request it")
+	f.Bool(prefix+".enable-filters", DefaultBroadcasterConfig.EnableFilters, "allow clients to subscribe to only the messages matching a filter")
+	PersistentCatchupConfigAddOptions(prefix+".persistent-catchup", f)
+	APIKeyConfigAddOptions(prefix+".api-keys", f)
 }
 
 var DefaultBroadcasterConfig = BroadcasterConfig{
@@ -122,6 +192,10 @@ var DefaultBroadcasterConfig = BroadcasterConfig{
 	MaxCatchup:         -1,
 	ConnectionLimits:   DefaultConnectionLimiterConfig,
 	ClientDelay:        0,
+	EnableBinary:       true,
+	EnableFilters:      false,
+	PersistentCatchup:  DefaultPersistentCatchupConfig,
+	APIKeys:            DefaultAPIKeyConfig,
 }
 
 var DefaultTestBroadcasterConfig = BroadcasterConfig{
@@ -147,6 +221,10 @@ var DefaultTestBroadcasterConfig = BroadcasterConfig{
 	MaxCatchup:         -1,
 	ConnectionLimits:   DefaultConnectionLimiterConfig,
 	ClientDelay:        0,
+	EnableBinary:       true,
+	EnableFilters:      false,
+	PersistentCatchup:  DefaultPersistentCatchupConfig,
+	APIKeys:            DefaultAPIKeyConfig,
 }
 
 type WSBroadcastServer struct {
@@ -161,20 +239,33 @@ type WSBroadcastServer struct {
 	started       bool
 	clientManager *ClientManager
 	catchupBuffer CatchupBuffer
+	filterParser  MessageFilterParser
 	chainId       uint64
 	fatalErrChan  chan error
+	// Only read once started
+	readinessCheck func() error
 }
 
-func NewWSBroadcastServer(config BroadcasterConfigFetcher, catchupBuffer CatchupBuffer, chainId uint64, fatalErrChan chan error) *WSBroadcastServer {
+// NewWSBroadcastServer creates a broadcast server. If filterParser is nil,
+// clients can't request filtered subscriptions.
+func NewWSBroadcastServer(config BroadcasterConfigFetcher, catchupBuffer CatchupBuffer, filterParser MessageFilterParser, chainId uint64, fatalErrChan chan error) *WSBroadcastServer {
 	return &WSBroadcastServer{
 		config:        config,
 		started:       false,
 		catchupBuffer: catchupBuffer,
+		filterParser:  filterParser,
 		chainId:       chainId,
 		fatalErrChan:  fatalErrChan,
 	}
 }
 
+// SetReadinessCheck sets the check the readiness probe fails with if it
+// returns an error. Without one the server is ready once started. It must be
+// called before the server is started.
+func (s *WSBroadcastServer) SetReadinessCheck(check func() error) {
+	s.readinessCheck = check
+}
+
 func (s *WSBroadcastServer) Initialize() error {
 	if s.poller != nil {
 		return errors.New("broadcast server already initialized")
@@ -245,6 +336,10 @@ func (s *WSBroadcastServer) StartWithHeader(ctx context.Context, header ws.Hands
 		var feedClientVersionSeen bool
 		var connectingIP net.IP
 		var requestedSeqNum arbutil.MessageIndex
+		var binaryEncoding bool
+		var filter MessageFilter
+		var apiKeyValue string
+		var apiKey *APIKey
 		upgrader := ws.Upgrader{
 			OnRequest: func(uri []byte) error {
 				if strings.Contains(string(uri), LivenessProbeURI) {
@@ -252,6 +347,27 @@ func (s *WSBroadcastServer) StartWithHeader(ctx context.Context, header ws.Hands
 						ws.RejectionStatus(http.StatusOK),
 					)
 				}
+				if strings.Contains(string(uri), ReadinessProbeURI) {
+					if s.readinessCheck != nil {
+						if err := s.readinessCheck(); err != nil {
+							// The probe is unauthenticated, so the details
+							// are only logged
+							log.Warn("readiness check failed", "err", err)
+							return ws.RejectConnectionError(
+								ws.RejectionStatus(http.StatusServiceUnavailable),
+								ws.RejectionReason("not ready"),
+							)
+						}
+					}
+					return ws.RejectConnectionError(
+						ws.RejectionStatus(http.StatusOK),
+					)
+				}
+				if config.APIKeys.Enable {
+					if requestURI, err := url.ParseRequestURI(string(uri)); err == nil {
+						apiKeyValue = requestURI.Query().Get(APIKeyQueryParam)
+					}
+				}
 				return nil
 			},
 			OnHeader: func(key []byte, value []byte) error {
@@ -280,6 +396,33 @@ func (s *WSBroadcastServer) StartWithHeader(ctx context.Context, header ws.Hands
 						)
 					}
 					requestedSeqNum = arbutil.MessageIndex(num)
+				} else if headerName == HTTPHeaderFeedEncoding {
+					switch string(value) {
+					case FeedEncodingJSON:
+						binaryEncoding = false
+					case FeedEncodingBinary:
+						binaryEncoding = config.EnableBinary
+					default:
+						// Unknown encodings fall back to JSON
+						log.Debug("client requested unknown feed encoding", "encoding", string(value))
+					}
+				} else if headerName == HTTPHeaderFeedFilter {
+					if !config.EnableFilters || s.filterParser == nil {
+						return ws.RejectConnectionError(
+							ws.RejectionStatus(http.StatusBadRequest),
+							ws.RejectionReason("Feed filters are not supported by this server"),
+						)
+					}
+					var err error
+					filter, err = s.filterParser(value)
+					if err != nil {
+						return ws.RejectConnectionError(
+							ws.RejectionStatus(http.StatusBadRequest),
+							ws.RejectionReason(fmt.Sprintf("Malformed HTTP header %s: %v", HTTPHeaderFeedFilter, err)),
+						)
+					}
+				} else if headerName == HTTPHeaderFeedAPIKey {
+					apiKeyValue = string(value)
 				} else if headerName == HTTPHeaderCloudflareConnectingIP {
 					connectingIP = net.ParseIP(string(value))
 					log.Trace("Client IP parsed from header", "ip", connectingIP, "header", headerName, "value", string(value))
@@ -303,13 +446,40 @@ func (s *WSBroadcastServer) StartWithHeader(ctx context.Context, header ws.Hands
 					}
 				}
 
-				if config.ConnectionLimits.Enable && !s.clientManager.connectionLimiter.IsAllowed(connectingIP) {
+				if config.APIKeys.Enable {
+					if apiKeyValue != "" {
+						apiKey = s.clientManager.apiKeyLimiter.Lookup(apiKeyValue)
+						if apiKey == nil {
+							return nil, ws.RejectConnectionError(
+								ws.RejectionStatus(http.StatusUnauthorized),
+								ws.RejectionReason("Invalid API key."),
+							)
+						}
+					} else if config.APIKeys.Require {
+						return nil, ws.RejectConnectionError(
+							ws.RejectionStatus(http.StatusUnauthorized),
+							ws.RejectionReason("Missing API key."),
+						)
+					}
+				}
+
+				if apiKey != nil {
+					if !s.clientManager.apiKeyLimiter.IsAllowed(apiKey) {
+						return nil, ws.RejectConnectionError(
+							ws.RejectionStatus(http.StatusTooManyRequests),
+							ws.RejectionReason("Too many open feed connections for API key."),
+						)
+					}
+				} else if config.ConnectionLimits.Enable && !s.clientManager.connectionLimiter.IsAllowed(connectingIP) {
 					return nil, ws.RejectConnectionError(
 						ws.RejectionStatus(http.StatusTooManyRequests),
 						ws.RejectionReason("Too many open feed connections."),
 					)
 				}
 
+				if binaryEncoding {
+					return handshakeHeaders{header, binaryEncodingHeader}, nil
+				}
 				return header, nil
 			},
 			Negotiate: negotiate,
@@ -362,7 +532,7 @@ func (s *WSBroadcastServer) StartWithHeader(ctx context.Context, header ws.Hands
 		// Register incoming client in clientManager.
 		safeConn := writeDeadliner{conn, config.WriteTimeout}
 
-		client := s.clientManager.Register(safeConn, desc, requestedSeqNum, connectingIP, compressionAccepted)
+		client := s.clientManager.Register(safeConn, desc, requestedSeqNum, connectingIP, compressionAccepted, binaryEncoding, filter, apiKey)
 
 		// Subscribe to events about conn.
 		err = s.poller.Start(desc, func(ev netpoll.Event) {
@@ -539,3 +709,27 @@ func (d writeDeadliner) Write(p []byte) (int, error) {
 	}
 	return d.Conn.Write(p)
 }
+
+// binaryEncodingHeader confirms to a client that it will be sent binary
+// messages. Clients that don't see it in the response should expect JSON.
+var binaryEncodingHeader = ws.HandshakeHeaderHTTP(http.Header{
+	HTTPHeaderFeedEncoding: []string{FeedEncodingBinary},
+})
+
+// handshakeHeaders writes several handshake headers in turn.
+type handshakeHeaders []ws.HandshakeHeader
+
+func (h handshakeHeaders) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, header := range h {
+		if header == nil {
+			continue
+		}
+		n, err := header.WriteTo(w)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}